@@ -0,0 +1,47 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/dfaile/backstage-nobl9/internal/audit"
+	"github.com/dfaile/backstage-nobl9/internal/config"
+	"github.com/dfaile/backstage-nobl9/internal/secrets"
+)
+
+func main() {
+	path := flag.String("path", "", "Path to the audit log (default ~/.nobl9/audit.jsonl)")
+	keyFile := flag.String("key-file", "", "File holding the audit.key the log is signed with (default $NOBL9_BOT_AUDIT_KEY)")
+	flag.Parse()
+
+	if *path == "" {
+		defaultPath, err := config.DefaultAuditPath()
+		if err != nil {
+			log.Fatalf("Failed to locate audit log: %v", err)
+		}
+		*path = defaultPath
+	}
+
+	// The key stays off the command line, where it shows up in process listings
+	key := os.Getenv("NOBL9_BOT_AUDIT_KEY")
+	if *keyFile != "" {
+		var err error
+		key, err = secrets.ReadFile(*keyFile)
+		if err != nil {
+			log.Fatalf("Failed to read audit key: %v", err)
+		}
+	}
+
+	result, err := audit.Verify(*path, []byte(key))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Audit log verification failed: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("✅ Audit log intact: %d record(s) in %d file(s)\n", result.Records, len(result.Files))
+	if !result.Signed {
+		fmt.Println("⚠️  Checked without a key. Anyone able to write the log could rebuild its chain or remove its newest records. Set audit.key to sign it.")
+	}
+}
@@ -3,57 +3,148 @@ package main
 import (
 	"context"
 	"flag"
+	"fmt"
 	"log"
 	"os"
+	"os/signal"
+	"syscall"
 
+	"github.com/dfaile/backstage-nobl9/internal/audit"
 	"github.com/dfaile/backstage-nobl9/internal/bot"
+	"github.com/dfaile/backstage-nobl9/internal/config"
+	"github.com/dfaile/backstage-nobl9/internal/gitops"
+	"github.com/dfaile/backstage-nobl9/internal/identity"
+	"github.com/dfaile/backstage-nobl9/internal/logging"
 	"github.com/dfaile/backstage-nobl9/internal/nobl9"
+	"github.com/dfaile/backstage-nobl9/internal/tracing"
 )
 
 func main() {
 	// Command line flags
 	clientID := flag.String("client-id", "", "Nobl9 client ID")
-	clientSecret := flag.String("client-secret", "", "Nobl9 client secret")
+	clientSecret := flag.String("client-secret", "", "Nobl9 client secret or a secret reference such as vault:secret/data/nobl9#client_secret. A literal secret shows up in process listings, prefer --client-secret-file")
+	clientSecretFile := flag.String("client-secret-file", "", "File holding the Nobl9 client secret")
 	org := flag.String("organization", "", "Nobl9 organization")
 	baseURL := flag.String("url", "", "Nobl9 base URL")
+	configPath := flag.String("config", "", "Path to the bot config file in YAML, TOML or JSON (default config.yaml or ~/.nobl9/bot.yaml)")
+	overrides := config.Overrides{}
+	flag.Var(overrides, "set", "Override a config setting, e.g. --set rate_limits.max_retries=5 (repeatable)")
 	flag.Parse()
 
-	// Set environment variables if command line flags are provided
-	// This allows the Nobl9 SDK to pick them up automatically
-	if *clientID != "" {
-		os.Setenv("NOBL9_SDK_CLIENT_ID", *clientID)
+	if *clientSecretFile != "" {
+		if *clientSecret != "" {
+			log.Fatalf("Set either --client-secret or --client-secret-file, not both")
+		}
+		*clientSecret = "file:" + *clientSecretFile
 	}
-	if *clientSecret != "" {
-		os.Setenv("NOBL9_SDK_CLIENT_SECRET", *clientSecret)
+
+	// Flags take precedence over the config file and environment
+	for key, value := range map[string]string{
+		"nobl9.client_id":     *clientID,
+		"nobl9.client_secret": *clientSecret,
+		"nobl9.organization":  *org,
+		"nobl9.base_url":      *baseURL,
+	} {
+		if value != "" {
+			overrides[key] = value
+		}
 	}
-	if *org != "" {
-		os.Setenv("NOBL9_SDK_ORGANIZATION", *org)
+
+	cfg, err := config.Load(config.LoadOptions{Path: *configPath, Overrides: overrides})
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
 	}
-	if *baseURL != "" {
-		os.Setenv("NOBL9_SDK_URL", *baseURL)
+
+	// Create context for the bot
+	ctx := context.Background()
+
+	// Export traces of every message and Nobl9 call
+	if cfg.Tracing.Enabled() {
+		shutdown, err := tracing.Setup(ctx, cfg.Tracing.Options())
+		if err != nil {
+			log.Fatalf("Failed to set up tracing: %v", err)
+		}
+		defer shutdown(ctx)
 	}
 
-	// Create Nobl9 client using the SDK's built-in configuration system
-	// This will automatically read from:
+	// Each tenant gets its own bot, the CLI talks to the bot of its user's tenant
+	var slackBot *bot.Bot
+	var applyConfig func(*config.Config) error
+	if cfg.Tenants.Enabled() {
+		tenants, err := bot.NewTenants(cfg)
+		if err != nil {
+			log.Fatalf("Invalid tenants config: %v", err)
+		}
+		slackBot, err = tenants.BotFor(identity.CLI())
+		if err != nil {
+			log.Fatalf("Failed to start tenant bot: %v", err)
+		}
+		applyConfig = tenants.ApplyConfig
+	} else {
+		slackBot, err = newBot(cfg)
+		if err != nil {
+			log.Fatalf("Failed to start bot: %v", err)
+		}
+		applyConfig = slackBot.ApplyConfig
+	}
+
+	// Pick up config file changes, and SIGHUP, without a restart
+	watcher := config.NewWatcher(config.LoadOptions{Path: cfg.Source, Overrides: overrides}, applyConfig)
+	go watcher.Run(ctx, config.DefaultWatchInterval, func(err error) {
+		slackBot.Logger().Error("Config reload rejected, keeping the current config", logging.F("error", err))
+	})
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	go func() {
+		for range hangup {
+			watcher.Trigger()
+		}
+	}()
+
+	// Start the bot (this is a CLI bot, so it runs interactively)
+	if err := slackBot.Start(ctx); err != nil {
+		log.Fatalf("Bot failed: %v", err)
+	}
+}
+
+// newBot creates the bot for a single Nobl9 organization
+func newBot(cfg *config.Config) (*bot.Bot, error) {
+	// Create Nobl9 client using the SDK's built-in configuration system. Settings
+	// left empty in the bot config are read by the SDK from:
 	// 1. Environment variables (highest priority)
-	// 2. ~/.nobl9/config.toml (if exists)
+	// 2. The selected context of ~/.nobl9/config.toml (if exists)
 	// 3. Default values
-	nobl9Client, err := nobl9.NewClient("", "", "", "")
+	nobl9Client, err := nobl9.NewClientFromConfig(cfg.Nobl9)
 	if err != nil {
-		log.Fatalf("Failed to create Nobl9 client: %v", err)
+		return nil, fmt.Errorf("failed to create Nobl9 client: %w", err)
+	}
+
+	// Commit changes to a git repository for review instead of applying them
+	if cfg.GitOps.Enabled() {
+		repo, err := gitops.NewRepository(cfg.GitOps)
+		if err != nil {
+			return nil, fmt.Errorf("invalid gitops config: %w", err)
+		}
+		nobl9Client.SetGitOps(repo)
 	}
 
-	// Create and start bot
+	// Create bot
 	slackBot, err := bot.New(nobl9Client)
 	if err != nil {
-		log.Fatalf("Failed to create bot: %v", err)
+		return nil, fmt.Errorf("failed to create bot: %w", err)
 	}
 
-	// Create context for the bot
-	ctx := context.Background()
+	// Apply the logging, naming, template, approval and authorization settings
+	if err := slackBot.ApplyConfig(cfg); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
 
-	// Start the bot (this is a CLI bot, so it runs interactively)
-	if err := slackBot.Start(ctx); err != nil {
-		log.Fatalf("Bot failed: %v", err)
+	// Record every mutation in the audit log
+	auditLog, err := audit.Open(cfg.Audit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
 	}
-} 
\ No newline at end of file
+	slackBot.SetAuditLog(auditLog)
+
+	return slackBot, nil
+}
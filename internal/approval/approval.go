@@ -0,0 +1,253 @@
+package approval
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nobl9/nobl9-go/manifest"
+	"github.com/nobl9/nobl9-go/manifest/v1alpha/project"
+	"github.com/nobl9/nobl9-go/manifest/v1alpha/rolebinding"
+
+	"github.com/dfaile/backstage-nobl9/internal/config"
+	"github.com/dfaile/backstage-nobl9/internal/errors"
+)
+
+// Status represents where a request is in the approval workflow
+type Status string
+
+const (
+	StatusPending  Status = "pending"
+	StatusApproved Status = "approved"
+	StatusDenied   Status = "denied"
+)
+
+// Request represents a change waiting for an approver's decision
+type Request struct {
+	ID             string
+	Requester      string
+	ConversationID string // Where the requester is notified of the outcome
+	Nobl9Context   string // Context the change was requested in, the bot's own connection when empty
+	Summary        string
+	Objects        []manifest.Object
+	Reasons        []string
+	Approvers      []string
+	Status         Status
+	CreatedAt      time.Time
+	DecidedBy      string
+	DecidedAt      time.Time
+}
+
+// CanDecide reports whether approver may approve or deny the request
+func (r *Request) CanDecide(approver string) bool {
+	if strings.EqualFold(approver, r.Requester) {
+		return false
+	}
+	for _, allowed := range r.Approvers {
+		if strings.EqualFold(approver, allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+// Queue holds approval requests and the notifications waiting for each conversation
+type Queue struct {
+	mu            sync.Mutex
+	nextID        int
+	requests      map[string]*Request
+	notifications map[string][]string
+}
+
+// NewQueue creates an empty approval queue
+func NewQueue() *Queue {
+	return &Queue{
+		nextID:        1,
+		requests:      make(map[string]*Request),
+		notifications: make(map[string][]string),
+	}
+}
+
+// Submit adds a request to the queue as pending and assigns its ID
+func (q *Queue) Submit(req *Request) *Request {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	req.ID = strconv.Itoa(q.nextID)
+	q.nextID++
+	req.Status = StatusPending
+	req.CreatedAt = time.Now()
+	q.requests[req.ID] = req
+	return req
+}
+
+// Get retrieves a request by ID
+func (q *Queue) Get(id string) (*Request, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	req, ok := q.requests[strings.TrimPrefix(id, "#")]
+	if !ok {
+		return nil, errors.NewNotFoundError(fmt.Sprintf("approval request #%s not found", strings.TrimPrefix(id, "#")), nil)
+	}
+	return req, nil
+}
+
+// Pending returns the pending requests approver may decide, oldest first
+func (q *Queue) Pending(approver string) []*Request {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var pending []*Request
+	for _, req := range q.requests {
+		if req.Status == StatusPending && req.CanDecide(approver) {
+			pending = append(pending, req)
+		}
+	}
+	sort.Slice(pending, func(i, j int) bool {
+		return pending[i].CreatedAt.Before(pending[j].CreatedAt)
+	})
+	return pending
+}
+
+// Decide records approver's decision on a pending request
+func (q *Queue) Decide(id, approver string, approve bool) (*Request, error) {
+	req, err := q.Get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if req.Status != StatusPending {
+		return nil, errors.NewConflictError(fmt.Sprintf("approval request #%s was already %s by %s", req.ID, req.Status, req.DecidedBy), nil)
+	}
+	if strings.EqualFold(approver, req.Requester) {
+		return nil, errors.NewPermissionError("you cannot decide your own request", nil)
+	}
+	if !req.CanDecide(approver) {
+		return nil, errors.NewPermissionError(fmt.Sprintf("%s is not an approver for request #%s, approvers: %s", approver, req.ID, strings.Join(req.Approvers, ", ")), nil)
+	}
+
+	req.Status = StatusDenied
+	if approve {
+		req.Status = StatusApproved
+	}
+	req.DecidedBy = approver
+	req.DecidedAt = time.Now()
+	return req, nil
+}
+
+// Notify queues a message for the next reply in a conversation
+func (q *Queue) Notify(conversationID, message string) {
+	if conversationID == "" {
+		return
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.notifications[conversationID] = append(q.notifications[conversationID], message)
+}
+
+// Drain returns and clears the notifications waiting for a conversation
+func (q *Queue) Drain(conversationID string) []string {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	messages := q.notifications[conversationID]
+	delete(q.notifications, conversationID)
+	return messages
+}
+
+// Projects returns the projects objects define or change role bindings in, sorted
+func Projects(objects []manifest.Object) []string {
+	seen := make(map[string]bool)
+	var projects []string
+	add := func(name string) {
+		if name != "" && !seen[name] {
+			seen[name] = true
+			projects = append(projects, name)
+		}
+	}
+	for _, obj := range objects {
+		switch o := obj.(type) {
+		case project.Project:
+			add(o.Metadata.Name)
+		case rolebinding.RoleBinding:
+			add(o.Spec.ProjectRef)
+		}
+	}
+	sort.Strings(projects)
+	return projects
+}
+
+// Reasons returns why a change needs approval under policy, or nothing when it can go
+// ahead. projectLabels holds the current labels of every existing project in
+// Projects(objects); projects missing from it are new. Label rules are checked
+// against the current labels, so a manifest cannot drop a label to avoid them, and
+// any change to an existing project the rules match needs approval. Role bindings
+// in new projects, or projects the manifest labels to match, need approval too.
+func Reasons(policy config.ApprovalPolicy, objects []manifest.Object, projectLabels map[string]map[string]string) []string {
+	defined := make(map[string]map[string]string)
+	for _, obj := range objects {
+		if proj, ok := obj.(project.Project); ok {
+			values := make(map[string]string, len(proj.Metadata.Labels))
+			for key, list := range proj.Metadata.Labels {
+				if len(list) > 0 {
+					values[key] = list[0]
+				}
+			}
+			defined[proj.Metadata.Name] = values
+		}
+	}
+
+	var reasons []string
+	users := make(map[string]bool)
+	bound := make(map[string]bool)
+	for _, obj := range objects {
+		binding, ok := obj.(rolebinding.RoleBinding)
+		if !ok {
+			continue
+		}
+		bound[binding.Spec.ProjectRef] = true
+		subject := "a group"
+		if binding.Spec.User != nil {
+			subject = *binding.Spec.User
+			users[subject] = true
+		} else if binding.Spec.GroupRef != nil {
+			subject = fmt.Sprintf("group %s", *binding.Spec.GroupRef)
+		}
+		for _, role := range policy.Roles {
+			if binding.Spec.RoleRef == role {
+				reasons = append(reasons, fmt.Sprintf("grants %s to %s in project %s", role, subject, binding.Spec.ProjectRef))
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(policy.ProjectLabels))
+	for key := range policy.ProjectLabels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, name := range Projects(objects) {
+		current, exists := projectLabels[name]
+		newLabels, changed := defined[name]
+		for _, key := range keys {
+			value := policy.ProjectLabels[key]
+			switch {
+			case exists && changed && current[key] == value:
+				reasons = append(reasons, fmt.Sprintf("changes project %s, which is labeled %s=%s", name, key, value))
+			case bound[name] && (current[key] == value || newLabels[key] == value):
+				reasons = append(reasons, fmt.Sprintf("project %s is labeled %s=%s", name, key, value))
+			}
+		}
+	}
+
+	if policy.MaxUsers > 0 && len(users) > policy.MaxUsers {
+		reasons = append(reasons, fmt.Sprintf("grants roles to %d users, more than the limit of %d", len(users), policy.MaxUsers))
+	}
+
+	return reasons
+}
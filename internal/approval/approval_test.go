@@ -0,0 +1,149 @@
+package approval_test
+
+import (
+	"testing"
+
+	"github.com/nobl9/nobl9-go/manifest"
+	"github.com/nobl9/nobl9-go/manifest/v1alpha"
+	"github.com/nobl9/nobl9-go/manifest/v1alpha/project"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dfaile/backstage-nobl9/internal/approval"
+	"github.com/dfaile/backstage-nobl9/internal/config"
+	"github.com/dfaile/backstage-nobl9/internal/errors"
+	"github.com/dfaile/backstage-nobl9/internal/nobl9"
+)
+
+var policy = config.ApprovalPolicy{
+	Roles:         []string{nobl9.RoleProjectOwner},
+	ProjectLabels: map[string]string{"tier": "critical"},
+	MaxUsers:      2,
+}
+
+func TestReasons(t *testing.T) {
+	tests := []struct {
+		name    string
+		objects []manifest.Object
+		labels  map[string]map[string]string
+		want    []string
+	}{
+		{
+			name:    "editor on ordinary project",
+			objects: []manifest.Object{nobl9.NewRoleBinding("payments", "a@example.com", nobl9.RoleProjectEditor)},
+			labels:  map[string]map[string]string{"payments": {"tier": "standard"}},
+		},
+		{
+			name:    "owner role",
+			objects: []manifest.Object{nobl9.NewRoleBinding("payments", "a@example.com", nobl9.RoleProjectOwner)},
+			want:    []string{"grants project-owner to a@example.com in project payments"},
+		},
+		{
+			name:    "critical project",
+			objects: []manifest.Object{nobl9.NewRoleBinding("payments", "a@example.com", nobl9.RoleProjectViewer)},
+			labels:  map[string]map[string]string{"payments": {"tier": "critical"}},
+			want:    []string{"project payments is labeled tier=critical"},
+		},
+		{
+			name: "critical project defined in the manifest",
+			objects: []manifest.Object{
+				project.New(project.Metadata{Name: "ledger", Labels: v1alpha.Labels{"tier": {"critical"}}}, project.Spec{}),
+				nobl9.NewRoleBinding("ledger", "a@example.com", nobl9.RoleProjectViewer),
+			},
+			want: []string{"project ledger is labeled tier=critical"},
+		},
+		{
+			name: "critical label dropped by the manifest",
+			objects: []manifest.Object{
+				project.New(project.Metadata{Name: "payments"}, project.Spec{}),
+				nobl9.NewRoleBinding("payments", "a@example.com", nobl9.RoleProjectViewer),
+			},
+			labels: map[string]map[string]string{"payments": {"tier": "critical"}},
+			want:   []string{"changes project payments, which is labeled tier=critical"},
+		},
+		{
+			name:    "critical project changed",
+			objects: []manifest.Object{project.New(project.Metadata{Name: "payments"}, project.Spec{Description: "Moved"})},
+			labels:  map[string]map[string]string{"payments": {"tier": "critical"}},
+			want:    []string{"changes project payments, which is labeled tier=critical"},
+		},
+		{
+			name:    "new critical project",
+			objects: []manifest.Object{project.New(project.Metadata{Name: "ledger", Labels: v1alpha.Labels{"tier": {"critical"}}}, project.Spec{})},
+		},
+		{
+			name: "bulk import",
+			objects: []manifest.Object{
+				nobl9.NewRoleBinding("payments", "a@example.com", nobl9.RoleProjectViewer),
+				nobl9.NewRoleBinding("payments", "b@example.com", nobl9.RoleProjectViewer),
+				nobl9.NewRoleBinding("payments", "c@example.com", nobl9.RoleProjectViewer),
+			},
+			want: []string{"grants roles to 3 users, more than the limit of 2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, approval.Reasons(policy, tt.objects, tt.labels))
+		})
+	}
+}
+
+func newRequest(q *approval.Queue) *approval.Request {
+	return q.Submit(&approval.Request{
+		Requester:      "dev@example.com",
+		ConversationID: "dev-thread",
+		Summary:        "Grant project-owner",
+		Approvers:      []string{"owner@example.com"},
+	})
+}
+
+func TestQueueDecide(t *testing.T) {
+	q := approval.NewQueue()
+	req := newRequest(q)
+	assert.Equal(t, "1", req.ID)
+	assert.Equal(t, approval.StatusPending, req.Status)
+
+	assert.Len(t, q.Pending("owner@example.com"), 1)
+	assert.Empty(t, q.Pending("someone@example.com"))
+
+	_, err := q.Decide("1", "dev@example.com", true)
+	require.Error(t, err)
+	assert.True(t, errors.IsPermissionError(err))
+
+	_, err = q.Decide("1", "someone@example.com", true)
+	require.Error(t, err)
+	assert.True(t, errors.IsPermissionError(err))
+
+	decided, err := q.Decide("#1", "Owner@example.com", true)
+	require.NoError(t, err)
+	assert.Equal(t, approval.StatusApproved, decided.Status)
+	assert.Equal(t, "Owner@example.com", decided.DecidedBy)
+	assert.Empty(t, q.Pending("owner@example.com"))
+
+	_, err = q.Decide("1", "owner@example.com", false)
+	require.Error(t, err)
+	assert.True(t, errors.IsConflictError(err))
+
+	_, err = q.Decide("42", "owner@example.com", true)
+	assert.True(t, errors.IsNotFoundError(err))
+}
+
+func TestQueueDeny(t *testing.T) {
+	q := approval.NewQueue()
+	newRequest(q)
+
+	decided, err := q.Decide("1", "owner@example.com", false)
+	require.NoError(t, err)
+	assert.Equal(t, approval.StatusDenied, decided.Status)
+}
+
+func TestQueueNotifications(t *testing.T) {
+	q := approval.NewQueue()
+	q.Notify("dev-thread", "approved")
+	q.Notify("dev-thread", "denied")
+	q.Notify("", "dropped")
+
+	assert.Equal(t, []string{"approved", "denied"}, q.Drain("dev-thread"))
+	assert.Empty(t, q.Drain("dev-thread"))
+}
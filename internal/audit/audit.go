@@ -0,0 +1,478 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nobl9/nobl9-go/manifest"
+	"github.com/nobl9/nobl9-go/manifest/v1alpha/project"
+	"github.com/nobl9/nobl9-go/manifest/v1alpha/rolebinding"
+	"github.com/nobl9/nobl9-go/sdk"
+
+	"github.com/dfaile/backstage-nobl9/internal/config"
+	"github.com/dfaile/backstage-nobl9/internal/errors"
+	"github.com/dfaile/backstage-nobl9/internal/identity"
+)
+
+// Outcome represents the result of a mutation, or of a command refused before it ran
+type Outcome string
+
+const (
+	// OutcomeAttempted is written before a mutation starts
+	OutcomeAttempted Outcome = "attempted"
+	OutcomeSucceeded Outcome = "succeeded"
+	OutcomeFailed    Outcome = "failed"
+	// OutcomeDenied is written when a caller is refused a command, for lacking a
+	// permission or running too many
+	OutcomeDenied Outcome = "denied"
+)
+
+// Object identifies a manifest object a mutation touched and holds its JSON manifest
+type Object struct {
+	Kind     string          `json:"kind"`
+	Name     string          `json:"name"`
+	Project  string          `json:"project,omitempty"`
+	User     string          `json:"user,omitempty"` // Subject of a RoleBinding
+	Role     string          `json:"role,omitempty"` // Role granted by a RoleBinding
+	Manifest json.RawMessage `json:"manifest,omitempty"`
+}
+
+// Record represents one entry in the audit log. Each record carries the hash of the
+// record before it, so editing, removing or reordering records breaks the chain.
+// With a key the hashes are HMACs, so the chain cannot be rebuilt without it.
+type Record struct {
+	Seq       int64           `json:"seq"`
+	Time      time.Time       `json:"time"`
+	RequestID string          `json:"request_id,omitempty"`
+	Tenant    string          `json:"tenant,omitempty"` // Tenant whose organization was changed, in multi-tenant mode
+	Caller    identity.Caller `json:"caller"`
+	Command   string          `json:"command"`
+	Summary   string          `json:"summary,omitempty"`
+	Objects   []Object        `json:"objects,omitempty"`
+	Outcome   Outcome         `json:"outcome"`
+	Error     string          `json:"error,omitempty"`
+	Branch    string          `json:"branch,omitempty"` // GitOps branch holding the change
+	PrevHash  string          `json:"prev_hash"`
+	Hash      string          `json:"hash"`
+}
+
+// computeHash returns the hash of the record with its Hash field cleared, an
+// HMAC-SHA256 when key is set
+func (r Record) computeHash(key []byte) (string, error) {
+	r.Hash = ""
+	data, err := json.Marshal(r)
+	if err != nil {
+		return "", err
+	}
+	return sign(key, data), nil
+}
+
+// sign returns the hex HMAC-SHA256 of data with key, or its SHA-256 without one
+func sign(key, data []byte) string {
+	if len(key) == 0 {
+		sum := sha256.Sum256(data)
+		return hex.EncodeToString(sum[:])
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write(data)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Head records the newest record of a signed log, so removing records from its
+// end is detected. It is signed with the log's key.
+type Head struct {
+	Seq       int64  `json:"seq"`
+	Hash      string `json:"hash"`
+	Signature string `json:"signature"`
+}
+
+// HeadPath returns the path of the head file of the audit log at path
+func HeadPath(path string) string {
+	return path + ".head"
+}
+
+// signHead returns the signature of a head pointing at seq and hash
+func signHead(key []byte, seq int64, hash string) string {
+	return sign(key, []byte(fmt.Sprintf("head:%d:%s", seq, hash)))
+}
+
+// readHead reads the head of the log at path, nil when there is none
+func readHead(path string) (*Head, error) {
+	data, err := os.ReadFile(HeadPath(path))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audit log head: %w", err)
+	}
+	var head Head
+	if err := json.Unmarshal(data, &head); err != nil {
+		return nil, fmt.Errorf("invalid audit log head: %w", err)
+	}
+	return &head, nil
+}
+
+// checkHead checks the signed head of the log at path points at last, the newest
+// record in the log
+func checkHead(path string, key []byte, last *Record) error {
+	head, err := readHead(path)
+	if err != nil {
+		return err
+	}
+	if head == nil {
+		if last != nil {
+			return fmt.Errorf("%s is missing, the log's newest records cannot be checked", HeadPath(path))
+		}
+		return nil
+	}
+	if !hmac.Equal([]byte(head.Signature), []byte(signHead(key, head.Seq, head.Hash))) {
+		return fmt.Errorf("%s has been modified, its signature does not match", HeadPath(path))
+	}
+	if last == nil || last.Seq != head.Seq || last.Hash != head.Hash {
+		seq := int64(0)
+		if last != nil {
+			seq = last.Seq
+		}
+		return fmt.Errorf("log ends at record %d but its head is record %d, records have been removed", seq, head.Seq)
+	}
+	return nil
+}
+
+// Log is an append-only, hash-chained JSON lines audit log
+type Log struct {
+	path     string
+	maxBytes int64
+	key      []byte // Signs the chain and the head, unsigned when empty
+	mu       sync.Mutex
+	seq      int64
+	lastHash string
+	closed   bool
+}
+
+// Open opens the audit log, creating it if needed, and resumes the hash chain
+// from its last record. A log signed with a key must still end at its head, and
+// its last record must be signed with the configured key.
+func Open(cfg config.AuditConfig) (*Log, error) {
+	path := cfg.Path
+	if path == "" {
+		var err error
+		path, err = config.DefaultAuditPath()
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create audit log directory: %w", err)
+	}
+
+	l := &Log{
+		path:     path,
+		maxBytes: cfg.MaxBytes,
+		key:      []byte(cfg.Key),
+	}
+
+	// The chain continues across rotated files
+	files, err := Files(path)
+	if err != nil {
+		return nil, err
+	}
+	var last *Record
+	for i := len(files) - 1; i >= 0 && last == nil; i-- {
+		if last, err = lastRecord(files[i]); err != nil {
+			return nil, err
+		}
+	}
+	if len(l.key) > 0 {
+		if last != nil {
+			if hash, err := last.computeHash(l.key); err != nil || hash != last.Hash {
+				return nil, fmt.Errorf("the audit log at %s is not signed with audit.key, move it aside to start a signed log", path)
+			}
+		}
+		if err := checkHead(path, l.key, last); err != nil {
+			return nil, fmt.Errorf("refusing to extend the audit log: %w", err)
+		}
+	}
+	if last != nil {
+		l.seq = last.Seq
+		l.lastHash = last.Hash
+	}
+
+	// Fail early if the log cannot be written
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+
+	return l, nil
+}
+
+// Path returns the path of the current audit log file
+func (l *Log) Path() string {
+	return l.path
+}
+
+// Append chains rec onto the log and writes it durably. The record's Seq, Time,
+// PrevHash and Hash are filled in.
+func (l *Log) Append(rec Record) (Record, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.closed {
+		return Record{}, errors.NewInternalError("the audit log is closed", nil)
+	}
+	if err := l.rotate(); err != nil {
+		return Record{}, err
+	}
+
+	rec.Seq = l.seq + 1
+	rec.Time = time.Now().UTC()
+	rec.PrevHash = l.lastHash
+	hash, err := rec.computeHash(l.key)
+	if err != nil {
+		return Record{}, errors.NewInternalError("failed to hash audit record", err)
+	}
+	rec.Hash = hash
+
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return Record{}, errors.NewInternalError("failed to encode audit record", err)
+	}
+
+	file, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return Record{}, errors.NewInternalError("failed to open audit log", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		return Record{}, errors.NewInternalError("failed to write audit log", err)
+	}
+	if err := file.Sync(); err != nil {
+		return Record{}, errors.NewInternalError("failed to sync audit log", err)
+	}
+
+	l.seq = rec.Seq
+	l.lastHash = rec.Hash
+	if err := l.writeHead(); err != nil {
+		return Record{}, err
+	}
+	return rec, nil
+}
+
+// writeHead replaces the signed head with the newest record. Unsigned logs have no head.
+func (l *Log) writeHead() error {
+	if len(l.key) == 0 {
+		return nil
+	}
+	data, err := json.Marshal(Head{Seq: l.seq, Hash: l.lastHash, Signature: signHead(l.key, l.seq, l.lastHash)})
+	if err != nil {
+		return errors.NewInternalError("failed to encode audit log head", err)
+	}
+
+	// Written aside and renamed, so a crash never leaves a partial head
+	tmp := HeadPath(l.path) + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return errors.NewInternalError("failed to write audit log head", err)
+	}
+	if err := os.Rename(tmp, HeadPath(l.path)); err != nil {
+		return errors.NewInternalError("failed to write audit log head", err)
+	}
+	return nil
+}
+
+// Close closes the log. Appending afterwards fails, so nothing more is recorded
+// in it once the bot that writes it has been removed.
+func (l *Log) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.closed = true
+	return nil
+}
+
+// rotate renames the current file aside once it reaches the size limit
+func (l *Log) rotate() error {
+	if l.maxBytes <= 0 {
+		return nil
+	}
+	info, err := os.Stat(l.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.NewInternalError("failed to stat audit log", err)
+	}
+	if info.Size() < l.maxBytes {
+		return nil
+	}
+
+	rotated := fmt.Sprintf("%s.%s", l.path, time.Now().UTC().Format("20060102T150405.000000000"))
+	if err := os.Rename(l.path, rotated); err != nil {
+		return errors.NewInternalError("failed to rotate audit log", err)
+	}
+	return nil
+}
+
+// Objects converts manifest objects into audit objects, keeping their manifests
+func Objects(objects []manifest.Object) []Object {
+	result := make([]Object, 0, len(objects))
+	for _, obj := range objects {
+		entry := Object{
+			Kind: obj.GetKind().String(),
+			Name: obj.GetName(),
+		}
+		switch o := obj.(type) {
+		case project.Project:
+			entry.Project = o.GetName()
+		case rolebinding.RoleBinding:
+			entry.Project = o.Spec.ProjectRef
+			entry.Role = o.Spec.RoleRef
+			if o.Spec.User != nil {
+				entry.User = *o.Spec.User
+			} else if o.Spec.GroupRef != nil {
+				entry.User = "group " + *o.Spec.GroupRef
+			}
+		case manifest.ProjectScopedObject:
+			entry.Project = o.GetProject()
+		}
+
+		var buf bytes.Buffer
+		if err := sdk.EncodeObject(obj, &buf, manifest.ObjectFormatJSON); err == nil {
+			entry.Manifest = json.RawMessage(buf.Bytes())
+		}
+		result = append(result, entry)
+	}
+	return result
+}
+
+// Files returns the rotated audit files followed by the current file, oldest first
+func Files(path string) ([]string, error) {
+	matches, err := filepath.Glob(path + ".*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit log files: %w", err)
+	}
+	var rotated []string
+	for _, match := range matches {
+		if !strings.HasPrefix(match, HeadPath(path)) {
+			rotated = append(rotated, match)
+		}
+	}
+	sort.Strings(rotated)
+	if _, err := os.Stat(path); err == nil {
+		rotated = append(rotated, path)
+	}
+	return rotated, nil
+}
+
+// ReadFile reads every record in an audit file
+func ReadFile(path string) ([]Record, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit file: %w", err)
+	}
+	defer file.Close()
+
+	var records []Record
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var rec Record
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid audit record: %w", path, line, err)
+		}
+		records = append(records, rec)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit file: %w", err)
+	}
+	return records, nil
+}
+
+// lastRecord returns the last record of an audit file, or nil when it is empty
+func lastRecord(path string) (*Record, error) {
+	records, err := ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+	return &records[len(records)-1], nil
+}
+
+// VerifyResult summarizes a successful verification
+type VerifyResult struct {
+	Files   []string
+	Records int
+	Signed  bool // Checked with a key, so the chain was not rebuilt or truncated
+}
+
+// Verify walks every audit file for path in order and checks sequence numbers, the
+// hash chain and each record's own hash. The first broken record is reported.
+// With the log's key, it also checks the log still ends at its signed head.
+func Verify(path string, key []byte) (*VerifyResult, error) {
+	files, err := Files(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no audit log found at %s", path)
+	}
+
+	result := &VerifyResult{Files: files, Signed: len(key) > 0}
+	var prev *Record
+	for _, file := range files {
+		records, err := ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		for i := range records {
+			rec := records[i]
+			hash, err := rec.computeHash(key)
+			if err != nil {
+				return nil, err
+			}
+			if hash != rec.Hash {
+				return nil, fmt.Errorf("%s: record %d has been modified, its hash does not match its contents", file, rec.Seq)
+			}
+			if prev == nil {
+				if rec.Seq != 1 || rec.PrevHash != "" {
+					return nil, fmt.Errorf("%s: log starts at record %d, earlier records are missing", file, rec.Seq)
+				}
+			} else {
+				if rec.Seq != prev.Seq+1 {
+					return nil, fmt.Errorf("%s: record %d follows record %d, records are missing or out of order", file, rec.Seq, prev.Seq)
+				}
+				if rec.PrevHash != prev.Hash {
+					return nil, fmt.Errorf("%s: record %d does not chain to record %d", file, rec.Seq, prev.Seq)
+				}
+			}
+			prev = &records[i]
+			result.Records++
+		}
+	}
+	if len(key) > 0 {
+		if err := checkHead(path, key, prev); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
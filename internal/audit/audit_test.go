@@ -0,0 +1,188 @@
+package audit_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dfaile/backstage-nobl9/internal/audit"
+	"github.com/dfaile/backstage-nobl9/internal/config"
+	"github.com/dfaile/backstage-nobl9/internal/identity"
+)
+
+func appendRecords(t *testing.T, log *audit.Log, n int) {
+	t.Helper()
+	caller := identity.Caller{ID: "alice", Source: identity.SourceCLI}
+	for i := 0; i < n; i++ {
+		_, err := log.Append(audit.Record{
+			Caller:  caller,
+			Command: "create-project",
+			Objects: []audit.Object{{Kind: "Project", Name: "payments", Project: "payments"}},
+			Outcome: audit.OutcomeSucceeded,
+		})
+		require.NoError(t, err)
+	}
+}
+
+func TestAppendAndVerify(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	log, err := audit.Open(config.AuditConfig{Path: path})
+	require.NoError(t, err)
+
+	appendRecords(t, log, 3)
+
+	records, err := audit.ReadFile(path)
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+	assert.Equal(t, int64(1), records[0].Seq)
+	assert.Empty(t, records[0].PrevHash)
+	assert.Equal(t, records[0].Hash, records[1].PrevHash)
+	assert.Equal(t, "cli:alice", records[2].Caller.Identity())
+
+	result, err := audit.Verify(path, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 3, result.Records)
+}
+
+func TestVerifyDetectsTampering(t *testing.T) {
+	tests := []struct {
+		name   string
+		tamper func(lines []string) []string
+		want   string
+	}{
+		{
+			name: "modified record",
+			tamper: func(lines []string) []string {
+				lines[1] = strings.Replace(lines[1], "payments", "billing", 1)
+				return lines
+			},
+			want: "record 2 has been modified",
+		},
+		{
+			name: "deleted record",
+			tamper: func(lines []string) []string {
+				return append(lines[:1], lines[2:]...)
+			},
+			want: "record 3 follows record 1",
+		},
+		{
+			name: "truncated start",
+			tamper: func(lines []string) []string {
+				return lines[1:]
+			},
+			want: "log starts at record 2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "audit.jsonl")
+			log, err := audit.Open(config.AuditConfig{Path: path})
+			require.NoError(t, err)
+			appendRecords(t, log, 3)
+
+			data, err := os.ReadFile(path)
+			require.NoError(t, err)
+			lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+			lines = tt.tamper(lines)
+			require.NoError(t, os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0600))
+
+			_, err = audit.Verify(path, nil)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+}
+
+func TestRotationKeepsChain(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	log, err := audit.Open(config.AuditConfig{Path: path, MaxBytes: 1})
+	require.NoError(t, err)
+
+	appendRecords(t, log, 3)
+
+	files, err := audit.Files(path)
+	require.NoError(t, err)
+	assert.Len(t, files, 3)
+
+	result, err := audit.Verify(path, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 3, result.Records)
+}
+
+func TestOpenResumesChain(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	log, err := audit.Open(config.AuditConfig{Path: path})
+	require.NoError(t, err)
+	appendRecords(t, log, 2)
+
+	reopened, err := audit.Open(config.AuditConfig{Path: path})
+	require.NoError(t, err)
+	rec, err := reopened.Append(audit.Record{Command: "apply", Outcome: audit.OutcomeAttempted})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), rec.Seq)
+
+	result, err := audit.Verify(path, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 3, result.Records)
+}
+
+const testKey = "0123456789abcdef0123456789abcdef"
+
+func TestSignedLogDetectsRebuildAndTruncation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	log, err := audit.Open(config.AuditConfig{Path: path, Key: testKey})
+	require.NoError(t, err)
+	appendRecords(t, log, 3)
+
+	result, err := audit.Verify(path, []byte(testKey))
+	require.NoError(t, err)
+	assert.Equal(t, 3, result.Records)
+	assert.True(t, result.Signed)
+
+	// Hashes made without the key do not verify
+	_, err = audit.Verify(path, []byte("another-key-another-key-another-"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "record 1 has been modified")
+
+	// Removing the newest record is caught by the head
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.NoError(t, os.WriteFile(path, []byte(strings.Join(lines[:2], "\n")+"\n"), 0600))
+	_, err = audit.Verify(path, []byte(testKey))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "log ends at record 2 but its head is record 3")
+
+	// The bot refuses to carry on from a truncated log
+	_, err = audit.Open(config.AuditConfig{Path: path, Key: testKey})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "records have been removed")
+}
+
+func TestOpenRefusesUnsignedLogWithKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	log, err := audit.Open(config.AuditConfig{Path: path})
+	require.NoError(t, err)
+	appendRecords(t, log, 1)
+
+	_, err = audit.Open(config.AuditConfig{Path: path, Key: testKey})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not signed with audit.key")
+}
+
+func TestClosedLogRefusesRecords(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	log, err := audit.Open(config.AuditConfig{Path: path})
+	require.NoError(t, err)
+	appendRecords(t, log, 1)
+
+	require.NoError(t, log.Close())
+	_, err = log.Append(audit.Record{Command: "apply", Outcome: audit.OutcomeAttempted})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "the audit log is closed")
+}
@@ -0,0 +1,110 @@
+package audit
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Query filters audit records. Empty fields match every record.
+type Query struct {
+	Project string    // Project an object belongs to
+	User    string    // User a RoleBinding grants a role to, matched as a substring
+	Actor   string    // Caller who ran the command, matched as a substring of their identity, email or name
+	Action  string    // Command that made the change, e.g. assign-role
+	Outcome Outcome   // Defaults to every outcome except attempted
+	Since   time.Time // Inclusive
+	Until   time.Time // Exclusive
+}
+
+// Matches reports whether rec satisfies every filter in q
+func (q Query) Matches(rec Record) bool {
+	if q.Outcome != "" {
+		if rec.Outcome != q.Outcome {
+			return false
+		}
+	} else if rec.Outcome == OutcomeAttempted {
+		// Every attempt is followed by its outcome, so attempts are noise by default
+		return false
+	}
+	if q.Action != "" && !strings.EqualFold(rec.Command, q.Action) {
+		return false
+	}
+	if !q.Since.IsZero() && rec.Time.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && !rec.Time.Before(q.Until) {
+		return false
+	}
+	if q.Actor != "" && !containsFold(rec.Caller.Identity(), q.Actor) &&
+		!containsFold(rec.Caller.Email, q.Actor) && !containsFold(rec.Caller.DisplayName, q.Actor) {
+		return false
+	}
+	if q.Project == "" && q.User == "" {
+		return true
+	}
+	for _, obj := range rec.Objects {
+		if q.Project != "" && !strings.EqualFold(obj.Project, q.Project) {
+			continue
+		}
+		if q.User != "" && !containsFold(obj.User, q.User) {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// containsFold reports whether substr is within s, ignoring case
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
+
+// Search returns the records in every audit file for path that match q, newest first
+func Search(path string, q Query) ([]Record, error) {
+	files, err := Files(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []Record
+	for _, file := range files {
+		records, err := ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		for _, rec := range records {
+			if q.Matches(rec) {
+				matches = append(matches, rec)
+			}
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Seq > matches[j].Seq
+	})
+	return matches, nil
+}
+
+// ParseTime parses a query time. It accepts RFC 3339 timestamps, dates such as
+// 2024-05-01, and ages relative to now such as 90m, 24h or 7d.
+func ParseTime(value string, now time.Time) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, time.UTC); err == nil {
+		return t, nil
+	}
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+	if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q, use a date like 2024-05-01, an RFC 3339 timestamp or an age like 24h or 7d", value)
+}
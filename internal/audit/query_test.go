@@ -0,0 +1,95 @@
+package audit_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nobl9/nobl9-go/manifest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dfaile/backstage-nobl9/internal/audit"
+	"github.com/dfaile/backstage-nobl9/internal/config"
+	"github.com/dfaile/backstage-nobl9/internal/identity"
+	"github.com/dfaile/backstage-nobl9/internal/nobl9"
+)
+
+func TestSearch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	log, err := audit.Open(config.AuditConfig{Path: path})
+	require.NoError(t, err)
+
+	alice := identity.Caller{ID: "alice", Email: "alice@example.com", Source: identity.SourceCLI}
+	carol := identity.Caller{ID: "U42", DisplayName: "Carol", Source: identity.SourceSlack}
+	entries := []audit.Record{
+		{Caller: alice, Command: "create-project", Outcome: audit.OutcomeAttempted,
+			Objects: audit.Objects(nobl9.ProjectObjects("payments-api", "", nil, ""))},
+		{Caller: alice, Command: "create-project", Outcome: audit.OutcomeSucceeded,
+			Objects: audit.Objects(nobl9.ProjectObjects("payments-api", "", nil, ""))},
+		{Caller: carol, Command: "assign-role", Outcome: audit.OutcomeSucceeded,
+			Objects: audit.Objects([]manifest.Object{nobl9.NewRoleBinding("payments-api", "bob@example.com", "project-owner")})},
+		{Caller: carol, Command: "assign-role", Outcome: audit.OutcomeFailed,
+			Objects: audit.Objects([]manifest.Object{nobl9.NewRoleBinding("billing", "bob@example.com", "project-viewer")})},
+	}
+	for _, entry := range entries {
+		_, err := log.Append(entry)
+		require.NoError(t, err)
+	}
+
+	tests := []struct {
+		name  string
+		query audit.Query
+		want  []int64
+	}{
+		{"outcomes by default", audit.Query{}, []int64{4, 3, 2}},
+		{"attempts on request", audit.Query{Outcome: audit.OutcomeAttempted}, []int64{1}},
+		{"project", audit.Query{Project: "payments-api"}, []int64{3, 2}},
+		{"user in project", audit.Query{User: "bob", Project: "payments-api"}, []int64{3}},
+		{"actor by name", audit.Query{Actor: "carol"}, []int64{4, 3}},
+		{"actor by identity", audit.Query{Actor: "cli:alice"}, []int64{2}},
+		{"action", audit.Query{Action: "CREATE-PROJECT"}, []int64{2}},
+		{"future", audit.Query{Since: time.Now().Add(time.Hour)}, nil},
+		{"past", audit.Query{Until: time.Now().Add(time.Hour)}, []int64{4, 3, 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			records, err := audit.Search(path, tt.query)
+			require.NoError(t, err)
+			var got []int64
+			for _, rec := range records {
+				got = append(got, rec.Seq)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	records, err := audit.Search(path, audit.Query{User: "bob", Project: "payments-api"})
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, "project-owner", records[0].Objects[0].Role)
+	assert.Equal(t, "bob@example.com", records[0].Objects[0].User)
+}
+
+func TestParseTime(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		value string
+		want  time.Time
+	}{
+		{"2024-05-01", time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)},
+		{"2024-05-01T08:30:00Z", time.Date(2024, 5, 1, 8, 30, 0, 0, time.UTC)},
+		{"24h", time.Date(2024, 5, 9, 12, 0, 0, 0, time.UTC)},
+		{"7d", time.Date(2024, 5, 3, 12, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		got, err := audit.ParseTime(tt.value, now)
+		require.NoError(t, err, tt.value)
+		assert.True(t, tt.want.Equal(got), "%s: got %s", tt.value, got)
+	}
+
+	_, err := audit.ParseTime("last tuesday", now)
+	assert.Error(t, err)
+}
@@ -0,0 +1,138 @@
+package authz
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/dfaile/backstage-nobl9/internal/config"
+	"github.com/dfaile/backstage-nobl9/internal/errors"
+)
+
+// Permission represents an action a bot command performs
+type Permission string
+
+const (
+	PermissionProjectsRead     Permission = "projects:read"
+	PermissionProjectsCreate   Permission = "projects:create"
+	PermissionRolesAssign      Permission = "roles:assign"
+	PermissionManifestsApply   Permission = "manifests:apply"
+	PermissionApprovalsDecide  Permission = "approvals:decide"
+	PermissionAuditRead        Permission = "audit:read"
+	PermissionContextsRead     Permission = "contexts:read"
+	PermissionContextsUse      Permission = "contexts:use"
+	PermissionLoggingConfigure Permission = "logging:configure"
+	PermissionPluginsRun       Permission = "plugins:run"
+	// PermissionAll grants every permission
+	PermissionAll Permission = "*"
+)
+
+// Authorizer decides which commands a caller may run
+type Authorizer struct {
+	roles        map[string]map[Permission]bool
+	identities   map[string][]string
+	defaultRoles []string
+}
+
+// New creates an authorizer from the authorization policy, checking that every
+// referenced role is defined
+func New(policy config.AuthorizationPolicy) (*Authorizer, error) {
+	a := &Authorizer{
+		roles:        make(map[string]map[Permission]bool, len(policy.Roles)),
+		identities:   make(map[string][]string, len(policy.Identities)),
+		defaultRoles: policy.DefaultRoles,
+	}
+	for role, permissions := range policy.Roles {
+		a.roles[role] = make(map[Permission]bool, len(permissions))
+		for _, permission := range permissions {
+			a.roles[role][Permission(permission)] = true
+		}
+	}
+
+	for identity, roles := range policy.Identities {
+		for _, role := range roles {
+			if _, ok := a.roles[role]; !ok {
+				return nil, fmt.Errorf("identity %s has undefined role %s", identity, role)
+			}
+		}
+		a.identities[strings.ToLower(identity)] = roles
+	}
+	for _, role := range policy.DefaultRoles {
+		if _, ok := a.roles[role]; !ok {
+			return nil, fmt.Errorf("default role %s is not defined", role)
+		}
+	}
+
+	return a, nil
+}
+
+// Enabled reports whether authorization is enforced. A nil authorizer allows everything.
+func (a *Authorizer) Enabled() bool {
+	return a != nil && len(a.roles) > 0
+}
+
+// Roles returns the bot roles of identity. Exact matches win over source:* matches,
+// which win over the default roles.
+func (a *Authorizer) Roles(identity string) []string {
+	identity = strings.ToLower(identity)
+	if roles, ok := a.identities[identity]; ok {
+		return roles
+	}
+	if source, _, found := strings.Cut(identity, ":"); found {
+		if roles, ok := a.identities[source+":*"]; ok {
+			return roles
+		}
+	}
+	return a.defaultRoles
+}
+
+// Missing returns the required permissions identity does not have
+func (a *Authorizer) Missing(identity string, required []Permission) []Permission {
+	if !a.Enabled() {
+		return nil
+	}
+
+	granted := make(map[Permission]bool)
+	for _, role := range a.Roles(identity) {
+		for permission := range a.roles[role] {
+			granted[permission] = true
+		}
+	}
+	if granted[PermissionAll] {
+		return nil
+	}
+
+	var missing []Permission
+	for _, permission := range required {
+		if !granted[permission] {
+			missing = append(missing, permission)
+		}
+	}
+	return missing
+}
+
+// Allowed reports whether identity has every required permission
+func (a *Authorizer) Allowed(identity string, required []Permission) bool {
+	return len(a.Missing(identity, required)) == 0
+}
+
+// Check returns a permission error explaining what identity is missing to run command
+func (a *Authorizer) Check(identity, command string, required []Permission) error {
+	missing := a.Missing(identity, required)
+	if len(missing) == 0 {
+		return nil
+	}
+
+	names := make([]string, len(missing))
+	for i, permission := range missing {
+		names[i] = string(permission)
+	}
+	sort.Strings(names)
+
+	who := identity
+	if who == "" {
+		who = "an unidentified caller"
+	}
+	return errors.NewPermissionError(fmt.Sprintf("%s is not allowed to run '%s', it requires the %s permission. Ask a bot administrator for access",
+		who, command, strings.Join(names, ", ")), nil)
+}
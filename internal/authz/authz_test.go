@@ -0,0 +1,90 @@
+package authz_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dfaile/backstage-nobl9/internal/authz"
+	"github.com/dfaile/backstage-nobl9/internal/config"
+	"github.com/dfaile/backstage-nobl9/internal/errors"
+)
+
+func newAuthorizer(t *testing.T) *authz.Authorizer {
+	t.Helper()
+	a, err := authz.New(config.AuthorizationPolicy{
+		Roles: map[string][]string{
+			"admin":     {"*"},
+			"requester": {"projects:read", "projects:create"},
+			"viewer":    {"projects:read"},
+		},
+		Identities: map[string][]string{
+			"cli:root":               {"admin"},
+			"slack:*":                {"requester"},
+			"http:alice@example.com": {"requester", "viewer"},
+		},
+		DefaultRoles: []string{"viewer"},
+	})
+	require.NoError(t, err)
+	return a
+}
+
+func TestAllowed(t *testing.T) {
+	a := newAuthorizer(t)
+
+	tests := []struct {
+		identity string
+		required []authz.Permission
+		want     bool
+	}{
+		{"cli:root", []authz.Permission{authz.PermissionRolesAssign}, true},
+		{"CLI:Root", []authz.Permission{authz.PermissionApprovalsDecide}, true},
+		{"slack:U123", []authz.Permission{authz.PermissionProjectsCreate}, true},
+		{"slack:U123", []authz.Permission{authz.PermissionRolesAssign}, false},
+		{"http:alice@example.com", []authz.Permission{authz.PermissionProjectsRead, authz.PermissionProjectsCreate}, true},
+		{"cli:bob", []authz.Permission{authz.PermissionProjectsRead}, true},
+		{"cli:bob", []authz.Permission{authz.PermissionProjectsCreate}, false},
+		{"", []authz.Permission{authz.PermissionProjectsCreate}, false},
+		{"cli:bob", nil, true},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, a.Allowed(tt.identity, tt.required), "%s %v", tt.identity, tt.required)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	a := newAuthorizer(t)
+
+	err := a.Check("slack:U123", "assign-role", []authz.Permission{authz.PermissionRolesAssign})
+	require.Error(t, err)
+	assert.True(t, errors.IsPermissionError(err))
+	assert.Contains(t, err.Error(), "slack:U123 is not allowed to run 'assign-role', it requires the roles:assign permission")
+
+	assert.NoError(t, a.Check("cli:root", "assign-role", []authz.Permission{authz.PermissionRolesAssign}))
+}
+
+func TestDisabled(t *testing.T) {
+	var nilAuthorizer *authz.Authorizer
+	assert.True(t, nilAuthorizer.Allowed("", []authz.Permission{authz.PermissionRolesAssign}))
+
+	a, err := authz.New(config.AuthorizationPolicy{})
+	require.NoError(t, err)
+	assert.False(t, a.Enabled())
+	assert.NoError(t, a.Check("", "assign-role", []authz.Permission{authz.PermissionRolesAssign}))
+}
+
+func TestNewRejectsUndefinedRoles(t *testing.T) {
+	_, err := authz.New(config.AuthorizationPolicy{
+		Roles:      map[string][]string{"viewer": {"projects:read"}},
+		Identities: map[string][]string{"cli:bob": {"admin"}},
+	})
+	assert.Error(t, err)
+
+	_, err = authz.New(config.AuthorizationPolicy{
+		Roles:        map[string][]string{"viewer": {"projects:read"}},
+		DefaultRoles: []string{"admin"},
+	})
+	assert.Error(t, err)
+}
@@ -42,7 +42,8 @@ func (m *Manager) CreateBackup(ctx context.Context, data interface{}, metadata m
 		Metadata:  metadata,
 	}
 
-	filename := fmt.Sprintf("backup_%s.json", backup.Timestamp.Format("20060102_150405"))
+	// Backups taken within the same second must not overwrite each other
+	filename := fmt.Sprintf("backup_%s.json", backup.Timestamp.Format("20060102_150405.000000000"))
 	path := filepath.Join(m.backupDir, filename)
 
 	file, err := os.Create(path)
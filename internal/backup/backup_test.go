@@ -3,7 +3,6 @@ package backup
 import (
 	"context"
 	"os"
-	"path/filepath"
 	"testing"
 	"time"
 )
@@ -95,11 +94,12 @@ func TestRestoreBackup(t *testing.T) {
 		t.Fatalf("failed to restore backup: %v", err)
 	}
 
-	if restored.Timestamp != backup.Timestamp {
+	if !restored.Timestamp.Equal(backup.Timestamp) {
 		t.Error("expected timestamps to match")
 	}
 
-	if restored.Data.(map[string]string)["test"] != "data" {
+	// JSON decodes objects into map[string]interface{}
+	if restored.Data.(map[string]interface{})["test"] != "data" {
 		t.Error("expected data to be restored")
 	}
 
@@ -200,4 +200,4 @@ func TestCleanupOldBackups(t *testing.T) {
 	if len(backups) != 0 {
 		t.Errorf("expected 0 backups, got %d", len(backups))
 	}
-} 
\ No newline at end of file
+}
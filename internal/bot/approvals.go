@@ -0,0 +1,241 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nobl9/nobl9-go/manifest"
+	"github.com/nobl9/nobl9-go/manifest/v1alpha/rolebinding"
+
+	"github.com/dfaile/backstage-nobl9/internal/approval"
+	"github.com/dfaile/backstage-nobl9/internal/errors"
+	"github.com/dfaile/backstage-nobl9/internal/logging"
+	"github.com/dfaile/backstage-nobl9/internal/nobl9"
+	"github.com/dfaile/backstage-nobl9/internal/recovery"
+	"github.com/dfaile/backstage-nobl9/internal/tracing"
+)
+
+// approvalReasons returns why objects need approval under the configured policy
+func (b *Bot) approvalReasons(ctx context.Context, objects []manifest.Object) ([]string, error) {
+	if !b.approvalPolicy.Enabled() {
+		return nil, nil
+	}
+
+	labels := make(map[string]map[string]string)
+	if len(b.approvalPolicy.ProjectLabels) > 0 {
+		for _, name := range approval.Projects(objects) {
+			project, err := b.client(ctx).GetProject(ctx, name)
+			if err != nil {
+				return nil, err
+			}
+			if project != nil {
+				labels[name] = project.Labels
+			}
+		}
+	}
+
+	return approval.Reasons(b.approvalPolicy, objects, labels), nil
+}
+
+// withoutOwnership leaves out the binding that makes owner the owner of the
+// project they are creating, which needs no approval of its own
+func withoutOwnership(objects []manifest.Object, projectName, owner string) []manifest.Object {
+	result := make([]manifest.Object, 0, len(objects))
+	for _, obj := range objects {
+		if binding, ok := obj.(rolebinding.RoleBinding); ok &&
+			binding.Spec.ProjectRef == projectName &&
+			binding.Spec.RoleRef == nobl9.RoleProjectOwner &&
+			binding.Spec.User != nil && *binding.Spec.User == owner {
+			continue
+		}
+		result = append(result, obj)
+	}
+	return result
+}
+
+// approvers returns who may decide a request for objects: the configured approvers
+// and the current owners of every affected project
+func (b *Bot) approvers(ctx context.Context, objects []manifest.Object) ([]string, error) {
+	seen := make(map[string]bool)
+	var approvers []string
+	add := func(email string) {
+		key := strings.ToLower(email)
+		if email == "" || seen[key] {
+			return
+		}
+		seen[key] = true
+		approvers = append(approvers, email)
+	}
+
+	for _, email := range b.approvalPolicy.Approvers {
+		add(email)
+	}
+	for _, name := range approval.Projects(objects) {
+		owners, err := b.client(ctx).GetProjectOwners(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		for _, owner := range owners {
+			// Group owners cannot be matched to a single person
+			if !strings.HasPrefix(owner, "group ") {
+				add(owner)
+			}
+		}
+	}
+	return approvers, nil
+}
+
+// requestApproval sends a change to the approval queue. Only callers with a
+// platform-verified email may request approval, approvers need to know who asks.
+func (b *Bot) requestApproval(ctx context.Context, state *ConversationState, summary string, objects []manifest.Object, reasons []string) (string, error) {
+	requester, err := verifiedEmail(state, fmt.Sprintf("this request needs approval because it %s, and requesting approval", strings.Join(reasons, " and ")))
+	if err != nil {
+		state.Reset()
+		return "", err
+	}
+
+	return b.queueApproval(ctx, state, &approval.Request{
+		Requester:      requester,
+		ConversationID: logging.ConversationID(ctx),
+		Nobl9Context:   state.Nobl9Context,
+		Summary:        summary,
+		Objects:        objects,
+		Reasons:        reasons,
+	})
+}
+
+// queueApproval submits a request from a verified requester
+func (b *Bot) queueApproval(ctx context.Context, state *ConversationState, req *approval.Request) (string, error) {
+	logger := b.logger.WithContext(ctx)
+	defer state.Reset()
+
+	approvers, err := b.approvers(ctx, req.Objects)
+	if err != nil {
+		return "", err
+	}
+	for _, approver := range approvers {
+		if !strings.EqualFold(approver, req.Requester) {
+			req.Approvers = append(req.Approvers, approver)
+		}
+	}
+	if len(req.Approvers) == 0 {
+		return "", errors.NewPermissionError(fmt.Sprintf("this request needs approval because it %s, but nobody other than you can approve it. Ask an administrator to configure approvers", strings.Join(req.Reasons, " and ")), nil)
+	}
+
+	b.approvals.Submit(req)
+	logger.Info("Request queued for approval",
+		logging.F("request_id", req.ID),
+		logging.F("requester", req.Requester),
+		logging.F("summary", req.Summary),
+		logging.F("reasons", req.Reasons),
+		logging.F("approvers", req.Approvers),
+	)
+
+	return fmt.Sprintf("⏳ This request needs approval because it %s.\nRequest #%s was sent to: %s. You'll be notified here once it has been decided.",
+		strings.Join(req.Reasons, " and "), req.ID, strings.Join(req.Approvers, ", ")), nil
+}
+
+// verifiedEmail returns the caller's platform-verified email, refusing action
+// when the chat platform does not know it. Emails typed into the conversation
+// are never trusted.
+func verifiedEmail(state *ConversationState, action string) (string, error) {
+	if state.Caller.Email == "" {
+		return "", errors.NewPermissionError(fmt.Sprintf("%s needs a verified email from your chat platform", action), nil)
+	}
+	return state.Caller.Email, nil
+}
+
+// listApprovals lists the pending requests approver may decide
+func (b *Bot) listApprovals(approver string) string {
+	pending := b.approvals.Pending(approver)
+	if len(pending) == 0 {
+		return "No requests are waiting for your approval."
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%d request(s) waiting for your approval:\n", len(pending)))
+	for _, req := range pending {
+		sb.WriteString(fmt.Sprintf("• #%s %s, requested by %s (%s)\n", req.ID, req.Summary, req.Requester, strings.Join(req.Reasons, "; ")))
+	}
+	sb.WriteString("\nReply with 'approve <id>' or 'deny <id>'.")
+	return sb.String()
+}
+
+// decideApproval records an approver's decision, applies approved changes and
+// notifies the requester of the outcome
+func (b *Bot) decideApproval(ctx context.Context, approver, id string, approve bool) (string, error) {
+	logger := b.logger.WithContext(ctx)
+
+	req, err := b.approvals.Decide(id, approver, approve)
+	if err != nil {
+		return "", err
+	}
+	logger.Info("Approval request decided",
+		logging.F("request_id", req.ID),
+		logging.F("status", req.Status),
+		logging.F("approver", approver),
+		logging.F("requester", req.Requester),
+	)
+
+	if !approve {
+		b.approvals.Notify(req.ConversationID, fmt.Sprintf("Your request #%s (%s) was denied by %s.", req.ID, req.Summary, approver))
+		return fmt.Sprintf("🚫 Request #%s denied. %s has been notified.", req.ID, req.Requester), nil
+	}
+
+	// The change is applied in the context it was requested in
+	client, err := b.clientFor(req.Nobl9Context)
+	if err != nil {
+		b.approvals.Notify(req.ConversationID, fmt.Sprintf("Your request #%s (%s) was approved by %s, but applying it failed: %v", req.ID, req.Summary, approver, err))
+		return "", errors.NewValidationError(fmt.Sprintf("failed to connect with context '%s'", req.Nobl9Context), err)
+	}
+	ctx = withClient(ctx, client)
+
+	summary := fmt.Sprintf("%s (request #%s from %s)", req.Summary, req.ID, req.Requester)
+	branch, err := b.mutate(ctx, "approve", summary, req.Objects, func() (string, error) {
+		return b.applyWithRetry(ctx, req.Summary, req.Objects)
+	})
+	if err != nil {
+		b.approvals.Notify(req.ConversationID, fmt.Sprintf("Your request #%s (%s) was approved by %s, but applying it failed: %v", req.ID, req.Summary, approver, err))
+		return "", err
+	}
+
+	outcome := "applied"
+	if branch != "" {
+		outcome = fmt.Sprintf("committed to branch '%s' for review", branch)
+	}
+	b.approvals.Notify(req.ConversationID, fmt.Sprintf("Your request #%s (%s) was approved by %s and %s.", req.ID, req.Summary, approver, outcome))
+	return fmt.Sprintf("✅ Request #%s approved and %s. %s has been notified.", req.ID, outcome, req.Requester), nil
+}
+
+// applyWithRetry applies objects, retrying errors the recovery policy allows.
+// It returns the GitOps branch when changes are committed instead.
+func (b *Bot) applyWithRetry(ctx context.Context, summary string, objects []manifest.Object) (string, error) {
+	logger := b.logger.WithContext(ctx)
+
+	attempts := 0
+	for {
+		attemptCtx, span := startAttempt(ctx, "apply", attempts)
+		branch, err := b.client(ctx).ApplyObjects(attemptCtx, summary, objects)
+		tracing.End(span, err)
+		if err == nil {
+			return branch, nil
+		}
+		if !recovery.ShouldRetry(err, attempts) {
+			logger.Error("Failed to apply objects",
+				logging.F("summary", summary),
+				logging.F("error", err),
+				logging.F("attempts", attempts),
+			)
+			return "", err
+		}
+		logger.Warn("Retrying apply",
+			logging.F("summary", summary),
+			logging.F("error", err),
+			logging.F("attempts", attempts),
+		)
+		time.Sleep(recovery.Backoff(err, attempts))
+		attempts++
+	}
+}
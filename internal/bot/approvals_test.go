@@ -0,0 +1,97 @@
+package bot_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dfaile/backstage-nobl9/internal/bot"
+	"github.com/dfaile/backstage-nobl9/internal/config"
+	"github.com/dfaile/backstage-nobl9/internal/errors"
+	"github.com/dfaile/backstage-nobl9/internal/identity"
+)
+
+var approver = identity.Caller{ID: "sam", Email: "sam@example.com", Source: "test"}
+
+// startApproval asks to create a project from the team template under a policy
+// that needs approval for new project editors. The request is #1.
+func startApproval(t *testing.T) (*bot.Bot, *fakeNobl9) {
+	t.Helper()
+	cfg := config.Default()
+	cfg.TemplatesDir = templatesDir(t, leadParameter)
+	cfg.Approvals = config.ApprovalPolicy{Roles: []string{"project-editor"}, Approvers: []string{approver.Email}}
+	b, api := newTestBot(t, cfg)
+
+	send(t, b, requester, "requester", "create-project payments-api --template team --description Payments")
+	send(t, b, requester, "requester", "lee@example.com")
+	response := send(t, b, requester, "requester", "yes")
+	assert.Contains(t, response, "This request needs approval because it grants project-editor to lee@example.com in project payments-api")
+	assert.Contains(t, response, "Request #1 was sent to: sam@example.com")
+	assert.Zero(t, api.Applies())
+	return b, api
+}
+
+func TestApproveRequest(t *testing.T) {
+	b, api := startApproval(t)
+
+	// Requesters cannot approve their own requests
+	_, err := b.HandleMessage(requester, "requester", "approve 1")
+	assert.Error(t, err)
+	assert.Zero(t, api.Applies())
+
+	response := send(t, b, approver, "approver", "approvals")
+	assert.Contains(t, response, "#1 Create project payments-api, requested by jane@example.com")
+
+	response = send(t, b, approver, "approver", "approve 1")
+	assert.Equal(t, "✅ Request #1 approved and applied. jane@example.com has been notified.", response)
+	assert.Equal(t, []string{"payments-api"}, api.Applied("Project"))
+	assert.Contains(t, api.Applied("RoleBinding"), "payments-api-lead")
+
+	response = send(t, b, approver, "approver", "approvals")
+	assert.Equal(t, "No requests are waiting for your approval.", response)
+
+	// The requester hears about it with their next message
+	response = send(t, b, requester, "requester", "plan")
+	assert.Contains(t, response, "🔔 Your request #1 (Create project payments-api) was approved by sam@example.com and applied.")
+	assert.Contains(t, response, "Plan mode is off.")
+}
+
+func TestDenyRequest(t *testing.T) {
+	b, api := startApproval(t)
+
+	response := send(t, b, approver, "approver", "deny 1")
+	assert.Equal(t, "🚫 Request #1 denied. jane@example.com has been notified.", response)
+	assert.Zero(t, api.Applies())
+
+	response = send(t, b, requester, "requester", "plan")
+	assert.Contains(t, response, "🔔 Your request #1 (Create project payments-api) was denied by sam@example.com.")
+}
+
+func TestApprovalNeedsVerifiedEmail(t *testing.T) {
+	b, _ := startApproval(t)
+
+	_, err := b.HandleMessage(identity.Caller{ID: "sam", Source: "test"}, "approver", "approve 1")
+	assert.True(t, errors.IsPermissionError(err))
+
+	response := send(t, b, approver, "approver", "approvals")
+	assert.Contains(t, response, "#1 Create project payments-api")
+}
+
+func TestApprovalForCriticalProjectChange(t *testing.T) {
+	cfg := config.Default()
+	cfg.Approvals = config.ApprovalPolicy{ProjectLabels: map[string]string{"tier": "critical"}, Approvers: []string{approver.Email}}
+	b, api := newTestBot(t, cfg)
+
+	// Creating a critical project is not a change to one
+	send(t, b, requester, "c1", "create-project payments-api --description Payments --label tier=critical")
+	response := send(t, b, requester, "c1", "yes")
+	assert.Equal(t, "Project created successfully! You are now its project owner.", response)
+
+	// A manifest dropping the label is checked against the label the project has
+	response = send(t, b, requester, "c1", "apply\n- apiVersion: n9/v1alpha\n  kind: Project\n  metadata:\n    name: payments-api\n  spec:\n    description: Moved\n")
+	assert.Contains(t, response, "Apply these objects?")
+	response = send(t, b, requester, "c1", "yes")
+	assert.Contains(t, response, "This request needs approval because it changes project payments-api, which is labeled tier=critical")
+	assert.Contains(t, response, "was sent to: sam@example.com")
+	assert.Equal(t, 1, api.Applies())
+}
@@ -0,0 +1,84 @@
+package bot
+
+import (
+	"context"
+
+	"github.com/nobl9/nobl9-go/manifest"
+
+	"github.com/dfaile/backstage-nobl9/internal/audit"
+	"github.com/dfaile/backstage-nobl9/internal/errors"
+	"github.com/dfaile/backstage-nobl9/internal/identity"
+	"github.com/dfaile/backstage-nobl9/internal/logging"
+)
+
+// SetAuditLog sets the log every mutation is recorded in. Once set, mutations are
+// refused whenever the log cannot be written.
+func (b *Bot) SetAuditLog(log *audit.Log) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.audit = log
+}
+
+// Close closes the bot's audit log. Changes are refused afterwards, as they can
+// no longer be recorded.
+func (b *Bot) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.audit == nil {
+		return nil
+	}
+	return b.audit.Close()
+}
+
+// mutate runs apply, recording the attempt and its outcome in the audit log.
+// Nothing is applied if the attempt cannot be recorded. apply returns the GitOps
+// branch when changes are committed for review instead.
+func (b *Bot) mutate(ctx context.Context, command, summary string, objects []manifest.Object, apply func() (string, error)) (branch string, err error) {
+	b.count(MetricMutations)
+	defer func() {
+		if err != nil {
+			b.count(MetricMutationFailures)
+		}
+	}()
+
+	if b.audit == nil {
+		return apply()
+	}
+	logger := b.logger.WithContext(ctx)
+
+	caller, _ := identity.FromContext(ctx)
+	rec := audit.Record{
+		RequestID: logging.RequestID(ctx),
+		Tenant:    b.tenant,
+		Caller:    caller,
+		Command:   command,
+		Summary:   summary,
+		Objects:   audit.Objects(objects),
+		Outcome:   audit.OutcomeAttempted,
+	}
+	if _, err := b.audit.Append(rec); err != nil {
+		logger.Error("Refusing mutation, audit log cannot be written",
+			logging.F("command", command),
+			logging.F("error", err),
+		)
+		return "", errors.NewInternalError("the change was not made because the audit log cannot be written", err)
+	}
+
+	branch, applyErr := apply()
+
+	rec.Outcome = audit.OutcomeSucceeded
+	rec.Branch = branch
+	if applyErr != nil {
+		rec.Outcome = audit.OutcomeFailed
+		rec.Error = applyErr.Error()
+	}
+	if _, err := b.audit.Append(rec); err != nil {
+		// The change has already happened, so report it and keep its result
+		logger.Error("Failed to record mutation outcome in audit log",
+			logging.F("command", command),
+			logging.F("outcome", rec.Outcome),
+			logging.F("error", err),
+		)
+	}
+	return branch, applyErr
+}
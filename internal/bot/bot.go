@@ -5,22 +5,54 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/nobl9/nobl9-go/manifest"
+	"github.com/nobl9/nobl9-go/manifest/v1alpha/project"
+	"github.com/nobl9/nobl9-go/sdk"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/dfaile/backstage-nobl9/internal/approval"
+	"github.com/dfaile/backstage-nobl9/internal/audit"
+	"github.com/dfaile/backstage-nobl9/internal/authz"
 	"github.com/dfaile/backstage-nobl9/internal/command"
+	"github.com/dfaile/backstage-nobl9/internal/config"
 	"github.com/dfaile/backstage-nobl9/internal/errors"
+	"github.com/dfaile/backstage-nobl9/internal/identity"
 	"github.com/dfaile/backstage-nobl9/internal/interactive"
 	"github.com/dfaile/backstage-nobl9/internal/logging"
+	"github.com/dfaile/backstage-nobl9/internal/metrics"
 	"github.com/dfaile/backstage-nobl9/internal/nobl9"
+	"github.com/dfaile/backstage-nobl9/internal/plugin"
 	"github.com/dfaile/backstage-nobl9/internal/recovery"
+	"github.com/dfaile/backstage-nobl9/internal/templates"
+	"github.com/dfaile/backstage-nobl9/internal/tracing"
+	"github.com/dfaile/backstage-nobl9/internal/validation"
 )
 
 // ConversationState represents the state of a conversation
 type ConversationState struct {
 	ProjectName        string
 	ProjectDescription string
+	ProjectLabels      map[string]string
+	LabelPolicies      []config.LabelPolicy // Label policies the wizard asks for, as configured when it started
+	LabelIndex         int
+	TemplateName       string
+	Template           *templates.Template // Template as loaded when the wizard started, a reload does not change it
+	TemplateParams     map[string]string
+	ParamIndex         int
+	Objects            []manifest.Object // Rendered template or applied manifest objects awaiting confirmation
+	ManifestLines      []string          // Pasted manifest lines awaiting the EOF terminator
+	Caller             identity.Caller   // Who sent the latest message in this conversation
+	Command            string            // Command that started the wizard in progress, its steps run as it
+	Nobl9Context       string            // Context picked with use-context, the bot's own connection when empty
+	Requester          string            // Platform-verified Nobl9 email of the caller, empty when the platform does not know it
+	DryRun             bool              // Plan the current flow instead of applying it
+	PlanMode           bool              // Plan every mutation in this conversation
 	Owner              string
 	CreatedAt          time.Time
 	UserRoles          map[string][]string // Map of user email to roles
@@ -36,68 +68,172 @@ type ConversationState struct {
 type Bot struct {
 	nobl9Client *nobl9.Client
 	logger      logging.Logger
+	logLevels   *logging.Levels // Shared with logger, changed by the log-level command
 	commands    *command.CommandRegistry
 	state       map[string]*ConversationState
 	mu          sync.RWMutex
+
+	// Held for reading while a message is handled, so ApplyConfig swaps config between messages
+	reloadMu sync.RWMutex
+	cfg      *config.Config // Config last applied with ApplyConfig
+
+	labelPolicies []config.LabelPolicy
+	templates     *templates.Registry
+	nameValidator *validation.ProjectNameValidator
+	allowedKinds  []string
+	applyFilesDir string // Directory apply <file> reads from, files are refused when empty
+
+	contextClients map[string]*nobl9.Client // Connections with the contexts conversations picked with use-context
+
+	approvalPolicy config.ApprovalPolicy
+	approvals      *approval.Queue
+	authorizer     *authz.Authorizer
+	audit          *audit.Log
+	commandLimits  *callerLimits // Commands each caller may run a minute, nil for no limit
+
+	plugins map[string]*plugin.Plugin // Plugin commands in the registry, by command name
+
+	helpResources config.HelpResources
+	frontend      config.FrontendConfig
+
+	tenant  string           // Tenant the bot serves in multi-tenant mode
+	metrics *metrics.Metrics // Not recorded when nil
+}
+
+// defaultCommands returns a registry with the built-in commands
+func defaultCommands() *command.CommandRegistry {
+	commands := command.NewCommandRegistry()
+	commands.Register(&command.Command{
+		Name:        "help",
+		Aliases:     []string{"h", "?"},
+		Description: "Show available commands or help for a specific command",
+		Spec:        helpSpec,
+		Handler:     command.HelpCommand,
+	})
+	commands.Register(&command.Command{
+		Name:        "create-project",
+		Aliases:     []string{"create", "new"},
+		Description: "Create a new Nobl9 project",
+		Spec:        createProjectSpec,
+		Permissions: []authz.Permission{authz.PermissionProjectsCreate},
+		Handler:     command.CreateProjectCommand,
+	})
+	commands.Register(&command.Command{
+		Name:        "assign-role",
+		Aliases:     []string{},
+		Description: "Assign a role to a user in a project",
+		Spec:        assignRoleSpec,
+		Permissions: []authz.Permission{authz.PermissionRolesAssign},
+		Handler:     command.AssignRoleCommand,
+	})
+	commands.Register(&command.Command{
+		Name:        "list-projects",
+		Aliases:     []string{"list", "ls"},
+		Description: "List available projects",
+		Usage:       "list-projects",
+		Permissions: []authz.Permission{authz.PermissionProjectsRead},
+		Handler:     command.ListProjectsCommand,
+	})
+	commands.Register(&command.Command{
+		Name:        "export-project",
+		Aliases:     []string{"export"},
+		Description: "Export a project and its role bindings as a manifest for sloctl apply",
+		Spec:        command.ExportProjectSpec,
+		Permissions: []authz.Permission{authz.PermissionProjectsRead},
+		Handler:     command.ExportProjectCommand,
+	})
+	commands.Register(&command.Command{
+		Name:        "apply",
+		Description: "Apply a manifest of Project and RoleBinding objects",
+		Spec:        applySpec,
+		Permissions: []authz.Permission{authz.PermissionManifestsApply},
+	})
+	commands.Register(&command.Command{
+		Name:        "approvals",
+		Description: "List the approval requests waiting for your decision",
+		Usage:       "approvals",
+		Permissions: []authz.Permission{authz.PermissionApprovalsDecide},
+	})
+	commands.Register(&command.Command{
+		Name:        "approve",
+		Description: "Approve a pending request",
+		Spec:        decideSpec,
+		Permissions: []authz.Permission{authz.PermissionApprovalsDecide},
+	})
+	commands.Register(&command.Command{
+		Name:        "deny",
+		Description: "Deny a pending request",
+		Spec:        decideSpec,
+		Permissions: []authz.Permission{authz.PermissionApprovalsDecide},
+	})
+	commands.Register(&command.Command{
+		Name:        "audit",
+		Description: "Search the audit log of changes made through the bot",
+		Spec:        command.AuditSpec,
+		Permissions: []authz.Permission{authz.PermissionAuditRead},
+		Handler:     command.AuditCommand,
+	})
+	commands.Register(&command.Command{
+		Name:        "contexts",
+		Description: "List the Nobl9 contexts in the sloctl config.toml",
+		Usage:       "contexts",
+		Permissions: []authz.Permission{authz.PermissionContextsRead},
+		Handler:     command.ContextsCommand,
+	})
+	commands.Register(&command.Command{
+		Name:        "use-context",
+		Description: "Switch this conversation to another Nobl9 context",
+		Spec:        useContextSpec,
+		Permissions: []authz.Permission{authz.PermissionContextsUse},
+		Handler:     command.UseContextCommand,
+	})
+	commands.Register(&command.Command{
+		Name:        "current-context",
+		Description: "Show the Nobl9 context this conversation uses",
+		Usage:       "current-context",
+		Permissions: []authz.Permission{authz.PermissionContextsRead},
+		Handler:     command.CurrentContextCommand,
+	})
+	commands.Register(&command.Command{
+		Name:        "log-level",
+		Description: "Show or change the log levels while the bot runs",
+		Usage:       "log-level [<level>] | log-level <package> <level|reset>",
+		Permissions: []authz.Permission{authz.PermissionLoggingConfigure},
+		Handler:     command.LogLevelCommand,
+	})
+	commands.Register(&command.Command{
+		Name:        "plan",
+		Description: "Show or toggle plan mode, which previews changes without applying them",
+		Spec:        planSpec,
+	})
+	return commands
 }
 
 // NewBot creates a new bot instance
 func NewBot(nobl9Client *nobl9.Client, commands *command.CommandRegistry) *Bot {
 	if commands == nil {
-		commands = command.NewCommandRegistry()
-		// Register default commands
-		commands.Register(&command.Command{
-			Name:        "help",
-			Aliases:     []string{"h", "?"},
-			Description: "Show available commands or help for a specific command",
-			Usage:       "help [command]",
-			Handler:     command.HelpCommand,
-		})
-		commands.Register(&command.Command{
-			Name:        "create-project",
-			Aliases:     []string{"create", "new"},
-			Description: "Create a new Nobl9 project",
-			Usage:       "create-project <name>",
-			Handler:     command.CreateProjectCommand,
-			Validate: func(args []string) error {
-				if len(args) == 0 {
-					return errors.NewValidationError("project name is required", nil)
-				}
-				return nil
-			},
-		})
-		commands.Register(&command.Command{
-			Name:        "assign-role",
-			Aliases:     []string{},
-			Description: "Assign a role to a user in a project",
-			Usage:       "assign-role [<project> <user>]",
-			Handler:     command.AssignRoleCommand,
-			Validate: func(args []string) error {
-				if len(args) != 0 && len(args) != 2 {
-					return errors.NewValidationError("usage: assign-role [<project> <user>] or just 'assign-role' for interactive mode", nil)
-				}
-				return nil
-			},
-		})
-		commands.Register(&command.Command{
-			Name:        "list-projects",
-			Aliases:     []string{"list", "ls"},
-			Description: "List available projects",
-			Usage:       "list-projects",
-			Handler:     command.ListProjectsCommand,
-		})
+		commands = defaultCommands()
 	}
 
-	logger, err := logging.NewLogger(logging.LevelWarn)
+	logger, logLevels, err := newLogger(config.Default().Logging, "")
 	if err != nil {
 		panic(fmt.Sprintf("failed to create logger: %v", err))
 	}
 
+	nameValidator, err := validation.NewProjectNameValidator(config.NamingPolicy{})
+	if err != nil {
+		panic(fmt.Sprintf("failed to create name validator: %v", err))
+	}
+
 	return &Bot{
-		nobl9Client: nobl9Client,
-		logger:      logger,
-		commands:    commands,
-		state:       make(map[string]*ConversationState),
+		nobl9Client:   nobl9Client,
+		logger:        logger,
+		logLevels:     logLevels,
+		commands:      commands,
+		state:         make(map[string]*ConversationState),
+		templates:     templates.NewRegistry(),
+		nameValidator: nameValidator,
+		approvals:     approval.NewQueue(),
 	}
 }
 
@@ -111,13 +247,152 @@ func (b *Bot) SetCommands(commands *command.CommandRegistry) {
 	b.commands = commands
 }
 
-// HandleMessage handles an incoming message and returns a response
-func (b *Bot) HandleMessage(conversationID string, message string) (string, error) {
-	ctx := context.WithValue(context.Background(), "conversation_id", conversationID)
+// SetLabelPolicies sets the labels the create-project wizard asks for
+func (b *Bot) SetLabelPolicies(policies []config.LabelPolicy) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.labelPolicies = policies
+}
+
+// SetTemplates sets the registry of create-project templates
+func (b *Bot) SetTemplates(registry *templates.Registry) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.templates = registry
+}
+
+// SetNameValidator sets the validator applied to project names before any API call
+func (b *Bot) SetNameValidator(validator *validation.ProjectNameValidator) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.nameValidator = validator
+}
+
+// SetAllowedKinds sets the object kinds the apply command accepts
+func (b *Bot) SetAllowedKinds(kinds []string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.allowedKinds = kinds
+}
+
+// SetApprovalPolicy sets the rules that send requests to the approval queue
+func (b *Bot) SetApprovalPolicy(policy config.ApprovalPolicy) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.approvalPolicy = policy
+}
+
+// SetLogger sets the logger
+func (b *Bot) SetLogger(logger logging.Logger) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.logger = logger
+}
+
+// Logger returns the logger, which ApplyConfig replaces when the logging config changes
+func (b *Bot) Logger() logging.Logger {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.logger
+}
+
+// SetHelpResources sets the documentation links shown in help and error messages
+func (b *Bot) SetHelpResources(resources config.HelpResources) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.helpResources = resources
+}
+
+// SetFrontend sets the interactive CLI's prompt and welcome message
+func (b *Bot) SetFrontend(frontend config.FrontendConfig) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.frontend = frontend
+}
+
+// userNotFoundMessage asks for another email, pointing at the user setup guide if configured
+func (b *Bot) userNotFoundMessage() string {
+	if b.helpResources.UserSetup != "" {
+		return fmt.Sprintf("User not found. They may need to be invited to Nobl9 first, see %s. Please enter a valid email:", b.helpResources.UserSetup)
+	}
+	return "User not found. Please enter a valid email:"
+}
+
+// SetAuthorizer sets the authorizer that decides which commands each caller may run
+func (b *Bot) SetAuthorizer(authorizer *authz.Authorizer) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.authorizer = authorizer
+}
+
+// allowedCommands returns the commands the caller may run, sorted by name
+func (b *Bot) allowedCommands(state *ConversationState) []*command.Command {
+	var allowed []*command.Command
+	for _, cmd := range b.commands.List() {
+		if b.authorizer.Allowed(state.Caller.Identity(), cmd.Permissions) {
+			allowed = append(allowed, cmd)
+		}
+	}
+	sort.Slice(allowed, func(i, j int) bool {
+		return allowed[i].Name < allowed[j].Name
+	})
+	return allowed
+}
+
+// HandleMessage handles an incoming message from caller and returns a response.
+// Notifications waiting for the conversation, such as approval outcomes, are delivered first.
+func (b *Bot) HandleMessage(caller identity.Caller, conversationID string, message string) (string, error) {
+	return b.HandleMessageContext(context.Background(), caller, conversationID, message)
+}
+
+// HandleMessageContext handles a message like HandleMessage. The message is traced
+// as part of the trace in ctx, such as the one an HTTP integration extracts with
+// tracing.Extract.
+func (b *Bot) HandleMessageContext(ctx context.Context, caller identity.Caller, conversationID string, message string) (string, error) {
+	b.reloadMu.RLock()
+	defer b.reloadMu.RUnlock()
+
+	// The request ID ties the trace to the message's log entries
+	requestID := logging.NewRequestID()
+	ctx = logging.WithRequestID(ctx, requestID)
+	ctx, span := tracing.Start(ctx, "bot.HandleMessage",
+		attribute.String("bot.request_id", requestID),
+		attribute.String("bot.conversation_id", conversationID),
+		attribute.String("bot.caller.source", caller.Source),
+		attribute.String("bot.tenant", b.tenant),
+	)
+	// Nothing a command prints or an API echoes back may leak a secret into the chat
+	response, err := b.handleMessage(ctx, caller, conversationID, message)
+	tracing.End(span, err)
+	if err != nil {
+		return logging.Redact(response), logging.RedactError(err)
+	}
+
+	notices := b.approvals.Drain(conversationID)
+	if len(notices) == 0 {
+		return logging.Redact(response), nil
+	}
+	var sb strings.Builder
+	for _, notice := range notices {
+		sb.WriteString(fmt.Sprintf("🔔 %s\n", notice))
+	}
+	if response != "" {
+		sb.WriteString("\n")
+		sb.WriteString(response)
+	}
+	return logging.Redact(strings.TrimRight(sb.String(), "\n")), nil
+}
+
+// handleMessage routes a message to the pending prompt, a command or the natural language handler
+func (b *Bot) handleMessage(ctx context.Context, caller identity.Caller, conversationID string, message string) (string, error) {
+	ctx = logging.WithConversationID(ctx, conversationID)
+	ctx = logging.WithUserID(ctx, caller.Identity())
+	ctx = identity.NewContext(ctx, caller)
 	logger := b.logger.WithContext(ctx)
 
 	// Get or create conversation state
 	state, exists := b.GetConversationState(conversationID)
+
 	if !exists {
 		// Initialize new conversation state
 		state = &ConversationState{
@@ -134,10 +409,60 @@ func (b *Bot) HandleMessage(conversationID string, message string) (string, erro
 		}
 	}
 
-	// Handle interactive responses
-	if state.PendingPrompt != nil {
-		response, err := b.handlePromptResponse(ctx, state, message)
+	// Only the platform-verified email of whoever sent this message identifies
+	// the requester, emails typed into the conversation are never trusted
+	b.mu.Lock()
+	state.Caller = caller
+	state.Requester = caller.Email
+	b.mu.Unlock()
+
+	// A conversation that picked a context with use-context talks to Nobl9 through it
+	if state.Nobl9Context != "" {
+		client, err := b.clientFor(state.Nobl9Context)
 		if err != nil {
+			name := state.Nobl9Context
+			state.Nobl9Context = ""
+			return "", errors.NewValidationError(fmt.Sprintf("failed to connect with context '%s', switched back to the bot's own connection", name), err)
+		}
+		ctx = withClient(ctx, client)
+	}
+
+	// Pasted manifest lines are only collected, so they do not count as commands.
+	// The line ending the manifest is a step like any other.
+	if state.CurrentStep == "manifest_input" && !endsManifest(message) {
+		state.ManifestLines = append(state.ManifestLines, strings.Split(message, "\n")...)
+		return "", nil
+	}
+
+	// Handle interactive responses. Each step runs as the command that started
+	// the wizard, through the same middleware.
+	if state.PendingPrompt != nil {
+		req := &command.Request{Context: ctx, Command: b.wizardCommand(state), Caller: caller, Step: true}
+		response, err := b.runCommand(req, func(req *command.Request) (string, error) {
+			// A step that panics leaves the wizard in an unknown state, so it starts over
+			finished := false
+			defer func() {
+				if !finished {
+					state.Reset()
+				}
+			}()
+			stepCtx, span := tracing.Start(req.Context, "bot.wizard_step", attribute.String("bot.step", state.CurrentStep))
+			response, err := b.handlePromptResponse(stepCtx, state, message)
+			tracing.End(span, err)
+			finished = true
+			// Steps that reset the state and prompt again stay in the same wizard
+			if state.PendingPrompt != nil {
+				state.Command = req.Command.Name
+			}
+			return response, err
+		})
+		switch {
+		case errors.IsPermissionError(err):
+			state.Reset()
+			return "", err
+		case errors.IsRateLimitError(err):
+			return "", err
+		case err != nil:
 			logger.Warn("Invalid response",
 				logging.F("error", err),
 				logging.F("message", message),
@@ -147,88 +472,113 @@ func (b *Bot) HandleMessage(conversationID string, message string) (string, erro
 		return response, nil
 	}
 
+	// A multi-line apply message carries its manifest inline
+	if first, rest, found := strings.Cut(message, "\n"); found && strings.TrimSpace(first) == "apply" {
+		cmd, ok := b.commands.Get("apply")
+		if !ok {
+			cmd = &command.Command{Name: "apply"}
+		}
+		req := &command.Request{Context: ctx, Command: cmd, Caller: caller}
+		return b.runCommand(req, func(req *command.Request) (string, error) {
+			logger.Info("Handling inline manifest")
+			state.Reset()
+			response, err := b.reviewManifest(req.Context, state, []byte(rest))
+			if state.PendingPrompt != nil {
+				state.Command = req.Command.Name
+			}
+			return response, err
+		})
+	}
+
 	// Handle help command specifically
 	if strings.ToLower(strings.TrimSpace(message)) == "help" {
-		return b.getHelpMessage(), nil
+		return b.getHelpMessage(state), nil
 	}
 
 	// Handle commands
-	if cmd, args := b.parseCommand(message); cmd != nil {
-		logger.Info("Handling command",
-			logging.F("command", cmd.Name),
-			logging.F("args", args),
-		)
-		return b.handleCommand(ctx, state, cmd, args)
+	cmd, args, err := b.parseCommand(message)
+	if err != nil {
+		return "", err
+	}
+	if cmd != nil {
+		return b.runCommand(&command.Request{Context: ctx, Command: cmd, Args: args, Caller: caller}, b.commandHandler(state))
 	}
 
 	// Handle default message
 	return b.handleNaturalLanguage(message), nil
 }
 
-// parseCommand parses user input into a command and arguments
-func (b *Bot) parseCommand(input string) (*command.Command, []string) {
+// endsManifest reports whether message contains the EOF line ending a pasted manifest
+func endsManifest(message string) bool {
+	for _, line := range strings.Split(message, "\n") {
+		if strings.TrimSpace(line) == "EOF" {
+			return true
+		}
+	}
+	return false
+}
+
+// parseCommand parses user input into a command and arguments. Arguments may be
+// quoted like in a shell, e.g. --description "Payments team services".
+func (b *Bot) parseCommand(input string) (*command.Command, []string, error) {
 	input = strings.TrimSpace(input)
 	if input == "" {
-		return nil, nil
+		return nil, nil, nil
 	}
 
 	// Handle special multi-word commands first
 	if strings.HasPrefix(strings.ToLower(input), "assign role") {
 		// Treat "assign role" as "assign-role" command
-		remainingInput := strings.TrimSpace(input[11:]) // Remove "assign role"
-		var args []string
-		if remainingInput != "" {
-			args = strings.Fields(remainingInput)
+		args, err := command.Split(input[11:]) // Remove "assign role"
+		if err != nil {
+			return nil, nil, err
 		}
 		if cmd, exists := b.commands.Get("assign-role"); exists {
-			return cmd, args
+			return cmd, args, nil
 		}
 	}
 
 	if strings.HasPrefix(strings.ToLower(input), "create project") {
 		// Treat "create project" as "create-project" command
-		remainingInput := strings.TrimSpace(input[14:]) // Remove "create project"
-		var args []string
-		if remainingInput != "" {
-			args = strings.Fields(remainingInput)
+		args, err := command.Split(input[14:]) // Remove "create project"
+		if err != nil {
+			return nil, nil, err
 		}
 		if cmd, exists := b.commands.Get("create-project"); exists {
-			return cmd, args
+			return cmd, args, nil
 		}
 	}
 
 	if strings.HasPrefix(strings.ToLower(input), "list projects") {
 		// Treat "list projects" as "list-projects" command
 		if cmd, exists := b.commands.Get("list-projects"); exists {
-			return cmd, []string{}
+			return cmd, []string{}, nil
 		}
 	}
 
-	// Split into fields for normal command parsing
-	fields := strings.Fields(input)
-	if len(fields) == 0 {
-		return nil, nil
-	}
-
 	// Get command name (with or without /)
-	cmdName := fields[0]
-	if strings.HasPrefix(cmdName, "/") {
-		cmdName = strings.TrimPrefix(cmdName, "/")
-	}
-	
-	args := fields[1:]
+	name := strings.Fields(input)[0]
+	cmdName := strings.TrimPrefix(name, "/")
 
 	// Look up the command in the registry
 	cmd, exists := b.commands.Get(cmdName)
 	if !exists {
-		return nil, nil
+		return nil, nil, nil
 	}
 
-	return cmd, args
+	// Only a command's arguments are split, so other messages may quote freely
+	args, err := command.Split(input[len(name):])
+	if err != nil {
+		return nil, nil, err
+	}
+	return cmd, args, nil
 }
 
 // getWelcomeMessage returns a friendly welcome message
 func (b *Bot) getWelcomeMessage() string {
+	if b.frontend.Welcome != "" {
+		return b.frontend.Welcome
+	}
 	return `👋 Hello! I'm your Nobl9 Project Bot. I can help you:
 
 🏗️  **Create new projects** - Just say "create project" or "new project"
@@ -244,15 +594,18 @@ What would you like to do?`
 }
 
 // getHelpMessage returns detailed help information
-func (b *Bot) getHelpMessage() string {
-	return `🤖 **Nobl9 Project Bot Help**
-
-**Available Commands:**
-• **create-project** (or "create", "new") - Create a new Nobl9 project
-• **assign-role** (or "assign", "role") - Assign roles to users
-• **list-projects** (or "list", "ls") - List available projects
-• **help** - Show this help message
+func (b *Bot) getHelpMessage(state *ConversationState) string {
+	var sb strings.Builder
+	sb.WriteString("🤖 **Nobl9 Project Bot Help**\n\n**Available Commands:**\n")
+	for _, cmd := range b.allowedCommands(state) {
+		sb.WriteString(fmt.Sprintf("• **%s**", cmd.Name))
+		if len(cmd.Aliases) > 0 {
+			sb.WriteString(fmt.Sprintf(" (or \"%s\")", strings.Join(cmd.Aliases, "\", \"")))
+		}
+		sb.WriteString(fmt.Sprintf(" - %s\n", cmd.Description))
+	}
 
+	sb.WriteString(`
 **Natural Language:**
 You can also try saying things like:
 • "I want to create a new project"
@@ -262,9 +615,15 @@ You can also try saying things like:
 
 **Examples:**
 • create-project my-awesome-service
-• assign-role my-project user@example.com
+• create-project --dry-run my-awesome-service
+• create-project --description "Payments team services" --label team=payments payments-api
+• assign-role --role member my-project user@example.com
 
-Type anything to get started!`
+Type anything to get started!`)
+	if b.helpResources.UserSetup != "" {
+		sb.WriteString(fmt.Sprintf("\n\n📚 New to Nobl9? %s", b.helpResources.UserSetup))
+	}
+	return sb.String()
 }
 
 // handleNaturalLanguage tries to understand natural language input
@@ -337,74 +696,81 @@ func (b *Bot) handlePromptResponse(ctx context.Context, state *ConversationState
 		return prompt.Format(), nil
 		
 	case "project_name":
-		// Validate project name with retry
-		var available bool
-		var validateErr error
-		attempts := 0
-		for {
-			available, validateErr = b.ValidateProjectName(ctx, state.ProjectName, response)
-			if validateErr == nil {
-				break
-			}
-			if !recovery.ShouldRetry(validateErr, attempts) {
-				logger.Error("Failed to validate project name",
-					logging.F("error", validateErr),
-					logging.F("attempts", attempts),
-				)
-				return "", validateErr
-			}
-			logger.Warn("Retrying project name validation",
-				logging.F("error", validateErr),
-				logging.F("attempts", attempts),
-			)
-			time.Sleep(recovery.GetRetryDelay(validateErr))
-			attempts++
-		}
-
-		if !available {
-			logger.Info("Project name not available",
-				logging.F("project_name", response),
-			)
-			prompt := interactive.NewPrompt(
-				"Project name is not available. Please choose another name:",
-				nil,
-				"",
-			)
-			state.PendingPrompt = prompt
-			return prompt.Format(), nil
-		}
-
-		logger.Info("Project name validated",
-			logging.F("project_name", response),
-		)
-		state.ProjectName = response
-		state.CurrentStep = "project_description"
-
-		// Prompt for project description
-		prompt := interactive.NewPrompt(
-			"Please provide a description for the project:",
-			nil,
-			"",
-		)
-		state.PendingPrompt = prompt
-		return prompt.Format(), nil
+		return b.submitProjectName(ctx, state, strings.TrimSpace(response))
 
 	case "project_description":
 		state.ProjectDescription = response
-		state.CurrentStep = "confirm_creation"
 
 		logger.Info("Project description received",
 			logging.F("project_name", state.ProjectName),
 			logging.F("description", response),
 		)
 
-		// Show confirmation
-		confirm := interactive.NewConfirmation(
-			fmt.Sprintf("Create project '%s' with description '%s'?", state.ProjectName, state.ProjectDescription),
-			true,
+		return b.askLabels(state)
+
+	case "project_labels":
+		prompt, ok := state.PendingPrompt.(*interactive.Prompt)
+		if !ok {
+			return "", fmt.Errorf("invalid prompt type: expected Prompt")
+		}
+		if state.LabelIndex >= len(state.LabelPolicies) {
+			return b.nextLabel(state)
+		}
+		policy := state.LabelPolicies[state.LabelIndex]
+
+		if strings.TrimSpace(response) == "" && policy.Default == "" {
+			if policy.Required {
+				return "", errors.NewValidationError(fmt.Sprintf("label '%s' is required", policy.Key), nil)
+			}
+		} else {
+			value, err := prompt.Validate(response)
+			if err != nil {
+				return "", err
+			}
+			if !policy.Allows(value) {
+				return "", errors.NewValidationError(fmt.Sprintf("label '%s' must be one of: %s", policy.Key, strings.Join(policy.AllowedValues, ", ")), nil)
+			}
+			state.ProjectLabels[policy.Key] = value
+		}
+
+		logger.Info("Project label received",
+			logging.F("project_name", state.ProjectName),
+			logging.F("label", policy.Key),
+		)
+
+		state.LabelIndex++
+		return b.nextLabel(state)
+
+	case "template_params":
+		prompt, ok := state.PendingPrompt.(*interactive.Prompt)
+		if !ok {
+			return "", fmt.Errorf("invalid prompt type: expected Prompt")
+		}
+		if state.Template == nil || state.ParamIndex >= len(state.Template.Parameters) {
+			return b.nextCreationStep(state)
+		}
+		param := state.Template.Parameters[state.ParamIndex]
+
+		value := strings.TrimSpace(response)
+		if value != "" || param.Default != "" {
+			var err error
+			value, err = prompt.Validate(response)
+			if err != nil {
+				return "", err
+			}
+		}
+		if value == "" && param.Required {
+			return "", errors.NewValidationError(fmt.Sprintf("template parameter '%s' is required", param.Name), nil)
+		}
+		state.TemplateParams[param.Name] = value
+
+		logger.Info("Template parameter received",
+			logging.F("template", state.TemplateName),
+			logging.F("parameter", param.Name),
 		)
-		state.PendingPrompt = confirm
-		return confirm.Format(), nil
+
+		state.ParamIndex++
+		return b.nextCreationStep(state)
 
 	case "confirm_creation":
 		confirm, ok := state.PendingPrompt.(*interactive.Confirmation)
@@ -428,42 +794,181 @@ func (b *Bot) handlePromptResponse(ctx context.Context, state *ConversationState
 			return "Project creation cancelled.", nil
 		}
 
+		// Enforce the label policy even if the wizard was bypassed. A reload since
+		// the wizard started does not change the policies it is held to.
+		if err := config.CheckLabels(state.LabelPolicies, state.ProjectLabels); err != nil {
+			logger.Warn("Project labels rejected",
+				logging.F("project_name", state.ProjectName),
+				logging.F("error", err),
+			)
+			state.Reset()
+			return "", err
+		}
+
+		objects := state.Objects
+		if objects != nil {
+			objects = nobl9.WithOwnership(objects, state.Requester)
+		} else {
+			objects = nobl9.ProjectObjects(state.ProjectName, state.ProjectDescription, state.ProjectLabels, state.Requester)
+		}
+
+		// Templates are held to the same kinds and policies as apply
+		if err := b.checkObjects(objects, state.LabelPolicies); err != nil {
+			logger.Warn("Project objects rejected",
+				logging.F("project_name", state.ProjectName),
+				logging.F("template", state.TemplateName),
+				logging.F("error", err),
+			)
+			state.Reset()
+			return "", err
+		}
+
+		if state.planning() {
+			return b.plan(ctx, state, objects)
+		}
+
+		summary := fmt.Sprintf("Create project %s", state.ProjectName)
+		reasons, err := b.approvalReasons(ctx, withoutOwnership(objects, state.ProjectName, state.Requester))
+		if err != nil {
+			return "", err
+		}
+		if len(reasons) > 0 {
+			return b.requestApproval(ctx, state, summary, objects, reasons)
+		}
+
 		// Create project with retry
-		var projectErr error
-		attempts := 0
-		for {
-			_, projectErr = b.CreateProject(state.ProjectName, state.ProjectDescription)
-			if projectErr == nil {
-				break
-			}
-			if !recovery.ShouldRetry(projectErr, attempts) {
-				logger.Error("Failed to create project",
-					logging.F("error", projectErr),
+		branch, projectErr := b.mutate(ctx, "create-project", summary, objects, func() (string, error) {
+			attempts := 0
+			for {
+				var branch string
+				var err error
+				attemptCtx, span := startAttempt(ctx, "create-project", attempts)
+				if state.Objects != nil {
+					branch, err = b.client(ctx).ApplyObjects(attemptCtx, summary, objects)
+				} else {
+					var created *nobl9.Project
+					created, err = b.client(ctx).CreateProject(attemptCtx, state.ProjectName, state.ProjectDescription, state.ProjectLabels, state.Requester)
+					if created != nil {
+						branch = created.Branch
+					}
+				}
+				tracing.End(span, err)
+				if err == nil {
+					return branch, nil
+				}
+				if !recovery.ShouldRetry(err, attempts) {
+					logger.Error("Failed to create project",
+						logging.F("error", err),
+						logging.F("attempts", attempts),
+					)
+					return "", err
+				}
+				logger.Warn("Retrying project creation",
+					logging.F("error", err),
 					logging.F("attempts", attempts),
 				)
-				return "", projectErr
+				time.Sleep(recovery.Backoff(err, attempts))
+				attempts++
 			}
-			logger.Warn("Retrying project creation",
-				logging.F("error", projectErr),
-				logging.F("attempts", attempts),
-			)
-			time.Sleep(recovery.GetRetryDelay(projectErr))
-			attempts++
+		})
+		if projectErr != nil {
+			return "", projectErr
 		}
 
 		logger.Info("Project created",
 			logging.F("project_name", state.ProjectName),
+			logging.F("owner", state.Requester),
+			logging.F("template", state.TemplateName),
+			logging.F("objects", len(state.Objects)),
+			logging.F("branch", branch),
 		)
+		if branch != "" {
+			name := state.ProjectName
+			state.Reset()
+			return fmt.Sprintf("🔀 Project '%s' committed to branch '%s' for review. It will be created once the branch is merged and applied.", name, branch), nil
+		}
+		if state.Objects != nil {
+			count := len(state.Objects)
+			state.Reset()
+			return fmt.Sprintf("Project created successfully with %d object(s)! You are now its project owner.", count), nil
+		}
 		state.Reset()
-		return "Project created successfully!", nil
+		return "Project created successfully! You are now its project owner.", nil
+
+	case "manifest_input":
+		// Lines are collected verbatim so YAML indentation survives
+		for _, line := range strings.Split(response, "\n") {
+			if strings.TrimSpace(line) == "EOF" {
+				data := strings.Join(state.ManifestLines, "\n")
+				state.ManifestLines = nil
+				return b.reviewManifest(ctx, state, []byte(data))
+			}
+			state.ManifestLines = append(state.ManifestLines, line)
+		}
+		return "", nil
 
-	case "role_user":
-		// Validate user with retry
-		var exists bool
-		var err error
-		attempts := 0
-		for {
-			exists, err = b.ValidateUser(response)
+	case "confirm_apply":
+		confirm, ok := state.PendingPrompt.(*interactive.Confirmation)
+		if !ok {
+			return "", fmt.Errorf("invalid prompt type: expected Confirmation")
+		}
+
+		confirmed, confirmErr := confirm.Validate(response)
+		if confirmErr != nil {
+			logger.Error("Invalid confirmation response",
+				logging.F("error", confirmErr),
+				logging.F("response", response),
+			)
+			return "", confirmErr
+		}
+		if !confirmed {
+			logger.Info("Manifest apply cancelled",
+				logging.F("objects", len(state.Objects)),
+			)
+			state.Reset()
+			return "Apply cancelled.", nil
+		}
+
+		if state.planning() {
+			return b.plan(ctx, state, state.Objects)
+		}
+
+		summary := fmt.Sprintf("Apply manifest with %d object(s)", len(state.Objects))
+		reasons, err := b.approvalReasons(ctx, state.Objects)
+		if err != nil {
+			return "", err
+		}
+		if len(reasons) > 0 {
+			return b.requestApproval(ctx, state, summary, state.Objects, reasons)
+		}
+
+		branch, applyErr := b.mutate(ctx, "apply", summary, state.Objects, func() (string, error) {
+			return b.applyWithRetry(ctx, summary, state.Objects)
+		})
+		if applyErr != nil {
+			return "", applyErr
+		}
+
+		count := len(state.Objects)
+		logger.Info("Manifest applied",
+			logging.F("objects", count),
+			logging.F("branch", branch),
+		)
+		state.Reset()
+		if branch != "" {
+			return fmt.Sprintf("🔀 %d object(s) committed to branch '%s' for review.", count, branch), nil
+		}
+		return fmt.Sprintf("✅ Applied %d object(s).", count), nil
+
+	case "role_user":
+		// Validate user with retry
+		var exists bool
+		var err error
+		attempts := 0
+		for {
+			attemptCtx, span := startAttempt(ctx, "validate-user", attempts)
+			exists, err = b.client(ctx).ValidateUser(attemptCtx, response)
+			tracing.End(span, err)
 			if err == nil {
 				break
 			}
@@ -478,7 +983,7 @@ func (b *Bot) handlePromptResponse(ctx context.Context, state *ConversationState
 				logging.F("error", err),
 				logging.F("attempts", attempts),
 			)
-			time.Sleep(recovery.GetRetryDelay(err))
+			time.Sleep(recovery.Backoff(err, attempts))
 			attempts++
 		}
 
@@ -487,7 +992,7 @@ func (b *Bot) handlePromptResponse(ctx context.Context, state *ConversationState
 				logging.F("user", response),
 			)
 			state.PendingPrompt = interactive.NewPrompt(
-				"User not found. Please enter a valid email:",
+				b.userNotFoundMessage(),
 				nil,
 				"",
 			)
@@ -498,32 +1003,28 @@ func (b *Bot) handlePromptResponse(ctx context.Context, state *ConversationState
 			logging.F("user", response),
 		)
 		state.RoleUser = response
+		if state.RoleType != "" {
+			// The role was given with --role
+			return b.confirmRole(state), nil
+		}
 		state.CurrentStep = "role_type"
 
 		// Prompt for role type
 		state.PendingPrompt = interactive.NewPrompt(
 			"Please select a role type:",
-			[]string{"admin", "member", "viewer"},
-			"member",
+			nobl9.RoleNames(),
+			nobl9.DefaultRoleName(),
 		)
 		return state.PendingPrompt.(*interactive.Prompt).Format(), nil
 
 	case "role_type":
 		state.RoleType = response
-		state.CurrentStep = "confirm_role"
 
 		logger.Info("Role type received",
 			logging.F("user", state.RoleUser),
 			logging.F("role", response),
 		)
-
-		// Show confirmation
-		confirm := interactive.NewConfirmation(
-			fmt.Sprintf("Assign role '%s' to user '%s' in project '%s'?", state.RoleType, state.RoleUser, state.ProjectName),
-			true,
-		)
-		state.PendingPrompt = confirm
-		return confirm.Format(), nil
+		return b.confirmRole(state), nil
 
 	case "confirm_role":
 		confirm, ok := state.PendingPrompt.(*interactive.Confirmation)
@@ -549,34 +1050,45 @@ func (b *Bot) handlePromptResponse(ctx context.Context, state *ConversationState
 			return "Role assignment cancelled.", nil
 		}
 
+		objects, err := b.client(ctx).RoleBindingObjects(ctx, state.ProjectName, map[string][]string{
+			state.RoleUser: {state.RoleType},
+		})
+		if err != nil {
+			state.Reset()
+			return "", err
+		}
+		if state.planning() {
+			return b.plan(ctx, state, objects)
+		}
+
+		summary := fmt.Sprintf("Assign role %s to %s in project %s", state.RoleType, state.RoleUser, state.ProjectName)
+		reasons, err := b.approvalReasons(ctx, objects)
+		if err != nil {
+			return "", err
+		}
+		if len(reasons) > 0 {
+			return b.requestApproval(ctx, state, summary, objects, reasons)
+		}
+
 		// Assign role with retry
-		var assignErr error
-		attempts := 0
-		for {
-			assignErr = b.AssignRoles(state.ProjectName, []string{state.RoleUser})
-			if assignErr == nil {
-				break
-			}
-			if !recovery.ShouldRetry(assignErr, attempts) {
-				logger.Error("Failed to assign role",
-					logging.F("error", assignErr),
-					logging.F("attempts", attempts),
-				)
-				return "", assignErr
-			}
-			logger.Warn("Retrying role assignment",
-				logging.F("error", assignErr),
-				logging.F("attempts", attempts),
-			)
-			time.Sleep(recovery.GetRetryDelay(assignErr))
-			attempts++
+		branch, assignErr := b.mutate(ctx, "assign-role", summary, objects, func() (string, error) {
+			return b.applyWithRetry(ctx, summary, objects)
+		})
+		if assignErr != nil {
+			return "", assignErr
 		}
 
 		logger.Info("Role assigned",
 			logging.F("user", state.RoleUser),
 			logging.F("role", state.RoleType),
 			logging.F("project", state.ProjectName),
+			logging.F("branch", branch),
 		)
+		if branch != "" {
+			message := fmt.Sprintf("🔀 Role change for user '%s' in project '%s' committed to branch '%s' for review.", state.RoleUser, state.ProjectName, branch)
+			state.Reset()
+			return message, nil
+		}
 		state.Reset()
 		return "Role assigned successfully!", nil
 
@@ -585,16 +1097,454 @@ func (b *Bot) handlePromptResponse(ctx context.Context, state *ConversationState
 	}
 }
 
+// submitProjectName checks that a project name is available and moves the wizard
+// to the description step. Taken names are reported with their owners and alternatives.
+func (b *Bot) submitProjectName(ctx context.Context, state *ConversationState, name string) (string, error) {
+	logger := b.logger.WithContext(ctx)
+
+	// Check naming rules locally before asking the API
+	if problems := b.nameValidator.Problems(name); len(problems) > 0 {
+		logger.Info("Project name rejected by naming policy",
+			logging.F("project_name", name),
+			logging.F("problems", problems),
+		)
+		state.CurrentStep = "project_name"
+		prompt := interactive.NewPrompt(
+			fmt.Sprintf("Project name '%s' is not valid: %s\nPlease choose another name:", name, strings.Join(problems, "; ")),
+			nil,
+			"",
+		)
+		state.PendingPrompt = prompt
+		return prompt.Format(), nil
+	}
+
+	// Validate project name with retry
+	var availability *nobl9.NameAvailability
+	var validateErr error
+	attempts := 0
+	for {
+		attemptCtx, span := startAttempt(ctx, "validate-project-name", attempts)
+		availability, validateErr = b.ValidateProjectName(attemptCtx, name)
+		tracing.End(span, validateErr)
+		if validateErr == nil {
+			break
+		}
+		if !recovery.ShouldRetry(validateErr, attempts) {
+			logger.Error("Failed to validate project name",
+				logging.F("error", validateErr),
+				logging.F("attempts", attempts),
+			)
+			return "", validateErr
+		}
+		logger.Warn("Retrying project name validation",
+			logging.F("error", validateErr),
+			logging.F("attempts", attempts),
+		)
+		time.Sleep(recovery.Backoff(validateErr, attempts))
+		attempts++
+	}
+
+	if !availability.Available {
+		logger.Info("Project name not available",
+			logging.F("project_name", name),
+			logging.F("owners", availability.Owners),
+		)
+		state.CurrentStep = "project_name"
+		prompt := interactive.NewPrompt(
+			formatNameTaken(name, availability),
+			nil,
+			"",
+		)
+		state.PendingPrompt = prompt
+		return prompt.Format(), nil
+	}
+
+	logger.Info("Project name validated",
+		logging.F("project_name", name),
+	)
+	state.ProjectName = name
+	if state.ProjectDescription != "" {
+		// The description was given with --description
+		return b.askLabels(state)
+	}
+	state.CurrentStep = "project_description"
+
+	// Prompt for project description
+	prompt := interactive.NewPrompt(
+		fmt.Sprintf("Please provide a description for project '%s':", name),
+		nil,
+		"",
+	)
+	state.PendingPrompt = prompt
+	return prompt.Format(), nil
+}
+
+// formatNameTaken explains who owns a taken project name and what to use instead
+func formatNameTaken(name string, availability *nobl9.NameAvailability) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Project name '%s' is not available.", name))
+	if len(availability.Owners) > 0 {
+		sb.WriteString(fmt.Sprintf(" It is owned by %s.", strings.Join(availability.Owners, ", ")))
+	} else {
+		sb.WriteString(" Its owner could not be determined.")
+	}
+	if len(availability.Suggestions) > 0 {
+		sb.WriteString(fmt.Sprintf("\nAvailable alternatives: %s", strings.Join(availability.Suggestions, ", ")))
+	}
+	sb.WriteString("\nPlease choose another name:")
+	return sb.String()
+}
+
+// labelPrompt builds the prompt for a single project label
+func (b *Bot) labelPrompt(policy config.LabelPolicy) *interactive.Prompt {
+	requirement := "optional, press enter to skip"
+	if policy.Required {
+		requirement = "required"
+	}
+	message := fmt.Sprintf("Please enter a value for label '%s' (%s):", policy.Key, requirement)
+	if policy.Description != "" {
+		message = fmt.Sprintf("%s\n%s", message, policy.Description)
+	}
+	return interactive.NewPrompt(message, policy.AllowedValues, policy.Default)
+}
+
+// askLabels asks for the configured labels that were not given with --label,
+// then moves on to the next creation step
+func (b *Bot) askLabels(state *ConversationState) (string, error) {
+	state.LabelIndex = 0
+	if state.ProjectLabels == nil {
+		state.ProjectLabels = make(map[string]string)
+	}
+	return b.nextLabel(state)
+}
+
+// nextLabel prompts for the next configured label without a value, or moves on
+// once every label has been asked for
+func (b *Bot) nextLabel(state *ConversationState) (string, error) {
+	for ; state.LabelIndex < len(state.LabelPolicies); state.LabelIndex++ {
+		policy := state.LabelPolicies[state.LabelIndex]
+		if _, given := state.ProjectLabels[policy.Key]; given {
+			continue
+		}
+		state.CurrentStep = "project_labels"
+		prompt := b.labelPrompt(policy)
+		state.PendingPrompt = prompt
+		return prompt.Format(), nil
+	}
+	return b.nextCreationStep(state)
+}
+
+// checkLabelValues checks labels given with --label against the values their
+// label policies allow. Required labels left out are asked for later.
+func (b *Bot) checkLabelValues(labels map[string]string) error {
+	for _, policy := range b.labelPolicies {
+		if value, ok := labels[policy.Key]; ok && !policy.Allows(value) {
+			return errors.NewValidationError(fmt.Sprintf("label '%s' must be one of: %s", policy.Key, strings.Join(policy.AllowedValues, ", ")), nil)
+		}
+	}
+	return nil
+}
+
+// nextCreationStep prompts for the next template parameter or moves to
+// confirmation. The verified requester becomes the project owner.
+func (b *Bot) nextCreationStep(state *ConversationState) (string, error) {
+	if _, err := verifiedEmail(state, "creating a project"); err != nil {
+		state.Reset()
+		return "", err
+	}
+
+	tmpl := state.Template
+	if tmpl == nil {
+		return b.confirmCreation(state), nil
+	}
+
+	if state.ParamIndex < len(tmpl.Parameters) {
+		param := tmpl.Parameters[state.ParamIndex]
+		state.CurrentStep = "template_params"
+		message := fmt.Sprintf("Please enter a value for template parameter '%s':", param.Name)
+		if param.Description != "" {
+			message = fmt.Sprintf("%s\n%s", message, param.Description)
+		}
+		prompt := interactive.NewPrompt(message, param.Options, param.Default)
+		state.PendingPrompt = prompt
+		return prompt.Format(), nil
+	}
+
+	objects, err := tmpl.Render(templates.Data{
+		Project:     state.ProjectName,
+		Description: state.ProjectDescription,
+		Labels:      state.ProjectLabels,
+		Params:      state.TemplateParams,
+	})
+	if err != nil {
+		state.Reset()
+		return "", err
+	}
+	state.Objects = objects
+
+	state.CurrentStep = "confirm_creation"
+	confirm := state.confirmation(
+		fmt.Sprintf("%s\nCreate project '%s' from template '%s' with owner '%s'?", templates.Preview(nobl9.WithOwnership(objects, state.Requester)), state.ProjectName, state.TemplateName, state.Requester),
+	)
+	state.PendingPrompt = confirm
+	return confirm.Format(), nil
+}
+
+// confirmCreation moves the wizard to the final confirmation step
+func (b *Bot) confirmCreation(state *ConversationState) string {
+	state.CurrentStep = "confirm_creation"
+
+	message := fmt.Sprintf("Create project '%s' with description '%s' and owner '%s'?", state.ProjectName, state.ProjectDescription, state.Requester)
+	if len(state.ProjectLabels) > 0 {
+		message = fmt.Sprintf("Create project '%s' with description '%s', labels %s and owner '%s'?", state.ProjectName, state.ProjectDescription, formatLabels(state.ProjectLabels), state.Requester)
+	}
+	confirm := state.confirmation(message)
+	state.PendingPrompt = confirm
+	return confirm.Format()
+}
+
+// confirmRole moves role assignment to its final confirmation step
+func (b *Bot) confirmRole(state *ConversationState) string {
+	state.CurrentStep = "confirm_role"
+	confirm := state.confirmation(
+		fmt.Sprintf("Assign role '%s' to user '%s' in project '%s'?", state.RoleType, state.RoleUser, state.ProjectName),
+	)
+	state.PendingPrompt = confirm
+	return confirm.Format()
+}
+
+// matchRole finds the configured role name matching role, ignoring case
+func matchRole(role string) (string, bool) {
+	for _, name := range nobl9.RoleNames() {
+		if strings.EqualFold(name, role) {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// reviewManifest decodes a user-supplied manifest, checks it against the same policies
+// as the guided flows and asks for confirmation with a per-object preview
+func (b *Bot) reviewManifest(ctx context.Context, state *ConversationState, data []byte) (string, error) {
+	logger := b.logger.WithContext(ctx)
+
+	objects, err := sdk.DecodeObjects(data)
+	if err != nil {
+		state.Reset()
+		return "", errors.NewValidationError("failed to decode manifest", err)
+	}
+
+	if err := b.checkObjects(objects, b.labelPolicies); err != nil {
+		logger.Warn("Manifest rejected",
+			logging.F("objects", len(objects)),
+			logging.F("error", err),
+		)
+		state.Reset()
+		return "", err
+	}
+
+	if err := b.checkProjectOwners(ctx, state, objects); err != nil {
+		logger.Warn("Manifest rejected",
+			logging.F("objects", len(objects)),
+			logging.F("error", err),
+		)
+		state.Reset()
+		return "", err
+	}
+
+	state.Objects = objects
+	state.CurrentStep = "confirm_apply"
+	confirm := state.confirmation(
+		fmt.Sprintf("The manifest contains %d object(s):\n%s\nApply these objects?", len(objects), nobl9.DescribeObjects(objects)),
+	)
+	state.PendingPrompt = confirm
+	return confirm.Format(), nil
+}
+
+// readManifestFile reads a manifest named in chat. Only files inside the
+// configured apply_files_dir may be read, so chat users cannot read other files
+// on the bot's host.
+func (b *Bot) readManifestFile(name string) ([]byte, error) {
+	if b.applyFilesDir == "" {
+		return nil, errors.NewPermissionError("applying manifest files is turned off, paste the manifest instead", nil)
+	}
+	name = filepath.Clean(name)
+	if !filepath.IsLocal(name) {
+		return nil, errors.NewValidationError(fmt.Sprintf("manifest '%s' must be a file name relative to the manifests directory", name), nil)
+	}
+
+	// Symlinks must not lead out of the directory either
+	dir, err := filepath.EvalSymlinks(b.applyFilesDir)
+	if err != nil {
+		return nil, errors.NewInternalError("failed to open the manifests directory", err)
+	}
+	path, err := filepath.EvalSymlinks(filepath.Join(dir, name))
+	if err != nil {
+		return nil, errors.NewNotFoundError(fmt.Sprintf("manifest '%s' not found", name), nil)
+	}
+	if rel, err := filepath.Rel(dir, path); err != nil || !filepath.IsLocal(rel) {
+		return nil, errors.NewValidationError(fmt.Sprintf("manifest '%s' must be a file name relative to the manifests directory", name), nil)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.NewValidationError(fmt.Sprintf("failed to read manifest '%s'", name), err)
+	}
+	return data, nil
+}
+
+// checkObjects checks objects against the apply kind allowlist, naming policy and labelPolicies
+func (b *Bot) checkObjects(objects []manifest.Object, labelPolicies []config.LabelPolicy) error {
+	policy := validation.ManifestPolicy{
+		AllowedKinds:  b.allowedKinds,
+		ProjectNames:  b.nameValidator,
+		LabelPolicies: labelPolicies,
+	}
+	return policy.Check(objects)
+}
+
+// checkProjectOwners refuses Project objects that would overwrite an existing
+// project the caller does not own, like the guided flow refuses taken names
+func (b *Bot) checkProjectOwners(ctx context.Context, state *ConversationState, objects []manifest.Object) error {
+	for _, obj := range objects {
+		proj, ok := obj.(project.Project)
+		if !ok {
+			continue
+		}
+		name := proj.Metadata.Name
+		existing, err := b.client(ctx).GetProject(ctx, name)
+		if err != nil {
+			return err
+		}
+		if existing == nil {
+			continue
+		}
+		owners, err := b.client(ctx).GetProjectOwners(ctx, name)
+		if err != nil {
+			return err
+		}
+		owned := false
+		for _, owner := range owners {
+			if state.Caller.Email != "" && strings.EqualFold(owner, state.Caller.Email) {
+				owned = true
+				break
+			}
+		}
+		if !owned {
+			return errors.NewPermissionError(fmt.Sprintf("project '%s' already exists and only its owners can change it with apply", name), nil)
+		}
+	}
+	return nil
+}
+
+// plan renders the objects a flow would apply and validates them with a server-side
+// dry run. Nothing is persisted.
+func (b *Bot) plan(ctx context.Context, state *ConversationState, objects []manifest.Object) (string, error) {
+	logger := b.logger.WithContext(ctx)
+	defer state.Reset()
+
+	rendered, err := nobl9.EncodeObjects(objects, manifest.ObjectFormatYAML)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("📝 Plan: %d object(s) would be applied. Nothing was changed.\n\n", len(objects)))
+	sb.WriteString("```yaml\n")
+	sb.WriteString(rendered)
+	sb.WriteString("```\n\n")
+
+	if err := b.client(ctx).DryRunApply(ctx, objects); err != nil {
+		logger.Warn("Dry run failed",
+			logging.F("objects", len(objects)),
+			logging.F("error", err),
+		)
+		sb.WriteString(fmt.Sprintf("❌ Server-side validation failed: %v", err))
+		return sb.String(), nil
+	}
+
+	logger.Info("Dry run succeeded",
+		logging.F("objects", len(objects)),
+	)
+	sb.WriteString("✅ Server-side validation passed.")
+	return sb.String(), nil
+}
+
+// formatLabels renders labels as a sorted key=value list
+func formatLabels(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, len(keys))
+	for i, key := range keys {
+		pairs[i] = fmt.Sprintf("%s=%s", key, labels[key])
+	}
+	return strings.Join(pairs, ", ")
+}
+
+// commandHandler returns the handler that ends the middleware chain for commands
+// sent in the conversation with state
+func (b *Bot) commandHandler(state *ConversationState) command.HandlerFunc {
+	return func(req *command.Request) (string, error) {
+		response, err := b.handleCommand(req.Context, state, req.Command, req.Args)
+		if state.PendingPrompt != nil {
+			state.Command = req.Command.Name
+		}
+		return response, err
+	}
+}
+
+// wizardCommand returns the command the wizard in progress runs its steps as.
+// It carries the started command's current permissions but no spec, a step's
+// response is not the command's arguments.
+func (b *Bot) wizardCommand(state *ConversationState) *command.Command {
+	if cmd, ok := b.commands.Get(state.Command); ok {
+		return &command.Command{Name: cmd.Name, Permissions: cmd.Permissions}
+	}
+	return &command.Command{Name: state.Command}
+}
+
+// handleCommand runs a command, starting the interactive flow of those that have one
 func (b *Bot) handleCommand(ctx context.Context, state *ConversationState, cmd *command.Command, args []string) (string, error) {
 	logger := b.logger.WithContext(ctx)
 
 	// Special handling for commands that need interactive flows
 	switch cmd.Name {
 	case "create-project":
-		if len(args) == 0 {
+		parsed, err := cmd.ParseArgs(args)
+		if err != nil {
+			return "", err
+		}
+		templateName := parsed.String("template")
+		var tmpl *templates.Template
+		if templateName != "" {
+			if tmpl, err = b.templates.Get(templateName); err != nil {
+				return "", err
+			}
+		}
+		labels := parsed.Map("label")
+		if err := b.checkLabelValues(labels); err != nil {
+			return "", err
+		}
+		// The requester becomes the project owner
+		if _, err := verifiedEmail(state, "creating a project"); err != nil {
+			return "", err
+		}
+
+		state.Reset()
+		state.DryRun = parsed.Bool("dry-run")
+		state.TemplateName = templateName
+		state.Template = tmpl
+		state.TemplateParams = make(map[string]string)
+		state.LabelPolicies = b.labelPolicies
+		state.ProjectDescription = parsed.String("description")
+		state.ProjectLabels = labels
+		if !parsed.Has("name") {
 			// Start interactive flow
-			logger.Info("Starting interactive project creation")
-			state.Reset()
+			logger.Info("Starting interactive project creation", logging.F("template", templateName))
 			state.CurrentStep = "project_name"
 			prompt := interactive.NewPrompt(
 				"Please enter a project name:",
@@ -603,26 +1553,35 @@ func (b *Bot) handleCommand(ctx context.Context, state *ConversationState, cmd *
 			)
 			state.PendingPrompt = prompt
 			return prompt.Format(), nil
-		} else {
-			// Project name provided, go to description step
-			logger.Info("Starting project creation with name", logging.F("name", args[0]))
-			state.Reset()
-			state.ProjectName = args[0]
-			state.CurrentStep = "project_description"
-			prompt := interactive.NewPrompt(
-				fmt.Sprintf("Please provide a description for project '%s':", args[0]),
-				nil,
-				"",
-			)
-			state.PendingPrompt = prompt
-			return prompt.Format(), nil
 		}
+		// Project name provided, check it before asking for a description
+		logger.Info("Starting project creation with name",
+			logging.F("name", parsed.String("name")),
+			logging.F("template", templateName),
+		)
+		return b.submitProjectName(ctx, state, parsed.String("name"))
 
 	case "assign-role":
-		if len(args) == 0 {
+		parsed, err := cmd.ParseArgs(args)
+		if err != nil {
+			return "", err
+		}
+		role := parsed.String("role")
+		if role != "" {
+			known, ok := matchRole(role)
+			if !ok {
+				return "", errors.NewValidationError(fmt.Sprintf("unknown role '%s', use %s", role, strings.Join(nobl9.RoleNames(), ", ")), nil)
+			}
+			role = known
+		}
+
+		state.Reset()
+		state.DryRun = parsed.Bool("dry-run")
+		state.RoleType = role
+		switch {
+		case !parsed.Has("project"):
 			// Start interactive flow from project selection
 			logger.Info("Starting interactive role assignment")
-			state.Reset()
 			state.CurrentStep = "project_selection"
 			prompt := interactive.NewPrompt(
 				"Please enter the project name:",
@@ -631,56 +1590,133 @@ func (b *Bot) handleCommand(ctx context.Context, state *ConversationState, cmd *
 			)
 			state.PendingPrompt = prompt
 			return prompt.Format(), nil
-		} else if len(args) == 1 {
+		case !parsed.Has("user"):
 			// Project provided, ask for user
-			logger.Info("Starting role assignment for project", logging.F("project", args[0]))
-			state.Reset()
-			state.ProjectName = args[0]
+			logger.Info("Starting role assignment for project", logging.F("project", parsed.String("project")))
+			state.ProjectName = parsed.String("project")
 			state.CurrentStep = "role_user"
 			prompt := interactive.NewPrompt(
-				fmt.Sprintf("Please enter the user's email for project '%s':", args[0]),
+				fmt.Sprintf("Please enter the user's email for project '%s':", state.ProjectName),
 				nil,
 				"",
 			)
 			state.PendingPrompt = prompt
 			return prompt.Format(), nil
-		} else {
-			// Both project and user provided, ask for role
-			logger.Info("Starting role selection", 
-				logging.F("project", args[0]),
-				logging.F("user", args[1]),
-			)
-			state.Reset()
-			state.ProjectName = args[0]
-			state.RoleUser = args[1]
-			state.CurrentStep = "role_type"
-			prompt := interactive.NewPrompt(
-				fmt.Sprintf("Please select a role for user '%s' in project '%s':", args[1], args[0]),
-				[]string{"admin", "member", "viewer"},
-				"member",
-			)
-			state.PendingPrompt = prompt
-			return prompt.Format(), nil
 		}
 
+		state.ProjectName = parsed.String("project")
+		state.RoleUser = parsed.String("user")
+		if role != "" {
+			return b.confirmRole(state), nil
+		}
+		// Both project and user provided, ask for role
+		logger.Info("Starting role selection",
+			logging.F("project", state.ProjectName),
+			logging.F("user", state.RoleUser),
+		)
+		state.CurrentStep = "role_type"
+		prompt := interactive.NewPrompt(
+			fmt.Sprintf("Please select a role for user '%s' in project '%s':", state.RoleUser, state.ProjectName),
+			nobl9.RoleNames(),
+			nobl9.DefaultRoleName(),
+		)
+		state.PendingPrompt = prompt
+		return prompt.Format(), nil
+
+	case "help":
+		parsed, err := cmd.ParseArgs(args)
+		if err != nil {
+			return "", err
+		}
+		// Only show commands the caller is allowed to run
+		if name := parsed.String("command"); name != "" {
+			target, ok := b.commands.Get(name)
+			if !ok || !b.authorizer.Allowed(state.Caller.Identity(), target.Permissions) {
+				return "", errors.NewValidationError(fmt.Sprintf("unknown command: %s", name), nil)
+			}
+			return command.FormatCommandHelp(target), nil
+		}
+		return b.getHelpMessage(state), nil
+
 	case "list-projects":
-		return command.ListProjectsCommand(b, args)
+		return command.ListProjectsCommand(&conversation{Bot: b, ctx: ctx, state: state}, args)
+
+	case "apply":
+		parsed, err := cmd.ParseArgs(args)
+		if err != nil {
+			return "", err
+		}
+		state.Reset()
+		state.DryRun = parsed.Bool("dry-run")
+		if file := parsed.String("file"); file != "" {
+			logger.Info("Applying manifest file", logging.F("file", file))
+			data, err := b.readManifestFile(file)
+			if err != nil {
+				state.Reset()
+				return "", err
+			}
+			return b.reviewManifest(ctx, state, data)
+		}
+
+		// Collect a pasted manifest line by line
+		logger.Info("Starting manifest paste")
+		state.CurrentStep = "manifest_input"
+		prompt := interactive.NewPrompt(
+			"Paste the manifest, then send a line containing only EOF:",
+			nil,
+			"",
+		)
+		state.PendingPrompt = prompt
+		return prompt.Format(), nil
+
+	case "approvals":
+		approver, err := verifiedEmail(state, "listing approvals")
+		if err != nil {
+			return "", err
+		}
+		return b.listApprovals(approver), nil
+
+	case "approve", "deny":
+		parsed, err := cmd.ParseArgs(args)
+		if err != nil {
+			return "", err
+		}
+		approver, err := verifiedEmail(state, "deciding approvals")
+		if err != nil {
+			return "", err
+		}
+		return b.decideApproval(ctx, approver, parsed.String("id"), cmd.Name == "approve")
+
+	case "plan":
+		parsed, err := cmd.ParseArgs(args)
+		if err != nil {
+			return "", err
+		}
+		if parsed.Has("mode") {
+			state.PlanMode = parsed.String("mode") == "on"
+			logger.Info("Plan mode changed", logging.F("plan_mode", state.PlanMode))
+		}
+		if state.PlanMode {
+			return "📝 Plan mode is on. Changes are previewed and dry-run validated but never applied. Type 'plan off' to apply changes again.", nil
+		}
+		return "Plan mode is off. Confirmed changes are applied to Nobl9.", nil
 
 	default:
+		if p, ok := b.plugins[cmd.Name]; ok {
+			return b.runPlugin(ctx, state, p, cmd, args)
+		}
 		// For other commands, call the handler directly
 		if cmd.Handler != nil {
-			return cmd.Handler(b, args)
+			return cmd.Handler(&conversation{Bot: b, ctx: ctx, state: state}, args)
 		}
 		return fmt.Sprintf("Command '%s' is not implemented yet", cmd.Name), nil
 	}
 }
 
 // StartConversation starts a new conversation
-func (b *Bot) StartConversation(projectName string) error {
-	ctx := context.Background()
-	
+func (b *Bot) StartConversation(ctx context.Context, projectName string) error {
 	// Check if project exists
-	isValid, _, err := b.nobl9Client.ValidateProjectName(ctx, projectName)
+	isValid, _, err := b.client(ctx).ValidateProjectName(ctx, projectName)
 	if err != nil {
 		return fmt.Errorf("failed to validate project name: %w", err)
 	}
@@ -736,44 +1772,54 @@ func (b *Bot) EndConversation(threadID string) {
 	delete(b.state, threadID)
 }
 
-// CreateProject creates a new project
-func (b *Bot) CreateProject(name, description string) (*nobl9.Project, error) {
-	ctx := context.Background()
-	return b.nobl9Client.CreateProject(ctx, name, description)
+// CreateProject creates a new project owned by owner
+func (b *Bot) CreateProject(ctx context.Context, name, description string, labels map[string]string, owner string) (*nobl9.Project, error) {
+	var created *nobl9.Project
+	objects := nobl9.ProjectObjects(name, description, labels, owner)
+	_, err := b.mutate(ctx, "create-project", fmt.Sprintf("Create project %s", name), objects, func() (string, error) {
+		var err error
+		created, err = b.client(ctx).CreateProject(ctx, name, description, labels, owner)
+		if created != nil {
+			return created.Branch, err
+		}
+		return "", err
+	})
+	return created, err
 }
 
 // ValidateUser checks if a user exists
-func (b *Bot) ValidateUser(email string) (bool, error) {
-	ctx := context.Background()
-	return b.nobl9Client.ValidateUser(ctx, email)
+func (b *Bot) ValidateUser(ctx context.Context, email string) (bool, error) {
+	return b.client(ctx).ValidateUser(ctx, email)
 }
 
-// AssignRoles assigns roles to users in a project
-func (b *Bot) AssignRoles(project string, users []string) error {
-	ctx := context.Background()
-	
-	// Get the current conversation state to determine the role type
-	state, exists := b.GetConversationState("cli")
-	var roleType string
-	if exists && state.RoleType != "" {
-		roleType = state.RoleType
-	} else {
-		roleType = "member" // Default role
-	}
-	
-	// Convert users slice to map with the selected role
+// AssignRoles assigns role to users in a project and returns the GitOps branch, if any
+func (b *Bot) AssignRoles(ctx context.Context, project string, users []string, role string) (string, error) {
 	assignments := make(map[string][]string)
 	for _, user := range users {
-		assignments[user] = []string{roleType}
+		assignments[user] = []string{role}
 	}
 	
-	return b.nobl9Client.AssignRoles(ctx, project, assignments)
+	objects, err := b.client(ctx).RoleBindingObjects(ctx, project, assignments)
+	if err != nil {
+		return "", err
+	}
+	summary := fmt.Sprintf("Assign roles in project %s", project)
+	return b.mutate(ctx, "assign-role", summary, objects, func() (string, error) {
+		return b.client(ctx).ApplyObjects(ctx, summary, objects)
+	})
+}
+
+// SearchAudit returns the audit records matching query, newest first
+func (b *Bot) SearchAudit(query audit.Query) ([]audit.Record, error) {
+	if b.audit == nil {
+		return nil, errors.NewValidationError("the audit log is not enabled", nil)
+	}
+	return audit.Search(b.audit.Path(), query)
 }
 
 // ListProjects retrieves all projects in the organization
-func (b *Bot) ListProjects() ([]*command.Project, error) {
-	ctx := context.Background()
-	projects, err := b.nobl9Client.ListProjects(ctx)
+func (b *Bot) ListProjects(ctx context.Context) ([]*command.Project, error) {
+	projects, err := b.client(ctx).ListProjects(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -792,10 +1838,27 @@ func (b *Bot) ListProjects() ([]*command.Project, error) {
 	return result, nil
 }
 
-// ValidateProjectName checks if a project name is valid and available
-func (b *Bot) ValidateProjectName(ctx context.Context, conversationID, name string) (bool, error) {
-	isValid, _, err := b.nobl9Client.ValidateProjectName(ctx, name)
-	return isValid, err
+// ExportProject renders a project, its role bindings and optionally its services and
+// SLOs as a YAML or JSON manifest
+func (b *Bot) ExportProject(ctx context.Context, name string, includeSLOs bool, format string) (string, error) {
+	objects, err := b.client(ctx).ExportProject(ctx, name, includeSLOs)
+	if err != nil {
+		return "", err
+	}
+
+	objectFormat := manifest.ObjectFormatYAML
+	if format == "json" {
+		objectFormat = manifest.ObjectFormatJSON
+	}
+	return nobl9.EncodeObjects(objects, objectFormat)
+}
+
+// ValidateProjectName checks if a project name is available, reporting the current
+// owners and alternative names following the naming policy when it is taken
+func (b *Bot) ValidateProjectName(ctx context.Context, name string) (*nobl9.NameAvailability, error) {
+	return b.client(ctx).CheckProjectName(ctx, name, func(candidate string) bool {
+		return b.nameValidator.Validate(candidate) == nil
+	})
 }
 
 // UpdateConversationState updates the state of a conversation
@@ -818,7 +1881,7 @@ func (b *Bot) UpdateConversationState(ctx context.Context, conversationID string
 
 // GetUserRoles retrieves a user's roles in a project
 func (b *Bot) GetUserRoles(ctx context.Context, conversationID, userEmail string) ([]string, error) {
-	return b.nobl9Client.GetUserRoles(ctx, conversationID, userEmail)
+	return b.client(ctx).GetUserRoles(ctx, conversationID, userEmail)
 }
 
 // ValidateRoles checks if the roles are valid and not redundant
@@ -836,70 +1899,67 @@ func (b *Bot) ValidateRoles(ctx context.Context, conversationID, userEmail strin
 		return false, nil, fmt.Errorf("no project associated with conversation")
 	}
 	
-	return b.nobl9Client.ValidateRoles(ctx, state.ProjectName, userEmail, newRoles)
+	return b.client(ctx).ValidateRoles(ctx, state.ProjectName, userEmail, newRoles)
+}
+
+// planning reports whether the current flow should be planned instead of applied
+func (s *ConversationState) planning() bool {
+	return s.DryRun || s.PlanMode
 }
 
-// Ensure Bot implements command.BotCommander
-var _ command.BotCommander = (*Bot)(nil)
+// confirmation builds the final confirmation for a flow, flagging plan-only flows
+func (s *ConversationState) confirmation(message string) *interactive.Confirmation {
+	if s.planning() {
+		message = fmt.Sprintf("%s (plan only, nothing will be applied)", message)
+	}
+	return interactive.NewConfirmation(message, true)
+}
 
 func (s *ConversationState) Reset() {
 	s.ProjectName = ""
 	s.ProjectDescription = ""
+	s.ProjectLabels = nil
+	s.LabelPolicies = nil
+	s.LabelIndex = 0
+	s.TemplateName = ""
+	s.Template = nil
+	s.TemplateParams = nil
+	s.ParamIndex = 0
+	s.Objects = nil
+	s.ManifestLines = nil
+	s.DryRun = false
 	s.Owner = ""
 	s.Step = ""
 	s.PendingPrompt = nil
 	s.CurrentStep = ""
+	s.Command = ""
 	s.RoleUser = ""
 	s.RoleType = ""
 }
 
 // New creates a new bot instance
 func New(client *nobl9.Client) (*Bot, error) {
-	commandRegistry := command.NewCommandRegistry()
-	
-	// Register all commands
-	commandRegistry.Register(&command.Command{
-		Name:        "help",
-		Aliases:     []string{"h", "?"},
-		Description: "Show available commands or help for a specific command",
-		Usage:       "help [command]",
-		Handler:     command.HelpCommand,
-	})
-	
-	commandRegistry.Register(&command.Command{
-		Name:        "create-project",
-		Aliases:     []string{"create", "new"},
-		Description: "Create a new Nobl9 project",
-		Usage:       "create-project [name]",
-		Handler:     command.CreateProjectCommand,
-	})
-	
-	commandRegistry.Register(&command.Command{
-		Name:        "assign-role",
-		Aliases:     []string{},
-		Description: "Assign a role to a user in a project",
-		Usage:       "assign-role [<project> <user>]",
-		Handler:     command.AssignRoleCommand,
-	})
-	
-	commandRegistry.Register(&command.Command{
-		Name:        "list-projects",
-		Aliases:     []string{"list", "ls"},
-		Description: "List available projects",
-		Usage:       "list-projects",
-		Handler:     command.ListProjectsCommand,
-	})
-	
-	logger, err := logging.NewLogger(logging.LevelWarn)
+	commandRegistry := defaultCommands()
+
+	logger, logLevels, err := newLogger(config.Default().Logging, "")
 	if err != nil {
 		return nil, fmt.Errorf("failed to create logger: %w", err)
 	}
+
+	nameValidator, err := validation.NewProjectNameValidator(config.NamingPolicy{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create name validator: %w", err)
+	}
 	
 	return &Bot{
-		nobl9Client: client,
-		logger:      logger,
-		commands:    commandRegistry,
-		state:       make(map[string]*ConversationState),
+		nobl9Client:   client,
+		logger:        logger,
+		logLevels:     logLevels,
+		commands:      commandRegistry,
+		state:         make(map[string]*ConversationState),
+		templates:     templates.NewRegistry(),
+		nameValidator: nameValidator,
+		approvals:     approval.NewQueue(),
 	}, nil
 }
 
@@ -909,7 +1969,7 @@ func (b *Bot) Start(ctx context.Context) error {
 	fmt.Println()
 	
 	// Initialize conversation state for CLI
-	response, err := b.HandleMessage("cli", "start")
+	response, err := b.HandleMessage(b.cliCaller(), "cli", "start")
 	if err == nil && response != "" {
 		// Don't print the welcome message twice
 	}
@@ -922,7 +1982,7 @@ func (b *Bot) Start(ctx context.Context) error {
 			fmt.Println("\n👋 Thanks for using Nobl9 Project Bot! Goodbye!")
 			return nil
 		default:
-			fmt.Print("> ")
+			fmt.Print(b.prompt())
 			
 			if !scanner.Scan() {
 				if scanner.Err() != nil {
@@ -931,6 +1991,17 @@ func (b *Bot) Start(ctx context.Context) error {
 				continue
 			}
 			
+			// Pasted manifests are passed through untrimmed, blank lines included
+			if b.collectingManifest("cli") {
+				response, err := b.HandleMessage(b.cliCaller(), "cli", scanner.Text())
+				if err != nil {
+					b.printError(err)
+				} else if response != "" {
+					fmt.Printf("%s\n\n", response)
+				}
+				continue
+			}
+
 			input := strings.TrimSpace(scanner.Text())
 			if input == "" {
 				continue
@@ -941,9 +2012,9 @@ func (b *Bot) Start(ctx context.Context) error {
 				return nil
 			}
 			
-			response, err := b.HandleMessage("cli", input)
+			response, err := b.HandleMessage(b.cliCaller(), "cli", input)
 			if err != nil {
-				fmt.Printf("❌ Error: %v\n\n", err)
+				b.printError(err)
 			} else {
 				fmt.Printf("%s\n\n", response)
 			}
@@ -951,6 +2022,45 @@ func (b *Bot) Start(ctx context.Context) error {
 	}
 }
 
+// cliCaller returns the OS user running the CLI, with the email the config
+// gives them
+func (b *Bot) cliCaller() identity.Caller {
+	caller := identity.CLI()
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.cfg != nil {
+		caller.Email = b.cfg.CLIEmail
+	}
+	return caller
+}
+
+// prompt returns the CLI input prompt
+func (b *Bot) prompt() string {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.frontend.Prompt != "" {
+		return b.frontend.Prompt
+	}
+	return "> "
+}
+
+// printError prints an error for the CLI user, with the error help link if configured
+func (b *Bot) printError(err error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	fmt.Printf("❌ Error: %v\n", err)
+	if b.helpResources.ErrorHelp != "" {
+		fmt.Printf("Need help? See %s\n", b.helpResources.ErrorHelp)
+	}
+	fmt.Println()
+}
+
+// collectingManifest reports whether a conversation is in the middle of a manifest paste
+func (b *Bot) collectingManifest(conversationID string) bool {
+	state, exists := b.GetConversationState(conversationID)
+	return exists && state.CurrentStep == "manifest_input"
+}
+
 // StartRoleAssignment initiates an interactive role assignment flow
 func (b *Bot) StartRoleAssignment() error {
 	// This will be handled by the conversation state in HandleMessage
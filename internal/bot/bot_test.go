@@ -1,898 +1,251 @@
 package bot_test
 
 import (
-	"context"
-	"errors"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
 	"testing"
-	"time"
 
 	"github.com/stretchr/testify/assert"
-	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
-	"github.com/dfaile/backstage-nobl9/internal/command"
+
+	"github.com/dfaile/backstage-nobl9/internal/bot"
+	"github.com/dfaile/backstage-nobl9/internal/config"
 	"github.com/dfaile/backstage-nobl9/internal/errors"
-	"github.com/dfaile/backstage-nobl9/internal/interactive"
-	"github.com/dfaile/backstage-nobl9/internal/logging"
+	"github.com/dfaile/backstage-nobl9/internal/identity"
 	"github.com/dfaile/backstage-nobl9/internal/nobl9"
 )
 
-// mockNobl9Client is a mock implementation of the Nobl9 client
-type mockNobl9Client struct {
-	mock.Mock
-	validateProjectNameAttempts int
-	createProjectAttempts        int
-	validateUserAttempts         int
-	assignRolesAttempts          int
-}
-
-func (m *mockNobl9Client) GetProject(ctx context.Context, name string) (*nobl9.Project, error) {
-	args := m.Called(ctx, name)
-	if args.Get(0) == nil {
-		return nil, args.Error(1)
-	}
-	return args.Get(0).(*nobl9.Project), args.Error(1)
-}
-
-func (m *mockNobl9Client) CreateProject(ctx context.Context, name, description string) (*nobl9.Project, error) {
-	args := m.Called(ctx, name, description)
-	if args.Get(0) == nil {
-		return nil, args.Error(1)
-	}
-	return args.Get(0).(*nobl9.Project), args.Error(1)
-}
-
-func (m *mockNobl9Client) ValidateProjectName(ctx context.Context, name string) (bool, string, error) {
-	args := m.Called(ctx, name)
-	return args.Bool(0), args.String(1), args.Error(2)
-}
-
-func (m *mockNobl9Client) ValidateUser(ctx context.Context, email string) (bool, error) {
-	args := m.Called(ctx, email)
-	return args.Bool(0), args.Error(1)
-}
-
-func (m *mockNobl9Client) GetUserRoles(ctx context.Context, projectName, userEmail string) ([]string, error) {
-	args := m.Called(ctx, projectName, userEmail)
-	return args.Get(0).([]string), args.Error(1)
-}
-
-func (m *mockNobl9Client) ValidateRoles(ctx context.Context, projectName, userEmail string, newRoles []string) (bool, []string, error) {
-	args := m.Called(ctx, projectName, userEmail, newRoles)
-	return args.Bool(0), args.Get(1).([]string), args.Error(2)
-}
-
-func (m *mockNobl9Client) AssignRoles(ctx context.Context, projectName string, assignments map[string][]string) error {
-	args := m.Called(ctx, projectName, assignments)
-	return args.Error(0)
-}
-
-type mockLogger struct {
-	mock.Mock
-}
-
-func (m *mockLogger) Debug(msg string, fields ...logging.Field) {
-	m.Called(msg, fields)
-}
-
-func (m *mockLogger) Info(msg string, fields ...logging.Field) {
-	m.Called(msg, fields)
-}
-
-func (m *mockLogger) Warn(msg string, fields ...logging.Field) {
-	m.Called(msg, fields)
-}
-
-func (m *mockLogger) Error(msg string, fields ...logging.Field) {
-	m.Called(msg, fields)
-}
-
-func (m *mockLogger) WithFields(fields ...logging.Field) logging.Logger {
-	args := m.Called(fields)
-	return args.Get(0).(logging.Logger)
-}
-
-func (m *mockLogger) WithContext(ctx context.Context) logging.Logger {
-	args := m.Called(ctx)
-	return args.Get(0).(logging.Logger)
-}
-
-func TestNewBot(t *testing.T) {
-	mockClient := &nobl9.MockClient{}
-	mockLogger := &mockLogger{}
-
-	bot := &Bot{
-		nobl9Client: mockClient,
-		logger:      mockLogger,
-		state:       make(map[string]*ConversationState),
-	}
-
-	assert.NotNil(t, bot)
-	assert.Equal(t, mockClient, bot.nobl9Client)
-	assert.Equal(t, mockLogger, bot.logger)
-	assert.NotNil(t, bot.state)
-}
-
-func TestHandleMessage(t *testing.T) {
-	client := &mockNobl9Client{}
-	b := NewBot(client, nil)
-
-	// Test unknown command
-	response, err := b.HandleMessage("thread1", "unknown-command")
-	if err != nil {
-		t.Errorf("HandleMessage failed: %v", err)
-	}
-	if response == "" {
-		t.Error("Expected non-empty response for unknown command")
-	}
-
-	// Test help command
-	response, err = b.HandleMessage("thread1", "help")
-	if err != nil {
-		t.Errorf("HandleMessage failed: %v", err)
-	}
-	if response == "" {
-		t.Error("Expected non-empty help response")
-	}
-
-	// Test create-project command
-	response, err = b.HandleMessage("thread1", "create-project test-project")
-	if err != nil {
-		t.Errorf("HandleMessage failed: %v", err)
-	}
-	if response == "" {
-		t.Error("Expected non-empty response for project creation")
-	}
-
-	// Test conversation flow
-	response, err = b.HandleMessage("thread1", "This is a project description")
-	if err != nil {
-		t.Errorf("HandleMessage failed: %v", err)
-	}
-	if response == "" {
-		t.Error("Expected non-empty response for project description")
-	}
-}
-
-func TestHandleConversationMessage(t *testing.T) {
-	client := &mockNobl9Client{}
-	b := NewBot(client, nil)
-
-	// Start a conversation
-	_, err := b.StartConversation("test-project")
-	if err != nil {
-		t.Fatalf("Failed to start conversation: %v", err)
-	}
-
-	// Test invalid state
-	_, err = b.handleConversationMessage("thread1", &ConversationState{
-		ProjectName: "test-project",
-		Step:        "invalid",
-	}, "description")
-	if !errors.IsInternalError(err) {
-		t.Error("Expected internal error for invalid state")
-	}
-
-	// Test valid conversation flow
-	response, err := b.handleConversationMessage("thread1", &ConversationState{
-		ProjectName: "test-project",
-		Step:        "description",
-	}, "Test project description")
-	if err != nil {
-		t.Errorf("handleConversationMessage failed: %v", err)
-	}
-	if response == "" {
-		t.Error("Expected non-empty response for project creation")
-	}
-}
-
-func TestConversationLifecycle(t *testing.T) {
-	mockClient := &nobl9.MockClient{}
-	mockLogger := &mockLogger{}
-	mockLogger.On("Info", "Started conversation", mock.Anything).Return()
-	mockLogger.On("Info", "Ended conversation", mock.Anything).Return()
-
-	bot := &Bot{
-		nobl9Client: mockClient,
-		logger:      mockLogger,
-		state:       make(map[string]*ConversationState),
-	}
-
-	// Test starting conversation
-	err := bot.StartConversation("test-conv")
-	assert.NoError(t, err)
-	state, err := bot.GetConversationState("test-conv")
-	assert.NoError(t, err)
-	assert.NotNil(t, state)
-	assert.WithinDuration(t, time.Now(), state.LastUpdated, time.Second)
-
-	// Test ending conversation
-	err = bot.EndConversation("test-conv")
-	assert.NoError(t, err)
-	_, err = bot.GetConversationState("test-conv")
-	assert.Error(t, err)
-	assert.True(t, errors.IsNotFoundError(err))
-
-	mockLogger.AssertExpectations(t)
-}
-
-func TestDuplicateConversation(t *testing.T) {
-	mockClient := new(mockNobl9Client)
-	bot := NewBot(mockClient, nil)
-	ctx := context.Background()
-	conversationID := "test-conversation"
-
-	// Start first conversation
-	err := bot.StartConversation(ctx, conversationID)
-	assert.NoError(t, err)
-
-	// Try to start duplicate conversation
-	err = bot.StartConversation(ctx, conversationID)
-	assert.Error(t, err)
-	assert.True(t, errors.IsConflictError(err))
-}
-
-func TestNonexistentConversation(t *testing.T) {
-	mockClient := new(mockNobl9Client)
-	bot := NewBot(mockClient, nil)
-	ctx := context.Background()
-	conversationID := "nonexistent"
-
-	// Test getting state of nonexistent conversation
-	_, err := bot.GetConversationState(ctx, conversationID)
-	assert.Error(t, err)
-	assert.True(t, errors.IsNotFoundError(err))
-
-	// Test updating state of nonexistent conversation
-	err = bot.UpdateConversationState(ctx, conversationID, func(state *ConversationState) error {
-		return nil
-	})
-	assert.Error(t, err)
-	assert.True(t, errors.IsNotFoundError(err))
-
-	// Test ending nonexistent conversation
-	err = bot.EndConversation(ctx, conversationID)
-	assert.Error(t, err)
-	assert.True(t, errors.IsNotFoundError(err))
+// fakeNobl9 serves the parts of the Nobl9 API the bot uses, keeping applied objects in memory
+type fakeNobl9 struct {
+	mu      sync.Mutex
+	objects []map[string]interface{}
+	applies int
+}
+
+func (f *fakeNobl9) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	result := make([]map[string]interface{}, 0)
+	switch {
+	case r.Method == http.MethodPut && r.URL.Path == "/apply":
+		var objects []map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&objects); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if r.URL.Query().Get("dryRun") == "true" {
+			break
+		}
+		f.applies++
+		f.objects = append(f.objects, objects...)
+	case r.Method == http.MethodGet && r.URL.Path == "/get/project":
+		names := r.URL.Query()["name"]
+		for _, obj := range f.find("Project") {
+			if len(names) == 0 || contains(names, objectName(obj)) {
+				result = append(result, obj)
+			}
+		}
+	case r.Method == http.MethodGet && r.URL.Path == "/get/rolebinding":
+		for _, obj := range f.find("RoleBinding") {
+			if spec, _ := obj["spec"].(map[string]interface{}); spec["projectRef"] == r.Header.Get("Project") {
+				result = append(result, obj)
+			}
+		}
+	default:
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(result)
+}
+
+// find returns the applied objects of kind
+func (f *fakeNobl9) find(kind string) []map[string]interface{} {
+	var found []map[string]interface{}
+	for _, obj := range f.objects {
+		if obj["kind"] == kind {
+			found = append(found, obj)
+		}
+	}
+	return found
+}
+
+// Applied returns the names of the applied objects of kind
+func (f *fakeNobl9) Applied(kind string) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var names []string
+	for _, obj := range f.find(kind) {
+		names = append(names, objectName(obj))
+	}
+	return names
+}
+
+// Applies returns how many manifests were applied
+func (f *fakeNobl9) Applies() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.applies
+}
+
+func objectName(obj map[string]interface{}) string {
+	metadata, _ := obj["metadata"].(map[string]interface{})
+	name, _ := metadata["name"].(string)
+	return name
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// startFakeNobl9 serves a fake Nobl9 API and returns the sloctl config.toml
+// whose default context connects to it
+func startFakeNobl9(t *testing.T) (*fakeNobl9, string) {
+	t.Helper()
+	api := &fakeNobl9{}
+	srv := httptest.NewServer(api)
+	t.Cleanup(srv.Close)
+
+	contexts := fmt.Sprintf("defaultContext = \"test\"\n\n[contexts.test]\norganization = \"acme\"\nurl = %q\ndisableOkta = true\n", srv.URL)
+	path := filepath.Join(t.TempDir(), "config.toml")
+	require.NoError(t, os.WriteFile(path, []byte(contexts), 0600))
+	return api, path
+}
+
+// newTestBot starts a bot configured with cfg that talks to a fake Nobl9 API
+func newTestBot(t *testing.T, cfg *config.Config) (*bot.Bot, *fakeNobl9) {
+	t.Helper()
+	api, path := startFakeNobl9(t)
+	client, err := nobl9.NewClientFromConfig(config.Nobl9Config{ConfigFile: path})
+	require.NoError(t, err)
+
+	b := bot.NewBot(client, nil)
+	t.Cleanup(func() { _ = b.Close() })
+	cfg.Nobl9 = client.Settings()
+	require.NoError(t, b.ApplyConfig(cfg))
+	return b, api
+}
+
+// templatesDir writes the team template, which makes lead a project editor, to a new directory
+func templatesDir(t *testing.T, parameters string) string {
+	t.Helper()
+	data := `name: team
+description: Project with a lead
+parameters:
+` + parameters + `
+manifest: |
+  - apiVersion: n9/v1alpha
+    kind: Project
+    metadata:
+      name: {{ toYaml .Project }}
+    spec:
+      description: {{ toYaml .Description }}
+  - apiVersion: n9/v1alpha
+    kind: RoleBinding
+    metadata:
+      name: {{ toYaml (printf "%s-lead" .Project) }}
+    spec:
+      user: {{ toYaml .Params.lead }}
+      roleRef: project-editor
+      projectRef: {{ toYaml .Project }}
+`
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "team.yaml"), []byte(data), 0600))
+	return dir
 }
 
-func TestProjectCreation(t *testing.T) {
-	mockClient := &nobl9.MockClient{}
-	mockLogger := &mockLogger{}
-	mockLogger.On("Info", "Started conversation", mock.Anything).Return()
-	mockLogger.On("Info", "Handling command", mock.Anything).Return()
-	mockLogger.On("Info", "Starting project creation", mock.Anything).Return()
-	mockLogger.On("Info", "Project name validated", mock.Anything).Return()
-	mockLogger.On("Info", "Project description received", mock.Anything).Return()
-	mockLogger.On("Info", "Project created", mock.Anything).Return()
-
-	bot := &Bot{
-		nobl9Client: mockClient,
-		logger:      mockLogger,
-		state:       make(map[string]*ConversationState),
-	}
-
-	err := bot.StartConversation("test-conv")
-	assert.NoError(t, err)
-
-	// Test project name validation
-	mockClient.On("ValidateProjectName", "test-project").Return(true, nil)
-	response, err := bot.HandleMessage("test-conv", "/create")
-	assert.NoError(t, err)
-	assert.Contains(t, response, "Please enter a project name")
-
-	response, err = bot.HandleMessage("test-conv", "test-project")
-	assert.NoError(t, err)
-	assert.Contains(t, response, "Please provide a description")
+const leadParameter = `  - name: lead
+    description: Email of the team lead
+    required: true`
 
-	// Test project creation
-	mockClient.On("CreateProject", "test-project", "test description").Return("owner@example.com", nil)
-	response, err = bot.HandleMessage("test-conv", "test description")
-	assert.NoError(t, err)
-	assert.Contains(t, response, "Create project")
+var requester = identity.Caller{ID: "jane", Email: "jane@example.com", Source: "test"}
 
-	response, err = bot.HandleMessage("test-conv", "yes")
-	assert.NoError(t, err)
-	assert.Contains(t, response, "Project created successfully")
-
-	mockLogger.AssertExpectations(t)
+// send sends message as caller and fails the test on an error
+func send(t *testing.T, b *bot.Bot, caller identity.Caller, conversationID, message string) string {
+	t.Helper()
+	response, err := b.HandleMessage(caller, conversationID, message)
+	require.NoError(t, err)
+	return response
 }
 
-func TestRoleManagement(t *testing.T) {
-	mockClient := &nobl9.MockClient{}
-	mockLogger := &mockLogger{}
-	mockLogger.On("Info", "Started conversation", mock.Anything).Return()
-	mockLogger.On("Info", "Handling command", mock.Anything).Return()
-	mockLogger.On("Info", "Starting role assignment", mock.Anything).Return()
-	mockLogger.On("Info", "User validated", mock.Anything).Return()
-	mockLogger.On("Info", "Role type received", mock.Anything).Return()
-	mockLogger.On("Info", "Role assigned", mock.Anything).Return()
-
-	bot := &Bot{
-		nobl9Client: mockClient,
-		logger:      mockLogger,
-		state:       make(map[string]*ConversationState),
-	}
-
-	err := bot.StartConversation("test-conv")
-	assert.NoError(t, err)
+func TestCreateProject(t *testing.T) {
+	b, api := newTestBot(t, config.Default())
 
-	// Test user validation
-	mockClient.On("ValidateUser", "user@example.com").Return(true, nil)
-	response, err := bot.HandleMessage("test-conv", "/assign test-project")
-	assert.NoError(t, err)
-	assert.Contains(t, response, "Please enter the user's email")
+	response := send(t, b, requester, "c1", `create-project payments-api --description "Payments team"`)
+	assert.Contains(t, response, "Create project 'payments-api' with description 'Payments team' and owner 'jane@example.com'?")
 
-	response, err = bot.HandleMessage("test-conv", "user@example.com")
-	assert.NoError(t, err)
-	assert.Contains(t, response, "Please select a role type")
+	response = send(t, b, requester, "c1", "yes")
+	assert.Equal(t, "Project created successfully! You are now its project owner.", response)
+	assert.Equal(t, []string{"payments-api"}, api.Applied("Project"))
+	assert.Len(t, api.Applied("RoleBinding"), 1)
 
-	// Test role assignment
-	mockClient.On("AssignRoles", "test-project", "user@example.com", "member").Return(nil)
-	response, err = bot.HandleMessage("test-conv", "member")
-	assert.NoError(t, err)
-	assert.Contains(t, response, "Assign role")
-
-	response, err = bot.HandleMessage("test-conv", "yes")
-	assert.NoError(t, err)
-	assert.Contains(t, response, "Role assigned successfully")
-
-	mockLogger.AssertExpectations(t)
+	// The name is taken now
+	response = send(t, b, requester, "c1", "create-project payments-api")
+	assert.Contains(t, response, "Project name 'payments-api' is not available. It is owned by jane@example.com.")
 }
 
-func TestErrorHandling(t *testing.T) {
-	mockClient := &nobl9.MockClient{}
-	mockLogger := &mockLogger{}
-	mockLogger.On("Info", "Started conversation", mock.Anything).Return()
-	mockLogger.On("Error", "Failed to get conversation state", mock.Anything).Return()
-	mockLogger.On("Warn", "Invalid response", mock.Anything).Return()
-	mockLogger.On("Error", "Failed to validate project name", mock.Anything).Return()
-	mockLogger.On("Warn", "Retrying project name validation", mock.Anything).Return()
-	mockLogger.On("Error", "Failed to create project", mock.Anything).Return()
-	mockLogger.On("Warn", "Retrying project creation", mock.Anything).Return()
-	mockLogger.On("Error", "Failed to validate user", mock.Anything).Return()
-	mockLogger.On("Warn", "Retrying user validation", mock.Anything).Return()
-	mockLogger.On("Error", "Failed to assign role", mock.Anything).Return()
-	mockLogger.On("Warn", "Retrying role assignment", mock.Anything).Return()
-	mockLogger.On("Warn", "Received message while in conversation", mock.Anything).Return()
-
-	bot := &Bot{
-		nobl9Client: mockClient,
-		logger:      mockLogger,
-		state:       make(map[string]*ConversationState),
-	}
+func TestCreateProjectNeedsVerifiedEmail(t *testing.T) {
+	b, api := newTestBot(t, config.Default())
+
+	_, err := b.HandleMessage(identity.Caller{ID: "jane", Source: "test"}, "c1", "create-project payments-api")
+	assert.True(t, errors.IsPermissionError(err))
+	assert.Contains(t, err.Error(), "creating a project needs a verified email from your chat platform")
+	assert.Zero(t, api.Applies())
+}
 
-	// Test conversation not found
-	_, err := bot.HandleMessage("non-existent", "test")
+func TestCreateProjectFromTemplate(t *testing.T) {
+	cfg := config.Default()
+	cfg.TemplatesDir = templatesDir(t, leadParameter)
+	b, api := newTestBot(t, cfg)
+
+	_, err := b.HandleMessage(requester, "c1", "create-project payments-api --template missing")
 	assert.Error(t, err)
-	assert.True(t, errors.IsNotFoundError(err))
 
-	// Test invalid response
-	err = bot.StartConversation("test-conv")
-	assert.NoError(t, err)
-	response, err := bot.HandleMessage("test-conv", "/create")
-	assert.NoError(t, err)
-	assert.Contains(t, response, "Please enter a project name")
+	response := send(t, b, requester, "c1", `create-project payments-api --template team --description "Payments team"`)
+	assert.Contains(t, response, "Please enter a value for template parameter 'lead':")
 
-	response, err = bot.HandleMessage("test-conv", "invalid@email")
-	assert.NoError(t, err)
+	response = send(t, b, requester, "c1", "")
 	assert.Contains(t, response, "Invalid response")
+	assert.Contains(t, response, "template parameter 'lead' is required")
 
-	// Test project name validation error
-	mockClient.On("ValidateProjectName", "test-project").Return(false, errors.NewInternalError("validation error"))
-	response, err = bot.HandleMessage("test-conv", "test-project")
-	assert.NoError(t, err)
-	assert.Contains(t, response, "Project name is not available")
-
-	// Test project creation error
-	mockClient.On("CreateProject", "test-project", "test description").Return("", errors.NewInternalError("creation error"))
-	response, err = bot.HandleMessage("test-conv", "test description")
-	assert.NoError(t, err)
-	assert.Contains(t, response, "Create project")
-
-	response, err = bot.HandleMessage("test-conv", "yes")
-	assert.Error(t, err)
-	assert.True(t, errors.IsInternalError(err))
-
-	// Test user validation error
-	mockClient.On("ValidateUser", "user@example.com").Return(false, errors.NewInternalError("validation error"))
-	response, err = bot.HandleMessage("test-conv", "/assign test-project")
-	assert.NoError(t, err)
-	assert.Contains(t, response, "Please enter the user's email")
-
-	response, err = bot.HandleMessage("test-conv", "user@example.com")
-	assert.NoError(t, err)
-	assert.Contains(t, response, "User not found")
+	response = send(t, b, requester, "c1", "lee@example.com")
+	assert.Contains(t, response, "Create project 'payments-api' from template 'team' with owner 'jane@example.com'?")
 
-	// Test role assignment error
-	mockClient.On("AssignRoles", "test-project", "user@example.com", "member").Return(errors.NewInternalError("assignment error"))
-	response, err = bot.HandleMessage("test-conv", "member")
-	assert.NoError(t, err)
-	assert.Contains(t, response, "Assign role")
-
-	response, err = bot.HandleMessage("test-conv", "yes")
-	assert.Error(t, err)
-	assert.True(t, errors.IsInternalError(err))
-
-	// Test invalid state
-	response, err = bot.HandleMessage("test-conv", "test")
-	assert.NoError(t, err)
-	assert.Contains(t, response, "Please complete the current step")
-
-	mockLogger.AssertExpectations(t)
+	response = send(t, b, requester, "c1", "yes")
+	assert.Equal(t, "Project created successfully with 2 object(s)! You are now its project owner.", response)
+	assert.Equal(t, []string{"payments-api"}, api.Applied("Project"))
+	assert.Contains(t, api.Applied("RoleBinding"), "payments-api-lead")
 }
 
-func TestInteractiveProjectCreation(t *testing.T) {
-	mockClient := &nobl9.MockClient{}
-	mockLogger := &mockLogger{}
-	mockLogger.On("Info", "Started conversation", mock.Anything).Return()
-	mockLogger.On("Info", "Handling command", mock.Anything).Return()
-	mockLogger.On("Info", "Starting project creation", mock.Anything).Return()
-	mockLogger.On("Info", "Project name validated", mock.Anything).Return()
-	mockLogger.On("Info", "Project description received", mock.Anything).Return()
-	mockLogger.On("Info", "Project created", mock.Anything).Return()
-
-	bot := &Bot{
-		nobl9Client: mockClient,
-		logger:      mockLogger,
-		state:       make(map[string]*ConversationState),
-	}
-
-	err := bot.StartConversation("test-conv")
-	assert.NoError(t, err)
-
-	// Test project creation flow
-	mockClient.On("ValidateProjectName", "test-project").Return(true, nil)
-	mockClient.On("CreateProject", "test-project", "test description").Return("owner@example.com", nil)
-
-	response, err := bot.HandleMessage("test-conv", "/create")
-	assert.NoError(t, err)
-	assert.Contains(t, response, "Please enter a project name")
+func TestApplyExistingProject(t *testing.T) {
+	b, api := newTestBot(t, config.Default())
+	send(t, b, requester, "c1", `create-project payments-api --description "Payments team"`)
+	send(t, b, requester, "c1", "yes")
 
-	response, err = bot.HandleMessage("test-conv", "test-project")
-	assert.NoError(t, err)
-	assert.Contains(t, response, "Please provide a description")
+	manifest := "apply\n- apiVersion: n9/v1alpha\n  kind: Project\n  metadata:\n    name: payments-api\n  spec:\n    description: Moved\n"
 
-	response, err = bot.HandleMessage("test-conv", "test description")
-	assert.NoError(t, err)
-	assert.Contains(t, response, "Create project")
+	// Other teams cannot overwrite the project
+	other := identity.Caller{ID: "sam", Email: "sam@example.com", Source: "test"}
+	_, err := b.HandleMessage(other, "c2", manifest)
+	assert.True(t, errors.IsPermissionError(err))
+	assert.Contains(t, err.Error(), "project 'payments-api' already exists and only its owners can change it with apply")
 
-	response, err = bot.HandleMessage("test-conv", "yes")
-	assert.NoError(t, err)
-	assert.Contains(t, response, "Project created successfully")
-
-	mockLogger.AssertExpectations(t)
+	// Its owner can
+	response := send(t, b, requester, "c1", manifest)
+	assert.Contains(t, response, "Apply these objects?")
+	response = send(t, b, requester, "c1", "yes")
+	assert.Equal(t, "✅ Applied 1 object(s).", response)
+	assert.Equal(t, 2, api.Applies())
 }
-
-func TestInteractiveRoleAssignment(t *testing.T) {
-	mockClient := &nobl9.MockClient{}
-	mockLogger := &mockLogger{}
-	mockLogger.On("Info", "Started conversation", mock.Anything).Return()
-	mockLogger.On("Info", "Handling command", mock.Anything).Return()
-	mockLogger.On("Info", "Starting role assignment", mock.Anything).Return()
-	mockLogger.On("Info", "User validated", mock.Anything).Return()
-	mockLogger.On("Info", "Role type received", mock.Anything).Return()
-	mockLogger.On("Info", "Role assigned", mock.Anything).Return()
-
-	bot := &Bot{
-		nobl9Client: mockClient,
-		logger:      mockLogger,
-		state:       make(map[string]*ConversationState),
-	}
-
-	err := bot.StartConversation("test-conv")
-	assert.NoError(t, err)
-
-	// Test role assignment flow
-	mockClient.On("ValidateUser", "user@example.com").Return(true, nil)
-	mockClient.On("AssignRoles", "test-project", "user@example.com", "member").Return(nil)
-
-	response, err := bot.HandleMessage("test-conv", "/assign test-project")
-	assert.NoError(t, err)
-	assert.Contains(t, response, "Please enter the user's email")
-
-	response, err = bot.HandleMessage("test-conv", "user@example.com")
-	assert.NoError(t, err)
-	assert.Contains(t, response, "Please select a role type")
-
-	response, err = bot.HandleMessage("test-conv", "member")
-	assert.NoError(t, err)
-	assert.Contains(t, response, "Assign role")
-
-	response, err = bot.HandleMessage("test-conv", "yes")
-	assert.NoError(t, err)
-	assert.Contains(t, response, "Role assigned successfully")
-
-	mockLogger.AssertExpectations(t)
-}
-
-func TestInteractiveValidation(t *testing.T) {
-	client := &mockNobl9Client{}
-	bot := NewBot(client)
-
-	// Test invalid project name
-	response, err := bot.HandleMessage("test-thread", "/create")
-	if err != nil {
-		t.Fatalf("Failed to start project creation: %v", err)
-	}
-
-	response, err = bot.HandleMessage("test-thread", "invalid-project")
-	if err != nil {
-		t.Fatalf("Failed to enter project name: %v", err)
-	}
-	if response == "" {
-		t.Error("Expected non-empty response for invalid project name")
-	}
-
-	// Test invalid user email
-	response, err = bot.HandleMessage("test-thread", "/assign test-project")
-	if err != nil {
-		t.Fatalf("Failed to start role assignment: %v", err)
-	}
-
-	response, err = bot.HandleMessage("test-thread", "invalid-email")
-	if err != nil {
-		t.Fatalf("Failed to enter user email: %v", err)
-	}
-	if response == "" {
-		t.Error("Expected non-empty response for invalid user email")
-	}
-
-	// Test invalid role type
-	response, err = bot.HandleMessage("test-thread", "invalid-role")
-	if err != nil {
-		t.Fatalf("Failed to enter role type: %v", err)
-	}
-	if response == "" {
-		t.Error("Expected non-empty response for invalid role type")
-	}
-}
-
-func TestInteractiveCancellation(t *testing.T) {
-	client := &mockNobl9Client{}
-	bot := NewBot(client)
-
-	// Test project creation cancellation
-	response, err := bot.HandleMessage("test-thread", "/create")
-	if err != nil {
-		t.Fatalf("Failed to start project creation: %v", err)
-	}
-
-	response, err = bot.HandleMessage("test-thread", "test-project")
-	if err != nil {
-		t.Fatalf("Failed to enter project name: %v", err)
-	}
-
-	response, err = bot.HandleMessage("test-thread", "Test description")
-	if err != nil {
-		t.Fatalf("Failed to enter project description: %v", err)
-	}
-
-	response, err = bot.HandleMessage("test-thread", "no")
-	if err != nil {
-		t.Fatalf("Failed to cancel project creation: %v", err)
-	}
-	if response == "" {
-		t.Error("Expected non-empty response for cancellation")
-	}
-
-	// Verify state is reset
-	state, err := bot.GetConversationState("test-thread")
-	if err != nil {
-		t.Fatalf("Failed to get conversation state: %v", err)
-	}
-	if state.CurrentStep != "" || state.PendingPrompt != nil {
-		t.Error("Expected conversation state to be reset")
-	}
-}
-
-func TestErrorRecovery(t *testing.T) {
-	client := &mockNobl9Client{
-		validateProjectNameAttempts: 2,
-		createProjectAttempts:       2,
-		validateUserAttempts:        2,
-		assignRolesAttempts:         2,
-	}
-	bot := NewBot(client)
-
-	// Test project name validation recovery
-	response, err := bot.HandleMessage("test-thread", "/create")
-	if err != nil {
-		t.Fatalf("Failed to start project creation: %v", err)
-	}
-
-	response, err = bot.HandleMessage("test-thread", "test-project")
-	if err != nil {
-		t.Fatalf("Failed to validate project name: %v", err)
-	}
-	if response == "" {
-		t.Error("Expected non-empty response after project name validation")
-	}
-
-	// Test project creation recovery
-	response, err = bot.HandleMessage("test-thread", "Test description")
-	if err != nil {
-		t.Fatalf("Failed to enter project description: %v", err)
-	}
-
-	response, err = bot.HandleMessage("test-thread", "yes")
-	if err != nil {
-		t.Fatalf("Failed to create project: %v", err)
-	}
-	if response == "" {
-		t.Error("Expected non-empty response after project creation")
-	}
-
-	// Test user validation recovery
-	response, err = bot.HandleMessage("test-thread", "/assign test-project")
-	if err != nil {
-		t.Fatalf("Failed to start role assignment: %v", err)
-	}
-
-	response, err = bot.HandleMessage("test-thread", "user@example.com")
-	if err != nil {
-		t.Fatalf("Failed to validate user: %v", err)
-	}
-	if response == "" {
-		t.Error("Expected non-empty response after user validation")
-	}
-
-	// Test role assignment recovery
-	response, err = bot.HandleMessage("test-thread", "member")
-	if err != nil {
-		t.Fatalf("Failed to enter role type: %v", err)
-	}
-
-	response, err = bot.HandleMessage("test-thread", "yes")
-	if err != nil {
-		t.Fatalf("Failed to assign role: %v", err)
-	}
-	if response == "" {
-		t.Error("Expected non-empty response after role assignment")
-	}
-}
-
-func TestErrorRecoveryFailure(t *testing.T) {
-	client := &mockNobl9Client{
-		validateProjectNameAttempts: 4, // Exceeds max attempts
-		createProjectAttempts:       4,
-		validateUserAttempts:        4,
-		assignRolesAttempts:         4,
-	}
-	bot := NewBot(client)
-
-	// Test project name validation failure
-	response, err := bot.HandleMessage("test-thread", "/create")
-	if err != nil {
-		t.Fatalf("Failed to start project creation: %v", err)
-	}
-
-	_, err = bot.HandleMessage("test-thread", "test-project")
-	if err == nil {
-		t.Error("Expected error after exceeding max attempts")
-	}
-
-	// Test project creation failure
-	response, err = bot.HandleMessage("test-thread", "/create")
-	if err != nil {
-		t.Fatalf("Failed to start project creation: %v", err)
-	}
-
-	response, err = bot.HandleMessage("test-thread", "test-project")
-	if err != nil {
-		t.Fatalf("Failed to enter project name: %v", err)
-	}
-
-	response, err = bot.HandleMessage("test-thread", "Test description")
-	if err != nil {
-		t.Fatalf("Failed to enter project description: %v", err)
-	}
-
-	_, err = bot.HandleMessage("test-thread", "yes")
-	if err == nil {
-		t.Error("Expected error after exceeding max attempts")
-	}
-
-	// Test user validation failure
-	response, err = bot.HandleMessage("test-thread", "/assign test-project")
-	if err != nil {
-		t.Fatalf("Failed to start role assignment: %v", err)
-	}
-
-	_, err = bot.HandleMessage("test-thread", "user@example.com")
-	if err == nil {
-		t.Error("Expected error after exceeding max attempts")
-	}
-
-	// Test role assignment failure
-	response, err = bot.HandleMessage("test-thread", "/assign test-project")
-	if err != nil {
-		t.Fatalf("Failed to start role assignment: %v", err)
-	}
-
-	response, err = bot.HandleMessage("test-thread", "user@example.com")
-	if err != nil {
-		t.Fatalf("Failed to enter user email: %v", err)
-	}
-
-	response, err = bot.HandleMessage("test-thread", "member")
-	if err != nil {
-		t.Fatalf("Failed to enter role type: %v", err)
-	}
-
-	_, err = bot.HandleMessage("test-thread", "yes")
-	if err == nil {
-		t.Error("Expected error after exceeding max attempts")
-	}
-}
-
-func TestNewBot(t *testing.T) {
-	logger, err := logging.NewLogger(logging.LevelInfo)
-	require.NoError(t, err)
-
-	client := &mockNobl9Client{}
-	bot := NewBot(client, logger)
-
-	assert.NotNil(t, bot)
-	assert.Equal(t, client, bot.client)
-	assert.Equal(t, logger, bot.logger)
-	assert.NotNil(t, bot.conversations)
-}
-
-func TestConversationLifecycle(t *testing.T) {
-	logger := &mockLogger{}
-	client := &mockNobl9Client{}
-	bot := NewBot(client, logger)
-
-	// Test starting a conversation
-	conv, err := bot.StartConversation("user123")
-	require.NoError(t, err)
-	assert.NotNil(t, conv)
-	assert.Equal(t, "user123", conv.UserID)
-	assert.Equal(t, ConversationStateWaitingForCommand, conv.State)
-	assert.True(t, logger.infoCalled)
-	assert.Equal(t, "user123", logger.fields["user_id"])
-
-	// Test ending a conversation
-	err = bot.EndConversation(conv.ID)
-	require.NoError(t, err)
-	_, err = bot.GetConversation(conv.ID)
-	assert.Error(t, err)
-	assert.True(t, logger.infoCalled)
-}
-
-func TestProjectCreation(t *testing.T) {
-	logger := &mockLogger{}
-	client := &mockNobl9Client{}
-	bot := NewBot(client, logger)
-
-	// Test project creation command
-	conv, err := bot.StartConversation("user123")
-	require.NoError(t, err)
-
-	response, err := bot.HandleMessage(conv.ID, "/create test-project")
-	require.NoError(t, err)
-	assert.Contains(t, response, "Please provide a display name for the project")
-	assert.Equal(t, ConversationStateWaitingForProjectDisplayName, conv.State)
-	assert.True(t, logger.infoCalled)
-
-	response, err = bot.HandleMessage(conv.ID, "Test Project")
-	require.NoError(t, err)
-	assert.Contains(t, response, "Project created successfully")
-	assert.Equal(t, ConversationStateWaitingForCommand, conv.State)
-	assert.True(t, client.createProjectCalled)
-	assert.True(t, logger.infoCalled)
-}
-
-func TestRoleManagement(t *testing.T) {
-	logger := &mockLogger{}
-	client := &mockNobl9Client{}
-	bot := NewBot(client, logger)
-
-	// Test role assignment command
-	conv, err := bot.StartConversation("user123")
-	require.NoError(t, err)
-
-	response, err := bot.HandleMessage(conv.ID, "/assign test-project user@example.com")
-	require.NoError(t, err)
-	assert.Contains(t, response, "Please specify the roles to assign")
-	assert.Equal(t, ConversationStateWaitingForRoles, conv.State)
-	assert.True(t, logger.infoCalled)
-
-	response, err = bot.HandleMessage(conv.ID, "admin,member")
-	require.NoError(t, err)
-	assert.Contains(t, response, "Roles assigned successfully")
-	assert.Equal(t, ConversationStateWaitingForCommand, conv.State)
-	assert.True(t, client.assignRolesCalled)
-	assert.True(t, logger.infoCalled)
-}
-
-func TestErrorHandling(t *testing.T) {
-	logger := &mockLogger{}
-	client := &mockNobl9Client{}
-	bot := NewBot(client, logger)
-
-	// Test invalid command
-	conv, err := bot.StartConversation("user123")
-	require.NoError(t, err)
-
-	response, err := bot.HandleMessage(conv.ID, "/invalid")
-	require.NoError(t, err)
-	assert.Contains(t, response, "Unknown command")
-	assert.True(t, logger.warnCalled)
-
-	// Test invalid project name
-	response, err = bot.HandleMessage(conv.ID, "/create invalid project")
-	require.NoError(t, err)
-	assert.Contains(t, response, "Invalid project name")
-	assert.True(t, logger.warnCalled)
-
-	// Test invalid user email
-	response, err = bot.HandleMessage(conv.ID, "/assign test-project invalid-email")
-	require.NoError(t, err)
-	assert.Contains(t, response, "Invalid email address")
-	assert.True(t, logger.warnCalled)
-}
-
-func TestInteractiveProjectCreation(t *testing.T) {
-	logger := &mockLogger{}
-	client := &mockNobl9Client{}
-	bot := NewBot(client, logger)
-
-	conv, err := bot.StartConversation("user123")
-	require.NoError(t, err)
-
-	// Test interactive project creation
-	response, err := bot.HandleMessage(conv.ID, "I want to create a project")
-	require.NoError(t, err)
-	assert.Contains(t, response, "What would you like to name your project?")
-	assert.Equal(t, ConversationStateWaitingForProjectName, conv.State)
-	assert.True(t, logger.infoCalled)
-
-	response, err = bot.HandleMessage(conv.ID, "test-project")
-	require.NoError(t, err)
-	assert.Contains(t, response, "Please provide a display name for the project")
-	assert.Equal(t, ConversationStateWaitingForProjectDisplayName, conv.State)
-	assert.True(t, logger.infoCalled)
-
-	response, err = bot.HandleMessage(conv.ID, "Test Project")
-	require.NoError(t, err)
-	assert.Contains(t, response, "Project created successfully")
-	assert.Equal(t, ConversationStateWaitingForCommand, conv.State)
-	assert.True(t, client.createProjectCalled)
-	assert.True(t, logger.infoCalled)
-}
-
-func TestInteractiveRoleAssignment(t *testing.T) {
-	logger := &mockLogger{}
-	client := &mockNobl9Client{}
-	bot := NewBot(client, logger)
-
-	conv, err := bot.StartConversation("user123")
-	require.NoError(t, err)
-
-	// Test interactive role assignment
-	response, err := bot.HandleMessage(conv.ID, "I want to assign roles")
-	require.NoError(t, err)
-	assert.Contains(t, response, "Which project would you like to assign roles for?")
-	assert.Equal(t, ConversationStateWaitingForProjectName, conv.State)
-	assert.True(t, logger.infoCalled)
-
-	response, err = bot.HandleMessage(conv.ID, "test-project")
-	require.NoError(t, err)
-	assert.Contains(t, response, "Which user would you like to assign roles to?")
-	assert.Equal(t, ConversationStateWaitingForUserEmail, conv.State)
-	assert.True(t, logger.infoCalled)
-
-	response, err = bot.HandleMessage(conv.ID, "user@example.com")
-	require.NoError(t, err)
-	assert.Contains(t, response, "Please specify the roles to assign")
-	assert.Equal(t, ConversationStateWaitingForRoles, conv.State)
-	assert.True(t, logger.infoCalled)
-
-	response, err = bot.HandleMessage(conv.ID, "admin,member")
-	require.NoError(t, err)
-	assert.Contains(t, response, "Roles assigned successfully")
-	assert.Equal(t, ConversationStateWaitingForCommand, conv.State)
-	assert.True(t, client.assignRolesCalled)
-	assert.True(t, logger.infoCalled)
-} 
\ No newline at end of file
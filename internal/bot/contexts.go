@@ -0,0 +1,150 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dfaile/backstage-nobl9/internal/command"
+	"github.com/dfaile/backstage-nobl9/internal/config"
+	"github.com/dfaile/backstage-nobl9/internal/errors"
+	"github.com/dfaile/backstage-nobl9/internal/logging"
+	"github.com/dfaile/backstage-nobl9/internal/nobl9"
+)
+
+// clientKey is the context key for the Nobl9 client of a conversation
+type clientKey struct{}
+
+// withClient returns a context whose Nobl9 calls go through client
+func withClient(ctx context.Context, client *nobl9.Client) context.Context {
+	return context.WithValue(ctx, clientKey{}, client)
+}
+
+// client returns the Nobl9 client for ctx, the conversation's context if it
+// picked one with use-context, otherwise the bot's own connection
+func (b *Bot) client(ctx context.Context) *nobl9.Client {
+	if client, ok := ctx.Value(clientKey{}).(*nobl9.Client); ok {
+		return client
+	}
+	return b.nobl9Client
+}
+
+// clientFor returns the connection with the named context, connecting the first
+// time it is used. An empty name is the bot's own connection.
+func (b *Bot) clientFor(name string) (*nobl9.Client, error) {
+	if name == "" {
+		return b.nobl9Client, nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if client, ok := b.contextClients[name]; ok {
+		return client, nil
+	}
+	client, err := b.nobl9Client.ForContext(name)
+	if err != nil {
+		return nil, err
+	}
+	if b.contextClients == nil {
+		b.contextClients = make(map[string]*nobl9.Client)
+	}
+	b.contextClients[name] = client
+	return client, nil
+}
+
+// loadContexts reads the sloctl config.toml the Nobl9 client uses
+func (b *Bot) loadContexts() (*config.Contexts, error) {
+	path, err := config.ContextsPath(b.nobl9Client.Settings())
+	if err != nil {
+		return nil, err
+	}
+	return config.LoadContexts(path)
+}
+
+// fixedConnection refuses context commands in tenant mode, where the other
+// contexts on the host belong to other organizations
+func (b *Bot) fixedConnection() error {
+	if b.tenant != "" {
+		return errors.NewValidationError(fmt.Sprintf("tenant '%s' always uses the Nobl9 connection set in the tenants config", b.tenant), nil)
+	}
+	return nil
+}
+
+// ListContexts returns the contexts in the sloctl config.toml, sorted by name
+func (b *Bot) ListContexts(ctx context.Context) ([]command.Nobl9Context, error) {
+	if err := b.fixedConnection(); err != nil {
+		return nil, err
+	}
+	contexts, err := b.loadContexts()
+	if err != nil {
+		return nil, err
+	}
+
+	current := b.client(ctx).CurrentContext()
+	result := make([]command.Nobl9Context, 0, len(contexts.Contexts))
+	for _, name := range contexts.Names() {
+		context, _ := contexts.Get(name)
+		result = append(result, command.Nobl9Context{
+			Name:         name,
+			Organization: context.Organization,
+			URL:          context.URL,
+			Current:      name == current,
+			Default:      name == contexts.DefaultContext,
+		})
+	}
+	return result, nil
+}
+
+// UseContext switches the conversation with state to the named context. Other
+// conversations and sloctl's config.toml are left as they are. The context's own
+// credentials replace any set in the bot config.
+func (b *Bot) UseContext(ctx context.Context, state *ConversationState, name string) error {
+	if err := b.fixedConnection(); err != nil {
+		return err
+	}
+	contexts, err := b.loadContexts()
+	if err != nil {
+		return err
+	}
+	if _, ok := contexts.Get(name); !ok {
+		return errors.NewNotFoundError(fmt.Sprintf("context '%s' not found in %s", name, contexts.Path()), nil)
+	}
+
+	client, err := b.clientFor(name)
+	if err != nil {
+		return errors.NewValidationError(fmt.Sprintf("failed to connect with context '%s'", name), err)
+	}
+	state.Nobl9Context = name
+
+	b.logger.WithContext(ctx).Info("Switched Nobl9 context",
+		logging.F("context", name),
+		logging.F("organization", client.Organization()),
+	)
+	return nil
+}
+
+// CurrentContext describes the context the conversation's Nobl9 calls go through
+func (b *Bot) CurrentContext(ctx context.Context) (command.Nobl9Context, error) {
+	client := b.client(ctx)
+	result := command.Nobl9Context{
+		Name:         client.CurrentContext(),
+		Organization: client.Organization(),
+		Current:      true,
+	}
+
+	// Credentials from the environment need no config.toml
+	contexts, err := b.loadContexts()
+	if err != nil {
+		if errors.IsNotFoundError(err) {
+			return result, nil
+		}
+		return result, err
+	}
+	if context, ok := contexts.Get(result.Name); ok {
+		if result.Organization == "" {
+			result.Organization = context.Organization
+		}
+		result.URL = context.URL
+	}
+	result.Default = result.Name == contexts.DefaultContext
+	return result, nil
+}
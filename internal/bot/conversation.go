@@ -0,0 +1,75 @@
+package bot
+
+import (
+	"context"
+
+	"github.com/dfaile/backstage-nobl9/internal/command"
+	"github.com/dfaile/backstage-nobl9/internal/nobl9"
+)
+
+// conversation is the bot as command handlers see it. Its Nobl9 operations run
+// with the request's context, on behalf of the conversation the command was
+// sent in.
+type conversation struct {
+	*Bot
+	ctx   context.Context
+	state *ConversationState
+}
+
+// Ensure conversation implements command.BotCommander
+var _ command.BotCommander = (*conversation)(nil)
+
+// StartConversation starts a new conversation
+func (c *conversation) StartConversation(projectName string) error {
+	return c.Bot.StartConversation(c.ctx, projectName)
+}
+
+// ValidateUser checks if a user exists
+func (c *conversation) ValidateUser(email string) (bool, error) {
+	return c.Bot.ValidateUser(c.ctx, email)
+}
+
+// AssignRoles assigns the role picked in the conversation, or the default role,
+// to users in a project
+func (c *conversation) AssignRoles(project string, users []string) (string, error) {
+	role := c.state.RoleType
+	if role == "" {
+		role = nobl9.DefaultRoleName()
+	}
+	return c.Bot.AssignRoles(c.ctx, project, users, role)
+}
+
+// ListProjects retrieves all projects in the organization
+func (c *conversation) ListProjects() ([]*command.Project, error) {
+	return c.Bot.ListProjects(c.ctx)
+}
+
+// ListContexts returns the contexts in the sloctl config.toml, marking the one
+// the conversation uses
+func (c *conversation) ListContexts() ([]command.Nobl9Context, error) {
+	return c.Bot.ListContexts(c.ctx)
+}
+
+// UseContext switches the conversation, from the rest of this command on, to the
+// named context
+func (c *conversation) UseContext(name string) error {
+	if err := c.Bot.UseContext(c.ctx, c.state, name); err != nil {
+		return err
+	}
+	client, err := c.clientFor(name)
+	if err != nil {
+		return err
+	}
+	c.ctx = withClient(c.ctx, client)
+	return nil
+}
+
+// CurrentContext describes the context the conversation uses
+func (c *conversation) CurrentContext() (command.Nobl9Context, error) {
+	return c.Bot.CurrentContext(c.ctx)
+}
+
+// ExportProject renders a project as a YAML or JSON manifest
+func (c *conversation) ExportProject(name string, includeSLOs bool, format string) (string, error) {
+	return c.Bot.ExportProject(c.ctx, name, includeSLOs, format)
+}
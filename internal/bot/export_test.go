@@ -0,0 +1,12 @@
+package bot
+
+import "time"
+
+// NewCallerLimits exposes newCallerLimits to the bot_test package
+var NewCallerLimits = newCallerLimits
+
+// AllowAt exposes allowAt to the bot_test package
+func (l *callerLimits) AllowAt(caller string, now time.Time) bool { return l.allowAt(caller, now) }
+
+// Callers returns the number of callers with a limiter
+func (l *callerLimits) Callers() int { return len(l.limiters) }
@@ -0,0 +1,49 @@
+package bot
+
+import (
+	"fmt"
+
+	"github.com/dfaile/backstage-nobl9/internal/errors"
+	"github.com/dfaile/backstage-nobl9/internal/logging"
+)
+
+// levels returns the log levels the bot's logger shares
+func (b *Bot) levels() *logging.Levels {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.logLevels
+}
+
+// LogLevels returns the default log level and the per-package overrides
+func (b *Bot) LogLevels() (string, map[string]string) {
+	levels := b.levels()
+	packages := make(map[string]string)
+	for pkg, level := range levels.Packages() {
+		packages[pkg] = string(level)
+	}
+	return string(levels.Level()), packages
+}
+
+// SetLogLevel changes the level of a package, or the default level when pkg is
+// empty. The change lasts until the logging config changes or the bot restarts.
+func (b *Bot) SetLogLevel(pkg, level string) error {
+	if err := b.levels().Set(pkg, logging.Level(level)); err != nil {
+		return errors.NewValidationError(err.Error(), nil)
+	}
+	b.logger.Info("Log level changed",
+		logging.F("package", pkg),
+		logging.F("level", level),
+	)
+	return nil
+}
+
+// ResetLogLevel makes a package log at the default level again
+func (b *Bot) ResetLogLevel(pkg string) error {
+	levels := b.levels()
+	if _, ok := levels.Packages()[pkg]; !ok {
+		return errors.NewNotFoundError(fmt.Sprintf("package '%s' has no log level of its own", pkg), nil)
+	}
+	levels.Reset(pkg)
+	b.logger.Info("Log level reset", logging.F("package", pkg))
+	return nil
+}
@@ -0,0 +1,45 @@
+package bot
+
+import (
+	"github.com/dfaile/backstage-nobl9/internal/metrics"
+)
+
+// Metrics recorded by the bot
+const (
+	MetricCommands         = "nobl9_bot_commands_total"
+	MetricCommandFailures  = "nobl9_bot_command_failures_total"
+	MetricCommandDuration  = "nobl9_bot_command_duration_seconds"
+	MetricMutations        = "nobl9_bot_mutations_total"
+	MetricMutationFailures = "nobl9_bot_mutation_failures_total"
+)
+
+// NewMetrics registers the bot's metrics, each carrying labels
+func NewMetrics(labels map[string]string) *metrics.Metrics {
+	m := metrics.New()
+	for _, name := range []string{MetricCommands, MetricCommandFailures, MetricMutations, MetricMutationFailures} {
+		m.Register(name, metrics.TypeCounter, labels)
+	}
+	m.Register(MetricCommandDuration, metrics.TypeHistogram, labels)
+	return m
+}
+
+// SetMetrics sets where the bot records its metrics
+func (b *Bot) SetMetrics(m *metrics.Metrics) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.metrics = m
+}
+
+// Metrics returns the bot's metrics, nil when none are recorded
+func (b *Bot) Metrics() *metrics.Metrics {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.metrics
+}
+
+// count increments a counter
+func (b *Bot) count(name string) {
+	if b.metrics != nil {
+		b.metrics.Increment(name, 1)
+	}
+}
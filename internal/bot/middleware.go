@@ -0,0 +1,207 @@
+package bot
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/dfaile/backstage-nobl9/internal/audit"
+	"github.com/dfaile/backstage-nobl9/internal/command"
+	"github.com/dfaile/backstage-nobl9/internal/errors"
+	"github.com/dfaile/backstage-nobl9/internal/logging"
+)
+
+// runCommand runs cmd through the middleware chain every command shares. handler
+// does the command's own work once the request has been let through. Recover is
+// outermost, so a panic in any middleware is recovered too.
+func (b *Bot) runCommand(req *command.Request, handler command.HandlerFunc) (string, error) {
+	return command.Chain(handler,
+		command.Recover(b.logPanic),
+		command.Trace(),
+		b.logCommands(),
+		b.measureCommands(),
+		b.auditDenials(),
+		b.limitCommands(),
+		b.authorizeCommands(),
+		command.Validate(),
+	)(req)
+}
+
+// logCommands logs each command and how it ended
+func (b *Bot) logCommands() command.Middleware {
+	return func(next command.HandlerFunc) command.HandlerFunc {
+		return func(req *command.Request) (string, error) {
+			logger := b.logger.WithContext(req.Context)
+			logger.Info("Handling command",
+				logging.F("command", req.Command.Name),
+				logging.F("args", req.Args),
+			)
+			start := time.Now()
+			response, err := next(req)
+			if err != nil {
+				logger.Warn("Command failed",
+					logging.F("command", req.Command.Name),
+					logging.F("duration", time.Since(start)),
+					logging.F("error", err),
+				)
+			}
+			return response, err
+		}
+	}
+}
+
+// measureCommands counts commands and their failures and records how long they take
+func (b *Bot) measureCommands() command.Middleware {
+	return func(next command.HandlerFunc) command.HandlerFunc {
+		return func(req *command.Request) (string, error) {
+			b.count(MetricCommands)
+			start := time.Now()
+			response, err := next(req)
+			if b.metrics != nil {
+				b.metrics.Observe(MetricCommandDuration, time.Since(start).Seconds())
+			}
+			if err != nil {
+				b.count(MetricCommandFailures)
+			}
+			return response, err
+		}
+	}
+}
+
+// auditDenials records the commands callers were not allowed to run. Changes
+// made by the commands that are let through are recorded as they are made.
+func (b *Bot) auditDenials() command.Middleware {
+	return func(next command.HandlerFunc) command.HandlerFunc {
+		return func(req *command.Request) (string, error) {
+			response, err := next(req)
+			if b.audit == nil || !(errors.IsPermissionError(err) || errors.IsRateLimitError(err)) {
+				return response, err
+			}
+			rec := audit.Record{
+				RequestID: logging.RequestID(req.Context),
+				Tenant:    b.tenant,
+				Caller:    req.Caller,
+				Command:   req.Command.Name,
+				Summary:   strings.TrimSpace(req.Command.Name + " " + strings.Join(req.Args, " ")),
+				Outcome:   audit.OutcomeDenied,
+				Error:     err.Error(),
+			}
+			if _, auditErr := b.audit.Append(rec); auditErr != nil {
+				b.logger.WithContext(req.Context).Error("Failed to record denied command in audit log",
+					logging.F("command", req.Command.Name),
+					logging.F("error", auditErr),
+				)
+			}
+			return response, err
+		}
+	}
+}
+
+// limitCommands refuses commands from callers who started more than the configured
+// number in the last minute. Answers to a wizard's prompts are not counted.
+func (b *Bot) limitCommands() command.Middleware {
+	return func(next command.HandlerFunc) command.HandlerFunc {
+		return func(req *command.Request) (string, error) {
+			if !req.Step && !b.commandLimits.Allow(req.Caller.Identity()) {
+				b.logger.WithContext(req.Context).Warn("Command rate limited",
+					logging.F("command", req.Command.Name),
+					logging.F("caller", req.Caller.Identity()),
+				)
+				return "", errors.NewRateLimitError(fmt.Sprintf("too many commands, you may run %d a minute. Please wait and try again", b.commandLimits.PerMinute()), nil)
+			}
+			return next(req)
+		}
+	}
+}
+
+// authorizeCommands refuses commands the caller lacks the permissions for
+func (b *Bot) authorizeCommands() command.Middleware {
+	return func(next command.HandlerFunc) command.HandlerFunc {
+		return func(req *command.Request) (string, error) {
+			if err := b.authorizer.Check(req.Caller.Identity(), req.Command.Name, req.Command.Permissions); err != nil {
+				b.logger.WithContext(req.Context).Warn("Command denied",
+					logging.F("command", req.Command.Name),
+					logging.F("caller", req.Caller.Identity()),
+					logging.F("source", req.Caller.Source),
+				)
+				return "", err
+			}
+			return next(req)
+		}
+	}
+}
+
+// logPanic logs a command's panic with the stack it happened on
+func (b *Bot) logPanic(req *command.Request, value interface{}, stack []byte) {
+	b.logger.WithContext(req.Context).Error("Command panicked",
+		logging.F("command", req.Command.Name),
+		logging.F("panic", fmt.Sprint(value)),
+		logging.F("stack", string(stack)),
+	)
+}
+
+// limiterIdle is how long a caller's limiter goes unused before it is dropped.
+// By then it has refilled, so a new one allows the same.
+const limiterIdle = time.Minute
+
+// callerLimits allows each caller a number of commands a minute
+type callerLimits struct {
+	mu        sync.Mutex
+	perMinute int // 0 allows any number
+	limiters  map[string]*callerLimit
+	swept     time.Time // When idle limiters were last dropped
+}
+
+// callerLimit is one caller's limiter and when they last used it
+type callerLimit struct {
+	limiter  *rate.Limiter
+	lastUsed time.Time
+}
+
+// newCallerLimits creates limits allowing perMinute commands to each caller
+func newCallerLimits(perMinute int) *callerLimits {
+	return &callerLimits{perMinute: perMinute, limiters: make(map[string]*callerLimit)}
+}
+
+// Allow reports whether caller may run another command now
+func (l *callerLimits) Allow(caller string) bool {
+	return l.allowAt(caller, time.Now())
+}
+
+// allowAt reports whether caller may run another command at now, dropping the
+// limiters of callers who have been idle
+func (l *callerLimits) allowAt(caller string, now time.Time) bool {
+	if l == nil || l.perMinute <= 0 {
+		return true
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.swept) >= limiterIdle {
+		for name, limit := range l.limiters {
+			if now.Sub(limit.lastUsed) >= limiterIdle {
+				delete(l.limiters, name)
+			}
+		}
+		l.swept = now
+	}
+
+	limit, ok := l.limiters[caller]
+	if !ok {
+		limit = &callerLimit{limiter: rate.NewLimiter(rate.Every(time.Minute/time.Duration(l.perMinute)), l.perMinute)}
+		l.limiters[caller] = limit
+	}
+	limit.lastUsed = now
+	return limit.limiter.AllowN(now, 1)
+}
+
+// PerMinute returns the number of commands each caller may run a minute
+func (l *callerLimits) PerMinute() int {
+	if l == nil {
+		return 0
+	}
+	return l.perMinute
+}
@@ -0,0 +1,48 @@
+package bot_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dfaile/backstage-nobl9/internal/bot"
+	"github.com/dfaile/backstage-nobl9/internal/config"
+	"github.com/dfaile/backstage-nobl9/internal/errors"
+)
+
+func TestCommandLimitSkipsWizardSteps(t *testing.T) {
+	cfg := config.Default()
+	cfg.RateLimits.CommandsPerMinute = 2
+	b, _ := newTestBot(t, cfg)
+
+	// Answering the wizard's prompts does not use up the limit
+	send(t, b, requester, "c1", "create-project payments-api")
+	send(t, b, requester, "c1", "Payments team")
+	response := send(t, b, requester, "c1", "yes")
+	assert.Equal(t, "Project created successfully! You are now its project owner.", response)
+
+	send(t, b, requester, "c1", "plan")
+	_, err := b.HandleMessage(requester, "c1", "plan")
+	assert.True(t, errors.IsRateLimitError(err))
+}
+
+func TestCallerLimitsDropIdleCallers(t *testing.T) {
+	limits := bot.NewCallerLimits(2)
+	start := time.Now()
+
+	require.True(t, limits.AllowAt("slack:U1", start))
+	require.True(t, limits.AllowAt("slack:U1", start))
+	assert.False(t, limits.AllowAt("slack:U1", start))
+	assert.True(t, limits.AllowAt("slack:U2", start))
+	assert.Equal(t, 2, limits.Callers())
+
+	// Callers idle for a minute are dropped and start over with a full limit
+	later := start.Add(time.Minute)
+	assert.True(t, limits.AllowAt("slack:U3", later))
+	assert.Equal(t, 1, limits.Callers())
+	assert.True(t, limits.AllowAt("slack:U1", later))
+	assert.True(t, limits.AllowAt("slack:U1", later))
+	assert.False(t, limits.AllowAt("slack:U1", later))
+}
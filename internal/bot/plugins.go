@@ -0,0 +1,126 @@
+package bot
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/dfaile/backstage-nobl9/internal/command"
+	"github.com/dfaile/backstage-nobl9/internal/config"
+	"github.com/dfaile/backstage-nobl9/internal/identity"
+	"github.com/dfaile/backstage-nobl9/internal/logging"
+	"github.com/dfaile/backstage-nobl9/internal/plugin"
+)
+
+// loadPlugins loads the plugins cfg points at. Plugins that fail to load are
+// logged and left out, so one broken plugin does not stop the bot.
+func loadPlugins(cfg config.PluginsConfig, logger logging.Logger) []*plugin.Plugin {
+	if cfg.Disabled {
+		return nil
+	}
+
+	dir := cfg.Dir
+	if dir == "" {
+		if defaultDir, err := config.DefaultPluginsDir(); err == nil {
+			dir = defaultDir
+		}
+	}
+	dirs := []string{dir}
+	if !cfg.IgnorePath {
+		dirs = append(dirs, filepath.SplitList(os.Getenv("PATH"))...)
+	}
+
+	paths, err := plugin.Discover(dirs)
+	if err != nil {
+		logger.Warn("Failed to discover plugins", logging.F("error", err))
+	}
+	var plugins []*plugin.Plugin
+	for _, path := range paths {
+		p, err := plugin.Load(path, time.Duration(cfg.Timeout)*time.Millisecond)
+		if err != nil {
+			logger.Warn("Skipping plugin", logging.F("path", path), logging.F("error", err))
+			continue
+		}
+		plugins = append(plugins, p)
+	}
+	return plugins
+}
+
+// registerPlugins replaces the plugin commands in the registry with plugins.
+// Plugins never replace built-in commands, those whose name or an alias is
+// taken are skipped.
+func (b *Bot) registerPlugins(plugins []*plugin.Plugin, logger logging.Logger) {
+	for name := range b.plugins {
+		b.commands.Unregister(name)
+	}
+
+	registered := make(map[string]*plugin.Plugin, len(plugins))
+	for _, p := range plugins {
+		if taken := b.takenName(p); taken != "" {
+			logger.Warn("Skipping plugin, its command name is taken",
+				logging.F("plugin", p.Path),
+				logging.F("name", taken),
+			)
+			continue
+		}
+		b.commands.Register(p.Command())
+		registered[p.Name] = p
+		logger.Info("Loaded plugin", logging.F("command", p.Name), logging.F("path", p.Path))
+	}
+	b.plugins = registered
+}
+
+// takenName returns the plugin's name or alias already used by another command,
+// or an empty string if there is none
+func (b *Bot) takenName(p *plugin.Plugin) string {
+	for _, name := range append([]string{p.Name}, p.Manifest.Aliases...) {
+		if _, exists := b.commands.Get(name); exists {
+			return name
+		}
+	}
+	return ""
+}
+
+// runPlugin runs the plugin behind cmd. Plugins that change things are audited
+// like the bot's own changes.
+func (b *Bot) runPlugin(ctx context.Context, state *ConversationState, p *plugin.Plugin, cmd *command.Command, args []string) (string, error) {
+	parsed, err := cmd.ParseArgs(args)
+	if err != nil {
+		return "", err
+	}
+
+	req := plugin.Request{
+		Args:   args,
+		Values: parsed.Values(),
+		Context: plugin.Context{
+			RequestID: logging.RequestID(ctx),
+			Tenant:    b.tenant,
+			PlanMode:  state.PlanMode,
+		},
+	}
+	if p.Has(plugin.CapabilityCaller) {
+		caller, _ := identity.FromContext(ctx)
+		req.Caller = &caller
+	}
+	if p.Has(plugin.CapabilityContext) && b.nobl9Client != nil {
+		current, err := b.CurrentContext(ctx)
+		if err != nil {
+			return "", err
+		}
+		req.Context.Nobl9 = &current
+	}
+
+	if !p.Has(plugin.CapabilityMutates) {
+		return p.Run(ctx, req)
+	}
+	var message string
+	summary := strings.TrimSpace(cmd.Name + " " + strings.Join(args, " "))
+	_, err = b.mutate(ctx, cmd.Name, summary, nil, func() (string, error) {
+		var runErr error
+		message, runErr = p.Run(ctx, req)
+		return "", runErr
+	})
+	return message, err
+}
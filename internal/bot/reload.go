@@ -0,0 +1,129 @@
+package bot
+
+import (
+	"reflect"
+
+	"github.com/dfaile/backstage-nobl9/internal/authz"
+	"github.com/dfaile/backstage-nobl9/internal/config"
+	"github.com/dfaile/backstage-nobl9/internal/errors"
+	"github.com/dfaile/backstage-nobl9/internal/logging"
+	"github.com/dfaile/backstage-nobl9/internal/nobl9"
+	"github.com/dfaile/backstage-nobl9/internal/plugin"
+	"github.com/dfaile/backstage-nobl9/internal/recovery"
+	"github.com/dfaile/backstage-nobl9/internal/templates"
+	"github.com/dfaile/backstage-nobl9/internal/validation"
+)
+
+// ApplyConfig switches the running bot to cfg. Everything cfg configures is built
+// first, so nothing changes if any of it is invalid. The switch happens between
+// messages and conversations in progress carry on under the new settings, wizards
+// keep the label policies and template they started with. The
+// Nobl9 SDK client is rebuilt when the credentials, organization or context change.
+func (b *Bot) ApplyConfig(cfg *config.Config) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	nameValidator, err := validation.NewProjectNameValidator(cfg.NamingPolicy)
+	if err != nil {
+		return errors.NewValidationError("invalid naming policy", err)
+	}
+	templateRegistry, err := templates.LoadDir(cfg.TemplatesDir)
+	if err != nil {
+		return errors.NewValidationError("failed to load project templates", err)
+	}
+	authorizer, err := authz.New(cfg.Authorization)
+	if err != nil {
+		return errors.NewValidationError("invalid authorization config", err)
+	}
+
+	// Plugins are run to read their manifests, so they load before messages are
+	// held up, and only when the plugins config changes
+	b.mu.RLock()
+	reloadPlugins := b.cfg == nil || cfg.Plugins != b.cfg.Plugins
+	b.mu.RUnlock()
+	var plugins []*plugin.Plugin
+	if reloadPlugins {
+		plugins = loadPlugins(cfg.Plugins, b.logger)
+	}
+
+	// Stop new messages from starting until the switch is done
+	b.reloadMu.Lock()
+	defer b.reloadMu.Unlock()
+	previous := b.cfg
+
+	// Levels changed with the log-level command last until the logging config changes
+	logger, logLevels := b.logger, b.logLevels
+	if previous == nil || !reflect.DeepEqual(cfg.Logging, previous.Logging) {
+		logger, logLevels, err = newLogger(cfg.Logging, b.tenant)
+		if err != nil {
+			return errors.NewValidationError("invalid logging config", err)
+		}
+	}
+
+	reconnect := previous != nil && cfg.Nobl9 != previous.Nobl9
+	if previous == nil && b.nobl9Client != nil {
+		reconnect = cfg.Nobl9 != b.nobl9Client.Settings()
+	}
+	if reconnect && b.nobl9Client != nil {
+		if err := b.nobl9Client.Reconnect(cfg.Nobl9); err != nil {
+			return errors.NewValidationError("failed to connect with the new Nobl9 credentials", err)
+		}
+		logger.Info("Reconnected to Nobl9", logging.F("organization", cfg.Nobl9.Organization))
+	}
+	if b.nobl9Client != nil {
+		b.nobl9Client.SetRateLimit(cfg.RateLimits.RequestsPerSecond)
+	}
+
+	// Retry policies and role mappings are shared by every client and conversation
+	recovery.Configure(cfg.RateLimits, cfg.Retry)
+	nobl9.SetRoleMappings(cfg.RoleMappings)
+
+	b.mu.Lock()
+	b.cfg = cfg
+	b.logger = logger
+	b.logLevels = logLevels
+	b.nameValidator = nameValidator
+	b.templates = templateRegistry
+	b.authorizer = authorizer
+	// Contexts picked in conversations reconnect with the new settings when next used
+	b.contextClients = nil
+	if previous == nil || cfg.RateLimits.CommandsPerMinute != previous.RateLimits.CommandsPerMinute {
+		b.commandLimits = newCallerLimits(cfg.RateLimits.CommandsPerMinute)
+	}
+	if reloadPlugins {
+		b.registerPlugins(plugins, logger)
+	}
+	b.labelPolicies = cfg.ProjectLabels
+	b.allowedKinds = cfg.ApplyAllowedKinds
+	b.applyFilesDir = cfg.ApplyFilesDir
+	b.approvalPolicy = cfg.Approvals
+	b.helpResources = cfg.HelpResources
+	b.frontend = cfg.Frontend
+	b.mu.Unlock()
+
+	if previous != nil {
+		// The audit log, GitOps repository and trace exporter stay open for the life of the process
+		if cfg.Audit != previous.Audit || cfg.GitOps != previous.GitOps || !reflect.DeepEqual(cfg.Tracing, previous.Tracing) {
+			logger.Warn("Audit, gitops and tracing settings changed, restart the bot to apply them")
+		}
+		logger.Info("Config reloaded", logging.F("source", cfg.Source))
+	}
+	return nil
+}
+
+// newLogger builds the logger cfg describes, tagged with the bot's tenant if it has one
+func newLogger(cfg config.LoggingConfig, tenant string) (logging.Logger, *logging.Levels, error) {
+	options, err := cfg.Options()
+	if err != nil {
+		return nil, nil, err
+	}
+	logger, err := logging.NewLoggerWithOptions(options)
+	if err != nil {
+		return nil, nil, err
+	}
+	if tenant != "" {
+		logger = logger.With(logging.F("tenant", tenant))
+	}
+	return logger, options.Levels, nil
+}
@@ -0,0 +1,82 @@
+package bot_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dfaile/backstage-nobl9/internal/config"
+)
+
+func TestReloadKeepsWizardLabels(t *testing.T) {
+	cfg := config.Default()
+	cfg.ProjectLabels = []config.LabelPolicy{
+		{Key: "team", Required: true, AllowedValues: []string{"payments", "search"}},
+		{Key: "tier", Required: true},
+	}
+	b, api := newTestBot(t, cfg)
+
+	response := send(t, b, requester, "c1", `create-project payments-api --description "Payments team"`)
+	assert.Contains(t, response, "Please enter a value for label 'team' (required):")
+	response = send(t, b, requester, "c1", "payments")
+	assert.Contains(t, response, "Please enter a value for label 'tier' (required):")
+
+	// The reload drops the labels the wizard is still asking for
+	reloaded := config.Default()
+	reloaded.Nobl9 = cfg.Nobl9
+	require.NoError(t, b.ApplyConfig(reloaded))
+
+	response = send(t, b, requester, "c1", "critical")
+	assert.Contains(t, response, "labels team=payments, tier=critical")
+	response = send(t, b, requester, "c1", "yes")
+	assert.Equal(t, "Project created successfully! You are now its project owner.", response)
+	assert.Equal(t, []string{"payments-api"}, api.Applied("Project"))
+}
+
+func TestReloadKeepsWizardTemplate(t *testing.T) {
+	cfg := config.Default()
+	cfg.TemplatesDir = templatesDir(t, `  - name: service
+    default: api
+`+leadParameter)
+	b, api := newTestBot(t, cfg)
+
+	response := send(t, b, requester, "c1", "create-project payments-api --template team --description Payments")
+	assert.Contains(t, response, "template parameter 'service'")
+	response = send(t, b, requester, "c1", "")
+	assert.Contains(t, response, "template parameter 'lead'")
+
+	// The reloaded template has fewer parameters than the wizard already went through
+	reloaded := config.Default()
+	reloaded.Nobl9 = cfg.Nobl9
+	reloaded.TemplatesDir = templatesDir(t, "")
+	require.NoError(t, b.ApplyConfig(reloaded))
+
+	response = send(t, b, requester, "c1", "lee@example.com")
+	assert.Contains(t, response, "from template 'team'")
+	response = send(t, b, requester, "c1", "yes")
+	assert.Equal(t, "Project created successfully with 2 object(s)! You are now its project owner.", response)
+	assert.Contains(t, api.Applied("RoleBinding"), "payments-api-lead")
+}
+
+func TestReloadDoesNotRejectWizardInProgress(t *testing.T) {
+	cfg := config.Default()
+	b, api := newTestBot(t, cfg)
+
+	response := send(t, b, requester, "c1", `create-project payments-api --description "Payments team"`)
+	assert.Contains(t, response, "Create project 'payments-api'")
+
+	// The reload requires a label the wizard did not ask for
+	reloaded := config.Default()
+	reloaded.Nobl9 = cfg.Nobl9
+	reloaded.ProjectLabels = []config.LabelPolicy{{Key: "team", Required: true}}
+	require.NoError(t, b.ApplyConfig(reloaded))
+
+	response = send(t, b, requester, "c1", "yes")
+	assert.Equal(t, "Project created successfully! You are now its project owner.", response)
+	assert.Equal(t, []string{"payments-api"}, api.Applied("Project"))
+
+	// Wizards started after the reload ask for it
+	response = send(t, b, requester, "c1", `create-project search-api --description "Search team"`)
+	assert.Contains(t, response, "Please enter a value for label 'team' (required):")
+}
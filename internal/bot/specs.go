@@ -0,0 +1,53 @@
+package bot
+
+import (
+	"github.com/dfaile/backstage-nobl9/internal/command"
+)
+
+// Arguments of the commands the bot handles itself. Whatever an interactive
+// command is not given is asked for.
+var (
+	helpSpec = &command.Spec{
+		Args: []command.Arg{{Name: "command", Description: "Command to show help for"}},
+	}
+
+	createProjectSpec = &command.Spec{
+		Args: []command.Arg{{Name: "name", Description: "Project name"}},
+		Flags: []command.Flag{
+			{Name: "dry-run", Type: command.TypeBool, Description: "Preview the project without creating it"},
+			{Name: "template", Placeholder: "name", Description: "Create the project from a template"},
+			{Name: "description", Placeholder: "text", Description: "Project description, quoted if it has spaces"},
+			{Name: "label", Type: command.TypeKeyValue, Placeholder: "key=value", Repeated: true, Description: "Project label, may be repeated"},
+		},
+	}
+
+	assignRoleSpec = &command.Spec{
+		Args: []command.Arg{
+			{Name: "project", Description: "Project to assign the role in"},
+			{Name: "user", Description: "Email of the user"},
+		},
+		Flags: []command.Flag{
+			{Name: "dry-run", Type: command.TypeBool, Description: "Preview the role binding without applying it"},
+			{Name: "role", Description: "Role to assign, such as member"},
+		},
+	}
+
+	applySpec = &command.Spec{
+		Args: []command.Arg{{Name: "file", Description: "Manifest file in the apply_files_dir, pasted in the chat when left out"}},
+		Flags: []command.Flag{
+			{Name: "dry-run", Type: command.TypeBool, Description: "Preview the changes without applying them"},
+		},
+	}
+
+	decideSpec = &command.Spec{
+		Args: []command.Arg{{Name: "id", Description: "Approval request ID", Required: true}},
+	}
+
+	useContextSpec = &command.Spec{
+		Args: []command.Arg{{Name: "name", Description: "Context from the sloctl config.toml", Required: true}},
+	}
+
+	planSpec = &command.Spec{
+		Args: []command.Arg{{Name: "mode", Choices: []string{"on", "off"}}},
+	}
+)
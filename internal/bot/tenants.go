@@ -0,0 +1,171 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/dfaile/backstage-nobl9/internal/audit"
+	"github.com/dfaile/backstage-nobl9/internal/config"
+	"github.com/dfaile/backstage-nobl9/internal/errors"
+	"github.com/dfaile/backstage-nobl9/internal/identity"
+	"github.com/dfaile/backstage-nobl9/internal/logging"
+	"github.com/dfaile/backstage-nobl9/internal/tenant"
+)
+
+// Tenants serves several Nobl9 organizations by routing each message to the bot of
+// the caller's tenant. Every tenant's bot has its own Nobl9 client and rate limit,
+// conversations, approval queue, audit log and metrics.
+type Tenants struct {
+	registry *tenant.Registry
+
+	mu   sync.Mutex
+	cfg  *config.Config
+	bots map[string]*Bot // Created on a tenant's first message
+}
+
+// NewTenants creates the router for the tenants in cfg
+func NewTenants(cfg *config.Config) (*Tenants, error) {
+	if !cfg.Tenants.Enabled() {
+		return nil, errors.NewValidationError("no tenants are configured", nil)
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &Tenants{
+		registry: tenant.NewRegistry(cfg.Tenants),
+		cfg:      cfg,
+		bots:     make(map[string]*Bot),
+	}, nil
+}
+
+// Registry returns the registry matching callers to tenants
+func (t *Tenants) Registry() *tenant.Registry {
+	return t.registry
+}
+
+// HandleMessage handles a message with the bot of the caller's tenant
+func (t *Tenants) HandleMessage(caller identity.Caller, conversationID string, message string) (string, error) {
+	return t.HandleMessageContext(context.Background(), caller, conversationID, message)
+}
+
+// HandleMessageContext handles a message with the bot of the caller's tenant, as
+// part of the trace in ctx
+func (t *Tenants) HandleMessageContext(ctx context.Context, caller identity.Caller, conversationID string, message string) (string, error) {
+	b, err := t.BotFor(caller)
+	if err != nil {
+		return "", err
+	}
+	return b.HandleMessageContext(ctx, caller, conversationID, message)
+}
+
+// BotFor returns the bot of the caller's tenant
+func (t *Tenants) BotFor(caller identity.Caller) (*Bot, error) {
+	name, err := t.registry.Resolve(caller)
+	if err != nil {
+		return nil, err
+	}
+	return t.Bot(name)
+}
+
+// Bot returns the named tenant's bot, connecting it on first use
+func (t *Tenants) Bot(name string) (*Bot, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if b, ok := t.bots[name]; ok {
+		return b, nil
+	}
+
+	cfg, err := t.cfg.ForTenant(name)
+	if err != nil {
+		return nil, errors.NewNotFoundError(err.Error(), nil)
+	}
+	client, err := t.registry.Client(name)
+	if err != nil {
+		return nil, err
+	}
+	b, err := New(client)
+	if err != nil {
+		return nil, err
+	}
+	b.SetTenant(name)
+	b.SetMetrics(NewMetrics(map[string]string{"tenant": name}))
+	if err := b.ApplyConfig(cfg); err != nil {
+		return nil, err
+	}
+	auditLog, err := audit.Open(cfg.Audit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log for tenant '%s': %w", name, err)
+	}
+	b.SetAuditLog(auditLog)
+
+	b.Logger().Info("Tenant connected", logging.F("organization", client.Organization()))
+	t.bots[name] = b
+	return b, nil
+}
+
+// ApplyConfig switches every tenant's bot to cfg. Tenants that were removed are
+// dropped along with their conversations, and their audit logs are closed. A tenant whose new config is rejected,
+// for example because its credentials do not work, keeps its old config.
+func (t *Tenants) ApplyConfig(cfg *config.Config) error {
+	if !cfg.Tenants.Enabled() {
+		return errors.NewValidationError("tenants cannot be turned off while the bot runs, restart it instead", nil)
+	}
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var problems []string
+	for name, b := range t.bots {
+		if _, ok := cfg.Tenants.Orgs[name]; !ok {
+			delete(t.bots, name)
+			if err := b.Close(); err != nil {
+				problems = append(problems, fmt.Sprintf("tenant '%s': failed to close its audit log: %v", name, err))
+			}
+			b.Logger().Info("Tenant removed")
+			continue
+		}
+		tenantCfg, err := cfg.ForTenant(name)
+		if err == nil {
+			err = b.ApplyConfig(tenantCfg)
+		}
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("tenant '%s': %v", name, err))
+		}
+	}
+	t.cfg = cfg
+	t.registry.Update(cfg.Tenants)
+
+	if len(problems) > 0 {
+		return errors.NewValidationError(strings.Join(problems, "; "), nil)
+	}
+	return nil
+}
+
+// FormatPrometheus returns the metrics of every connected tenant in Prometheus format
+func (t *Tenants) FormatPrometheus() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var sb strings.Builder
+	for _, name := range t.registry.Names() {
+		if b, ok := t.bots[name]; ok {
+			sb.WriteString(b.Metrics().FormatPrometheus())
+		}
+	}
+	return sb.String()
+}
+
+// SetTenant names the tenant the bot serves. The name is added to its logs and
+// audit records, and its Nobl9 connection is fixed by the tenants config.
+func (b *Bot) SetTenant(name string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tenant = name
+	b.logger = b.logger.With(logging.F("tenant", name))
+}
@@ -0,0 +1,58 @@
+package bot_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dfaile/backstage-nobl9/internal/bot"
+	"github.com/dfaile/backstage-nobl9/internal/config"
+	"github.com/dfaile/backstage-nobl9/internal/errors"
+)
+
+// tenantsConfig configures the payments tenant, connected to a fake Nobl9 API
+func tenantsConfig(t *testing.T) *config.Config {
+	t.Helper()
+	_, path := startFakeNobl9(t)
+	cfg := config.Default()
+	cfg.Audit.Path = filepath.Join(t.TempDir(), "audit.jsonl")
+	cfg.Tenants = config.TenantsConfig{
+		Orgs: map[string]config.TenantConfig{
+			"payments": {Nobl9: config.Nobl9Config{ConfigFile: path}, Callers: []string{"test:*"}},
+		},
+	}
+	return cfg
+}
+
+func TestTenantsRefuseGitOps(t *testing.T) {
+	cfg := tenantsConfig(t)
+	cfg.GitOps.RepoDir = t.TempDir()
+
+	// Tenant bots could not commit for review, so changes would skip it
+	_, err := bot.NewTenants(cfg)
+	assert.True(t, errors.IsValidationError(err))
+	assert.Contains(t, err.Error(), "gitops.repo_dir: GitOps mode cannot be used with tenants")
+
+	tenants, err := bot.NewTenants(tenantsConfig(t))
+	require.NoError(t, err)
+	err = tenants.ApplyConfig(cfg)
+	assert.True(t, errors.IsValidationError(err))
+}
+
+func TestTenantRefusesContexts(t *testing.T) {
+	tenants, err := bot.NewTenants(tenantsConfig(t))
+	require.NoError(t, err)
+
+	// The host's other contexts belong to other organizations
+	for _, message := range []string{"contexts", "use-context test"} {
+		_, err := tenants.HandleMessage(requester, "c1", message)
+		assert.True(t, errors.IsValidationError(err), message)
+		assert.Contains(t, err.Error(), "tenant 'payments' always uses the Nobl9 connection set in the tenants config")
+	}
+
+	response, err := tenants.HandleMessage(requester, "c1", "current-context")
+	require.NoError(t, err)
+	assert.Contains(t, response, "acme")
+}
@@ -0,0 +1,19 @@
+package bot
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/dfaile/backstage-nobl9/internal/tracing"
+)
+
+// startAttempt starts the span of one attempt at a retried operation, so a trace
+// shows how much of a slow message was spent retrying
+func startAttempt(ctx context.Context, operation string, attempt int) (context.Context, trace.Span) {
+	return tracing.Start(ctx, "retry.attempt",
+		attribute.String("retry.operation", operation),
+		attribute.Int("retry.attempt", attempt),
+	)
+}
@@ -0,0 +1,380 @@
+package command
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/dfaile/backstage-nobl9/internal/errors"
+)
+
+// ArgType is the kind of value an argument or flag takes
+type ArgType string
+
+const (
+	TypeString   ArgType = "string"
+	TypeInt      ArgType = "int"
+	TypeBool     ArgType = "bool"      // Flags only, set by naming the flag
+	TypeKeyValue ArgType = "key=value" // Read with Args.Map
+)
+
+// Arg describes a positional argument
+type Arg struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Type        ArgType  `json:"type,omitempty"` // Defaults to TypeString
+	Required    bool     `json:"required,omitempty"`
+	Repeated    bool     `json:"repeated,omitempty"` // Takes every remaining argument, only the last Arg may repeat
+	Choices     []string `json:"choices,omitempty"`  // Allowed values, matched ignoring case
+}
+
+// Flag describes a --flag. Flags may appear anywhere among the arguments, as
+// --name value or --name=value.
+type Flag struct {
+	Name        string   `json:"name"` // Without the leading dashes
+	Description string   `json:"description,omitempty"`
+	Type        ArgType  `json:"type,omitempty"`        // Defaults to TypeString
+	Placeholder string   `json:"placeholder,omitempty"` // Names the value in the usage, defaults to the flag's name
+	Default     string   `json:"default,omitempty"`
+	Required    bool     `json:"required,omitempty"`
+	Repeated    bool     `json:"repeated,omitempty"` // May be given more than once
+	Choices     []string `json:"choices,omitempty"`  // Allowed values, matched ignoring case
+}
+
+// Spec declares the arguments and flags a command takes. Commands with a spec
+// get their usage generated and their arguments checked before they run.
+type Spec struct {
+	Args  []Arg  `json:"args,omitempty"`
+	Flags []Flag `json:"flags,omitempty"`
+}
+
+// ArgError is a problem with a single argument or flag
+type ArgError struct {
+	Arg     string // The argument's name or --flag
+	Message string
+}
+
+// ArgErrors lists every problem found parsing a command's arguments
+type ArgErrors []ArgError
+
+// Error implements error, listing one problem per line
+func (e ArgErrors) Error() string {
+	problems := make([]string, len(e))
+	for i, problem := range e {
+		problems[i] = fmt.Sprintf("• %s: %s", problem.Arg, problem.Message)
+	}
+	return strings.Join(problems, "\n")
+}
+
+// Args holds arguments parsed with a Spec, keyed by argument or flag name
+type Args struct {
+	values map[string][]string
+}
+
+// String returns the value of an argument or flag, or its default when not given
+func (a *Args) String(name string) string {
+	if values := a.values[name]; len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// Strings returns every value of a repeated argument or flag
+func (a *Args) Strings(name string) []string {
+	return a.values[name]
+}
+
+// Int returns the value of an integer argument or flag, 0 when not given
+func (a *Args) Int(name string) int {
+	n, _ := strconv.Atoi(a.String(name))
+	return n
+}
+
+// Bool reports whether a boolean flag was given
+func (a *Args) Bool(name string) bool {
+	return a.String(name) == "true"
+}
+
+// Map returns the values of a key=value argument or flag
+func (a *Args) Map(name string) map[string]string {
+	values := a.values[name]
+	if len(values) == 0 {
+		return nil
+	}
+	m := make(map[string]string, len(values))
+	for _, value := range values {
+		key, val, _ := strings.Cut(value, "=")
+		m[key] = val
+	}
+	return m
+}
+
+// Values returns a copy of every argument and flag given or defaulted, keyed by name
+func (a *Args) Values() map[string][]string {
+	values := make(map[string][]string, len(a.values))
+	for name, v := range a.values {
+		values[name] = append([]string(nil), v...)
+	}
+	return values
+}
+
+// Has reports whether an argument or flag was given
+func (a *Args) Has(name string) bool {
+	_, ok := a.values[name]
+	return ok
+}
+
+// Parse checks args against the spec. Every problem is reported, each against
+// the argument it concerns, in the ArgErrors returned.
+func (s *Spec) Parse(args []string) (*Args, error) {
+	parsed := &Args{values: make(map[string][]string)}
+	var problems ArgErrors
+	add := func(arg, format string, a ...interface{}) {
+		problems = append(problems, ArgError{Arg: arg, Message: fmt.Sprintf(format, a...)})
+	}
+
+	var positional []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			positional = append(positional, args[i+1:]...)
+			break
+		}
+		if !strings.HasPrefix(arg, "--") {
+			positional = append(positional, arg)
+			continue
+		}
+
+		name, value, hasValue := strings.Cut(strings.TrimPrefix(arg, "--"), "=")
+		flag, ok := s.flag(name)
+		if !ok {
+			add("--"+name, "unknown flag")
+			continue
+		}
+		if flag.Type == TypeBool {
+			if hasValue {
+				add("--"+name, "takes no value")
+				continue
+			}
+			value = "true"
+		} else if !hasValue {
+			if i+1 >= len(args) {
+				add("--"+name, "requires a value")
+				continue
+			}
+			value = args[i+1]
+			i++
+		}
+		if parsed.Has(name) && !flag.Repeated {
+			add("--"+name, "may only be given once")
+			continue
+		}
+		value, err := checkValue(flag.Type, flag.Choices, value)
+		if err != nil {
+			add("--"+name, "%s", err)
+			continue
+		}
+		parsed.values[name] = append(parsed.values[name], value)
+	}
+
+	for i, arg := range s.Args {
+		if i >= len(positional) {
+			if arg.Required {
+				add(arg.Name, "is required")
+			}
+			continue
+		}
+		values := positional[i : i+1]
+		if arg.Repeated {
+			values = positional[i:]
+		}
+		for _, value := range values {
+			value, err := checkValue(arg.Type, arg.Choices, value)
+			if err != nil {
+				add(arg.Name, "%s", err)
+				continue
+			}
+			parsed.values[arg.Name] = append(parsed.values[arg.Name], value)
+		}
+	}
+	if extra := len(positional) - len(s.Args); extra > 0 && (len(s.Args) == 0 || !s.Args[len(s.Args)-1].Repeated) {
+		for _, value := range positional[len(s.Args):] {
+			add(value, "unexpected argument")
+		}
+	}
+
+	for _, flag := range s.Flags {
+		if parsed.Has(flag.Name) {
+			continue
+		}
+		if flag.Required {
+			add("--"+flag.Name, "is required")
+		} else if flag.Default != "" {
+			parsed.values[flag.Name] = []string{flag.Default}
+		}
+	}
+
+	if len(problems) > 0 {
+		return nil, problems
+	}
+	return parsed, nil
+}
+
+// ParseArgs parses args with the command's spec. The validation error returned
+// lists each problem followed by the command's usage.
+func (c *Command) ParseArgs(args []string) (*Args, error) {
+	if c.Spec == nil {
+		return &Args{values: map[string][]string{}}, nil
+	}
+	return parseArgs(c.Spec, c.Usage, args)
+}
+
+// parseArgs parses args with spec, explaining any problems with usage
+func parseArgs(spec *Spec, usage string, args []string) (*Args, error) {
+	parsed, err := spec.Parse(args)
+	if err != nil {
+		return nil, errors.NewValidationError(fmt.Sprintf("invalid arguments\n%s\nusage: %s", err, usage), nil)
+	}
+	return parsed, nil
+}
+
+// flag finds the flag with the given name
+func (s *Spec) flag(name string) (Flag, bool) {
+	for _, flag := range s.Flags {
+		if flag.Name == name {
+			return flag, true
+		}
+	}
+	return Flag{}, false
+}
+
+// checkValue converts value to the canonical form of its type and choices
+func checkValue(argType ArgType, choices []string, value string) (string, error) {
+	switch argType {
+	case TypeInt:
+		if _, err := strconv.Atoi(value); err != nil {
+			return "", fmt.Errorf("must be a whole number, got %q", value)
+		}
+	case TypeKeyValue:
+		if key, _, found := strings.Cut(value, "="); !found || key == "" {
+			return "", fmt.Errorf("must be key=value, got %q", value)
+		}
+	}
+	if len(choices) == 0 {
+		return value, nil
+	}
+	for _, choice := range choices {
+		if strings.EqualFold(value, choice) {
+			return choice, nil
+		}
+	}
+	return "", fmt.Errorf("must be %s, got %q", joinChoices(choices), value)
+}
+
+// joinChoices lists choices as "a, b or c"
+func joinChoices(choices []string) string {
+	if len(choices) == 1 {
+		return choices[0]
+	}
+	return strings.Join(choices[:len(choices)-1], ", ") + " or " + choices[len(choices)-1]
+}
+
+// Usage describes how to run the command name with the spec's arguments, e.g.
+// "export-project [--format yaml|json] [--with-slos] <name>"
+func (s *Spec) Usage(name string) string {
+	parts := []string{name}
+	for _, flag := range s.Flags {
+		part := "--" + flag.Name
+		if flag.Type != TypeBool {
+			part += " " + placeholder(flag.Placeholder, flag.Name, flag.Choices)
+		}
+		if !flag.Required {
+			part = "[" + part + "]"
+		}
+		if flag.Repeated {
+			part += "..."
+		}
+		parts = append(parts, part)
+	}
+	for _, arg := range s.Args {
+		part := placeholder("", arg.Name, arg.Choices)
+		if len(arg.Choices) == 0 {
+			part = "<" + arg.Name + ">"
+		}
+		if !arg.Required {
+			part = "[" + strings.Trim(part, "<>") + "]"
+		}
+		if arg.Repeated {
+			part += "..."
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, " ")
+}
+
+// placeholder names a value in the usage by its choices, placeholder or name
+func placeholder(placeholder, name string, choices []string) string {
+	if len(choices) > 0 {
+		return strings.Join(choices, "|")
+	}
+	if placeholder == "" {
+		placeholder = name
+	}
+	return "<" + placeholder + ">"
+}
+
+// Split breaks a command line into arguments the way a shell does. Single quotes
+// keep everything between them, double quotes keep spaces and allow \" and \\,
+// and a backslash outside quotes escapes the next character.
+func Split(input string) ([]string, error) {
+	var args []string
+	var current strings.Builder
+	inArg := false
+	var quote rune
+
+	runes := []rune(input)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case quote == '"':
+			switch {
+			case r == '"':
+				quote = 0
+			case r == '\\' && i+1 < len(runes) && (runes[i+1] == '"' || runes[i+1] == '\\'):
+				i++
+				current.WriteRune(runes[i])
+			default:
+				current.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inArg = true
+		case r == '\\' && i+1 < len(runes):
+			i++
+			current.WriteRune(runes[i])
+			inArg = true
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+	if quote != 0 {
+		return nil, errors.NewValidationError(fmt.Sprintf("unterminated %c quote", quote), nil)
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
@@ -0,0 +1,130 @@
+package command_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dfaile/backstage-nobl9/internal/command"
+	"github.com/dfaile/backstage-nobl9/internal/errors"
+)
+
+var createSpec = &command.Spec{
+	Args: []command.Arg{{Name: "name", Description: "Project name"}},
+	Flags: []command.Flag{
+		{Name: "dry-run", Type: command.TypeBool},
+		{Name: "description", Placeholder: "text", Description: "Project description"},
+		{Name: "label", Type: command.TypeKeyValue, Placeholder: "key=value", Repeated: true},
+		{Name: "format", Choices: []string{"yaml", "json"}, Default: "yaml"},
+		{Name: "page", Type: command.TypeInt, Placeholder: "n"},
+	},
+}
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{"  payments-api  --dry-run ", []string{"payments-api", "--dry-run"}},
+		{`--description "Payments team services" payments-api`, []string{"--description", "Payments team services", "payments-api"}},
+		{`--description 'It''s "quoted"'`, []string{"--description", `Its "quoted"`}},
+		{`"say \"hi\"" back\ slash ""`, []string{`say "hi"`, "back slash", ""}},
+		{"--label=team=payments", []string{"--label=team=payments"}},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := command.Split(tt.input)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	_, err := command.Split(`--description "Payments team`)
+	assert.True(t, errors.IsValidationError(err))
+	assert.Contains(t, err.Error(), `unterminated " quote`)
+}
+
+func TestSpecParse(t *testing.T) {
+	args, err := createSpec.Parse([]string{"--label", "team=payments", "payments-api", "--dry-run", "--label=tier=1", "--format", "JSON", "--description", "Payments team services"})
+	require.NoError(t, err)
+	assert.Equal(t, "payments-api", args.String("name"))
+	assert.True(t, args.Bool("dry-run"))
+	assert.Equal(t, "Payments team services", args.String("description"))
+	assert.Equal(t, map[string]string{"team": "payments", "tier": "1"}, args.Map("label"))
+	assert.Equal(t, "json", args.String("format"))
+	assert.False(t, args.Has("page"))
+
+	// Defaults fill in flags that are left out
+	args, err = createSpec.Parse(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "yaml", args.String("format"))
+	assert.False(t, args.Has("name"))
+	assert.Nil(t, args.Map("label"))
+
+	// Everything after -- is positional
+	args, err = createSpec.Parse([]string{"--", "--dry-run"})
+	require.NoError(t, err)
+	assert.Equal(t, "--dry-run", args.String("name"))
+	assert.False(t, args.Bool("dry-run"))
+}
+
+func TestSpecParseErrors(t *testing.T) {
+	_, err := createSpec.Parse([]string{"a", "b", "--format", "xml", "--label", "team", "--page", "two", "--owner", "bob", "--dry-run=yes", "--description", "x", "--description", "y", "--page"})
+	var problems command.ArgErrors
+	require.ErrorAs(t, err, &problems)
+	assert.Equal(t, command.ArgErrors{
+		{Arg: "--format", Message: `must be yaml or json, got "xml"`},
+		{Arg: "--label", Message: `must be key=value, got "team"`},
+		{Arg: "--page", Message: `must be a whole number, got "two"`},
+		{Arg: "--owner", Message: "unknown flag"},
+		{Arg: "--dry-run", Message: "takes no value"},
+		{Arg: "--description", Message: "may only be given once"},
+		{Arg: "--page", Message: "requires a value"},
+		// An unknown flag's value is taken as an argument
+		{Arg: "b", Message: "unexpected argument"},
+		{Arg: "bob", Message: "unexpected argument"},
+	}, problems)
+
+	spec := &command.Spec{
+		Args: []command.Arg{
+			{Name: "id", Required: true},
+			{Name: "users", Repeated: true, Choices: []string{"alice", "bob"}},
+		},
+		Flags: []command.Flag{{Name: "reason", Required: true}},
+	}
+	args, err := spec.Parse([]string{"42", "alice", "BOB", "--reason", "on call"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"alice", "bob"}, args.Strings("users"))
+
+	_, err = spec.Parse(nil)
+	assert.Equal(t, "• id: is required\n• --reason: is required", err.Error())
+}
+
+func TestSpecUsage(t *testing.T) {
+	assert.Equal(t,
+		"create-project [--dry-run] [--description <text>] [--label <key=value>]... [--format yaml|json] [--page <n>] [name]",
+		createSpec.Usage("create-project"))
+
+	spec := &command.Spec{
+		Args:  []command.Arg{{Name: "id", Required: true}, {Name: "mode", Choices: []string{"on", "off"}}, {Name: "users", Repeated: true}},
+		Flags: []command.Flag{{Name: "reason", Required: true}},
+	}
+	assert.Equal(t, "approve --reason <reason> <id> [on|off] [users]...", spec.Usage("approve"))
+}
+
+func TestParseArgs(t *testing.T) {
+	registry := command.NewCommandRegistry()
+	cmd := &command.Command{Name: "create-project", Spec: createSpec}
+	registry.Register(cmd)
+	assert.Equal(t, createSpec.Usage("create-project"), cmd.Usage)
+
+	_, err := cmd.ParseArgs([]string{"a", "b"})
+	assert.True(t, errors.IsValidationError(err))
+	assert.Contains(t, err.Error(), "invalid arguments\n• b: unexpected argument\nusage: create-project [--dry-run]")
+
+	help := command.FormatCommandHelp(cmd)
+	assert.Contains(t, help, "--description  Project description")
+	assert.Contains(t, help, "name           Project name")
+}
@@ -0,0 +1,156 @@
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dfaile/backstage-nobl9/internal/audit"
+	"github.com/dfaile/backstage-nobl9/internal/errors"
+)
+
+// DefaultAuditPageSize is how many audit records the audit command shows per page
+const DefaultAuditPageSize = 20
+
+// auditPage is the JSON output of the audit command
+type auditPage struct {
+	Page    int            `json:"page"`
+	Pages   int            `json:"pages"`
+	Total   int            `json:"total"`
+	Records []audit.Record `json:"records"`
+}
+
+// AuditSpec declares the flags of the audit command
+var AuditSpec = &Spec{
+	Flags: []Flag{
+		{Name: "project", Placeholder: "name", Description: "Changes to objects in the project"},
+		{Name: "user", Placeholder: "email", Description: "Role bindings for users whose email contains the value"},
+		{Name: "actor", Placeholder: "id", Description: "Callers whose identity, email or name contains the value"},
+		{Name: "action", Placeholder: "command", Description: "The command that made the change"},
+		{Name: "outcome", Choices: []string{string(audit.OutcomeSucceeded), string(audit.OutcomeFailed), string(audit.OutcomeAttempted), string(audit.OutcomeDenied)}},
+		{Name: "since", Placeholder: "time", Description: "A date, an RFC 3339 timestamp or an age such as 7d"},
+		{Name: "until", Placeholder: "time"},
+		{Name: "page", Placeholder: "n", Type: TypeInt, Default: "1"},
+		{Name: "limit", Placeholder: "n", Type: TypeInt, Default: strconv.Itoa(DefaultAuditPageSize), Description: "Records per page"},
+		{Name: "format", Choices: []string{"text", "json"}, Default: "text"},
+	},
+}
+
+// AuditCommand searches the audit log, newest first
+func AuditCommand(b BotCommander, args []string) (string, error) {
+	parsed, err := parseArgs(AuditSpec, AuditSpec.Usage("audit"), args)
+	if err != nil {
+		return "", err
+	}
+
+	query := audit.Query{
+		Project: parsed.String("project"),
+		User:    parsed.String("user"),
+		Actor:   parsed.String("actor"),
+		Action:  parsed.String("action"),
+		Outcome: audit.Outcome(parsed.String("outcome")),
+	}
+	since, until := parsed.String("since"), parsed.String("until")
+	page, limit, format := parsed.Int("page"), parsed.Int("limit"), parsed.String("format")
+	if page < 1 {
+		return "", errors.NewValidationError("--page must be a positive number", nil)
+	}
+	if limit < 1 {
+		return "", errors.NewValidationError("--limit must be a positive number", nil)
+	}
+
+	now := time.Now()
+	if since != "" {
+		t, err := audit.ParseTime(since, now)
+		if err != nil {
+			return "", errors.NewValidationError(err.Error(), nil)
+		}
+		query.Since = t
+	}
+	if until != "" {
+		t, err := audit.ParseTime(until, now)
+		if err != nil {
+			return "", errors.NewValidationError(err.Error(), nil)
+		}
+		query.Until = t
+	}
+
+	records, err := b.SearchAudit(query)
+	if err != nil {
+		return "", err
+	}
+
+	pages := (len(records) + limit - 1) / limit
+	start := (page - 1) * limit
+	end := start + limit
+	if start > len(records) {
+		start = len(records)
+	}
+	if end > len(records) {
+		end = len(records)
+	}
+	current := records[start:end]
+	if current == nil {
+		current = []audit.Record{}
+	}
+
+	if format == "json" {
+		data, err := json.MarshalIndent(auditPage{Page: page, Pages: pages, Total: len(records), Records: current}, "", "  ")
+		if err != nil {
+			return "", errors.NewInternalError("failed to encode audit records", err)
+		}
+		return string(data), nil
+	}
+
+	if len(records) == 0 {
+		return "🔍 No audit records match.", nil
+	}
+	if len(current) == 0 {
+		return fmt.Sprintf("🔍 Page %d is past the end, there are %d page(s).", page, pages), nil
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("🔍 %d audit record(s), newest first:\n\n", len(records)))
+	for _, rec := range current {
+		sb.WriteString(formatAuditRecord(rec))
+		sb.WriteString("\n")
+	}
+	sb.WriteString(fmt.Sprintf("\nPage %d of %d.", page, pages))
+	if page < pages {
+		sb.WriteString(fmt.Sprintf(" Repeat the command with --page %d for more.", page+1))
+	}
+	return sb.String(), nil
+}
+
+// formatAuditRecord renders a record as a headline followed by one line per object
+func formatAuditRecord(rec audit.Record) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("#%d %s %s ran %s: %s", rec.Seq, rec.Time.UTC().Format("2006-01-02 15:04:05 UTC"), rec.Caller, rec.Command, rec.Outcome))
+	if rec.Caller.Identity() != "" && rec.Caller.String() != rec.Caller.Identity() {
+		sb.WriteString(fmt.Sprintf(" (%s)", rec.Caller.Identity()))
+	}
+	sb.WriteString("\n")
+	if rec.Summary != "" {
+		sb.WriteString(fmt.Sprintf("   %s\n", rec.Summary))
+	}
+	for _, obj := range rec.Objects {
+		switch {
+		case obj.Role != "":
+			sb.WriteString(fmt.Sprintf("   • %s %s: %s for %s in project %s\n", obj.Kind, obj.Name, obj.Role, obj.User, obj.Project))
+		default:
+			sb.WriteString(fmt.Sprintf("   • %s %s\n", obj.Kind, obj.Name))
+		}
+	}
+	if rec.Branch != "" {
+		sb.WriteString(fmt.Sprintf("   Committed to branch %s\n", rec.Branch))
+	}
+	if rec.Error != "" {
+		sb.WriteString(fmt.Sprintf("   Error: %s\n", rec.Error))
+	}
+	if rec.RequestID != "" {
+		sb.WriteString(fmt.Sprintf("   Request ID: %s\n", rec.RequestID))
+	}
+	return sb.String()
+}
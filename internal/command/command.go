@@ -6,6 +6,8 @@ import (
 	"text/tabwriter"
 	"time"
 
+	"github.com/dfaile/backstage-nobl9/internal/audit"
+	"github.com/dfaile/backstage-nobl9/internal/authz"
 	"github.com/dfaile/backstage-nobl9/internal/errors"
 )
 
@@ -24,8 +26,16 @@ type BotCommander interface {
 	StartConversation(projectName string) error
 	StartRoleAssignment() error  // New method for starting role assignment flow
 	ValidateUser(email string) (bool, error)
-	AssignRoles(project string, users []string) error
+	AssignRoles(project string, users []string) (string, error) // Returns the GitOps branch, if any
 	ListProjects() ([]*Project, error)  // New method for listing projects
+	ExportProject(name string, includeSLOs bool, format string) (string, error)
+	SearchAudit(query audit.Query) ([]audit.Record, error) // Matching audit records, newest first
+	ListContexts() ([]Nobl9Context, error)
+	UseContext(name string) error // Switches the conversation to another sloctl context
+	CurrentContext() (Nobl9Context, error)
+	LogLevels() (string, map[string]string) // Default level and per-package overrides
+	SetLogLevel(pkg, level string) error    // An empty package sets the default level
+	ResetLogLevel(pkg string) error
 }
 
 // Command represents a bot command
@@ -33,9 +43,11 @@ type Command struct {
 	Name        string
 	Aliases     []string
 	Description string
-	Usage       string
+	Usage       string // Generated from Spec when empty
 	Handler     func(BotCommander, []string) (string, error)
 	Validate    func([]string) error
+	Spec        *Spec              // Arguments and flags, checked before the command runs
+	Permissions []authz.Permission // Required to run the command, none means anyone may
 }
 
 // CommandRegistry manages available commands
@@ -52,14 +64,32 @@ func NewCommandRegistry() *CommandRegistry {
 	}
 }
 
-// Register adds a command to the registry
+// Register adds a command to the registry, generating its usage from its spec
+// if it has none
 func (r *CommandRegistry) Register(cmd *Command) {
+	if cmd.Usage == "" && cmd.Spec != nil {
+		cmd.Usage = cmd.Spec.Usage(cmd.Name)
+	}
 	r.commands[cmd.Name] = cmd
 	for _, alias := range cmd.Aliases {
 		r.aliases[alias] = cmd
 	}
 }
 
+// Unregister removes a command and its aliases from the registry
+func (r *CommandRegistry) Unregister(name string) {
+	cmd, ok := r.commands[name]
+	if !ok {
+		return
+	}
+	delete(r.commands, name)
+	for _, alias := range cmd.Aliases {
+		if r.aliases[alias] == cmd {
+			delete(r.aliases, alias)
+		}
+	}
+}
+
 // Get retrieves a command by name or alias
 func (r *CommandRegistry) Get(name string) (*Command, bool) {
 	if cmd, ok := r.commands[name]; ok {
@@ -111,6 +141,18 @@ func FormatCommandHelp(cmd *Command) string {
 	if cmd.Usage != "" {
 		fmt.Fprintf(w, "Usage: %s\n", cmd.Usage)
 	}
+	if cmd.Spec != nil {
+		for _, arg := range cmd.Spec.Args {
+			if arg.Description != "" {
+				fmt.Fprintf(w, "  %s\t%s\n", arg.Name, arg.Description)
+			}
+		}
+		for _, flag := range cmd.Spec.Flags {
+			if flag.Description != "" {
+				fmt.Fprintf(w, "  --%s\t%s\n", flag.Name, flag.Description)
+			}
+		}
+	}
 	w.Flush()
 
 	return sb.String()
@@ -169,10 +211,13 @@ func AssignRoleCommand(b BotCommander, args []string) (string, error) {
 		}
 
 		// Assign role
-		err = b.AssignRoles(project, []string{user})
+		branch, err := b.AssignRoles(project, []string{user})
 		if err != nil {
 			return "", err
 		}
+		if branch != "" {
+			return fmt.Sprintf("🔀 Role change for user %s in project '%s' committed to branch '%s' for review", user, project, branch), nil
+		}
 
 		return fmt.Sprintf("✅ Assigned roles in project '%s' for user: %s", project, user), nil
 	}
@@ -211,6 +256,33 @@ func ListProjectsCommand(b BotCommander, args []string) (string, error) {
 	return sb.String(), nil
 }
 
+// ExportProjectSpec declares the arguments of the export-project command
+var ExportProjectSpec = &Spec{
+	Args: []Arg{
+		{Name: "name", Description: "Project to export", Required: true},
+	},
+	Flags: []Flag{
+		{Name: "format", Description: "Manifest format", Choices: []string{"yaml", "json"}, Default: "yaml"},
+		{Name: "with-slos", Description: "Include the project's services and SLOs", Type: TypeBool},
+	},
+}
+
+// ExportProjectCommand exports a project and its role bindings as a manifest bundle
+func ExportProjectCommand(b BotCommander, args []string) (string, error) {
+	parsed, err := parseArgs(ExportProjectSpec, ExportProjectSpec.Usage("export-project"), args)
+	if err != nil {
+		return "", err
+	}
+	name, format := parsed.String("name"), parsed.String("format")
+
+	bundle, err := b.ExportProject(name, parsed.Bool("with-slos"), format)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("📦 Manifest for project '%s' (apply with `sloctl apply -f <file>`):\n\n```%s\n%s```", name, format, bundle), nil
+}
+
 // DefaultCommand handles unrecognized commands
 func DefaultCommand(b BotCommander, args []string) (string, error) {
 	return "❌ Error: unknown command. Type 'help' for available commands.", nil
@@ -223,7 +295,10 @@ func ParseCommand(input string) (*Command, []string, error) {
 		return nil, nil, nil
 	}
 
-	fields := strings.Fields(input)
+	fields, err := Split(input)
+	if err != nil {
+		return nil, nil, err
+	}
 	if len(fields) == 0 {
 		return nil, nil, errors.NewValidationError("invalid command format", nil)
 	}
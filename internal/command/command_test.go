@@ -5,375 +5,181 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
-	"github.com/dfaile/backstage-nobl9/internal/bot"
+
+	"github.com/dfaile/backstage-nobl9/internal/command"
 	"github.com/dfaile/backstage-nobl9/internal/errors"
-	"github.com/dfaile/backstage-nobl9/internal/nobl9"
 )
 
-func TestCommandRegistry(t *testing.T) {
-	registry := NewCommandRegistry()
+// fakeBot records the calls commands make. Methods a test does not expect panic
+// through the nil embedded BotCommander.
+type fakeBot struct {
+	command.BotCommander
+	registry *command.CommandRegistry
+	users    map[string]bool
+	projects []*command.Project
+	branch   string
+
+	started  string
+	assigned []string
+}
 
-	// Test empty registry
-	if len(registry.List()) != 0 {
-		t.Error("Expected empty registry")
-	}
+func (b *fakeBot) Commands() *command.CommandRegistry {
+	return b.registry
+}
 
-	// Test command registration
-	cmd := &Command{
-		Name:        "test",
-		Description: "Test command",
-		Handler:     func(*bot.Bot, string) (string, error) { return "test", nil },
-	}
-	registry.Register(cmd)
+func (b *fakeBot) StartConversation(projectName string) error {
+	b.started = projectName
+	return nil
+}
 
-	// Test command retrieval
-	if got, ok := registry.Get("test"); !ok || got != cmd {
-		t.Error("Failed to retrieve registered command")
-	}
+func (b *fakeBot) StartRoleAssignment() error {
+	return nil
+}
 
-	// Test command listing
-	cmds := registry.List()
-	if len(cmds) != 1 || cmds[0] != cmd {
-		t.Error("Failed to list registered commands")
-	}
+func (b *fakeBot) ValidateUser(email string) (bool, error) {
+	return b.users[email], nil
 }
 
-func TestHelpCommand(t *testing.T) {
-	b := bot.NewBot(nil, nil)
-	registry := NewCommandRegistry()
-	registry.Register(&Command{
-		Name:        "test",
-		Description: "Test command",
-		Handler:     func(*bot.Bot, string) (string, error) { return "test", nil },
-	})
-	b.SetCommands(registry)
-
-	response, err := HelpCommand(b, "")
-	if err != nil {
-		t.Errorf("HelpCommand failed: %v", err)
-	}
-	if response == "" {
-		t.Error("Expected non-empty help response")
-	}
+func (b *fakeBot) AssignRoles(project string, users []string) (string, error) {
+	b.assigned = append(b.assigned, users...)
+	return b.branch, nil
 }
 
-func TestCreateProjectCommand(t *testing.T) {
-	b := bot.NewBot(nil, nil)
+func (b *fakeBot) ListProjects() ([]*command.Project, error) {
+	return b.projects, nil
+}
 
-	// Test missing project name
-	_, err := CreateProjectCommand(b, "")
-	if !errors.IsValidationError(err) {
-		t.Error("Expected validation error for missing project name")
-	}
+func TestCommandRegistry(t *testing.T) {
+	registry := command.NewCommandRegistry()
+	assert.Empty(t, registry.List())
 
-	// Test valid project creation
-	response, err := CreateProjectCommand(b, "test-project")
-	if err != nil {
-		t.Errorf("CreateProjectCommand failed: %v", err)
-	}
-	if response == "" {
-		t.Error("Expected non-empty response")
+	cmd := &command.Command{
+		Name:        "export-project",
+		Aliases:     []string{"export"},
+		Description: "Export a project",
+		Spec:        command.ExportProjectSpec,
 	}
+	registry.Register(cmd)
+	assert.Equal(t, "export-project [--format yaml|json] [--with-slos] <name>", cmd.Usage)
+
+	got, ok := registry.Get("export-project")
+	assert.True(t, ok)
+	assert.Same(t, cmd, got)
+	got, ok = registry.Get("export")
+	assert.True(t, ok)
+	assert.Same(t, cmd, got)
+	assert.Equal(t, []*command.Command{cmd}, registry.List())
+
+	registry.Unregister("export-project")
+	_, ok = registry.Get("export")
+	assert.False(t, ok)
+	assert.Empty(t, registry.List())
 }
 
-func TestAssignRoleCommand(t *testing.T) {
-	mockClient := &mockNobl9Client{}
-	b := bot.NewBot(mockClient, nil)
+func TestHelpCommand(t *testing.T) {
+	registry := command.NewCommandRegistry()
+	registry.Register(&command.Command{Name: "list-projects", Aliases: []string{"projects"}, Description: "List projects"})
+	b := &fakeBot{registry: registry}
+
+	response, err := command.HelpCommand(b, nil)
+	require.NoError(t, err)
+	assert.Contains(t, response, "/list-projects")
+	assert.Contains(t, response, "List projects")
+
+	response, err = command.HelpCommand(b, []string{"projects"})
+	require.NoError(t, err)
+	assert.Contains(t, response, "Command: /list-projects")
+	assert.Contains(t, response, "Aliases: projects")
+
+	_, err = command.HelpCommand(b, []string{"unknown"})
+	assert.True(t, errors.IsValidationError(err))
+}
 
-	// Test invalid arguments
-	_, err := AssignRoleCommand(b, "project")
-	if !errors.IsValidationError(err) {
-		t.Error("Expected validation error for invalid arguments")
-	}
+func TestCreateProjectCommand(t *testing.T) {
+	b := &fakeBot{}
 
-	// Test user not found
-	_, err = AssignRoleCommand(b, "project nonexistent-user")
-	if !errors.IsNotFoundError(err) {
-		t.Error("Expected not found error for nonexistent user")
-	}
+	_, err := command.CreateProjectCommand(b, nil)
+	assert.True(t, errors.IsValidationError(err))
 
-	// Test successful role assignment
-	response, err := AssignRoleCommand(b, "project test-user")
-	if err != nil {
-		t.Errorf("AssignRoleCommand failed: %v", err)
-	}
-	if response == "" {
-		t.Error("Expected non-empty response")
-	}
+	response, err := command.CreateProjectCommand(b, []string{"payments-api"})
+	require.NoError(t, err)
+	assert.Equal(t, "payments-api", b.started)
+	assert.Contains(t, response, "'payments-api'")
 }
 
-func TestDefaultCommand(t *testing.T) {
-	b := bot.NewBot(nil, nil)
-	response, err := DefaultCommand(b, "")
-	if err != nil {
-		t.Errorf("DefaultCommand failed: %v", err)
-	}
-	if response == "" {
-		t.Error("Expected non-empty response")
-	}
-}
+func TestAssignRoleCommand(t *testing.T) {
+	b := &fakeBot{users: map[string]bool{"jane@example.com": true}}
 
-// mockNobl9Client implements nobl9.Client for testing
-type mockNobl9Client struct{}
+	_, err := command.AssignRoleCommand(b, []string{"payments-api"})
+	assert.True(t, errors.IsValidationError(err))
 
-func (m *mockNobl9Client) GetProject(name string) (*nobl9.Project, error) {
-	return nil, nil
-}
+	_, err = command.AssignRoleCommand(b, []string{"payments-api", "nobody@example.com"})
+	assert.True(t, errors.IsNotFoundError(err))
+	assert.Empty(t, b.assigned)
 
-func (m *mockNobl9Client) CreateProject(name, description string) (*nobl9.Project, error) {
-	return nil, nil
-}
+	response, err := command.AssignRoleCommand(b, []string{"payments-api", "jane@example.com"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"jane@example.com"}, b.assigned)
+	assert.Equal(t, "✅ Assigned roles in project 'payments-api' for user: jane@example.com", response)
 
-func (m *mockNobl9Client) ValidateProjectName(name string) (bool, error) {
-	return true, nil
-}
+	b.branch = "nobl9-bot/payments-api"
+	response, err = command.AssignRoleCommand(b, []string{"payments-api", "jane@example.com"})
+	require.NoError(t, err)
+	assert.Contains(t, response, "committed to branch 'nobl9-bot/payments-api'")
 
-func (m *mockNobl9Client) ValidateUser(email string) (bool, error) {
-	if email == "nonexistent-user" {
-		return false, nil
-	}
-	return true, nil
+	response, err = command.AssignRoleCommand(b, nil)
+	require.NoError(t, err)
+	assert.NotEmpty(t, response)
 }
 
-func (m *mockNobl9Client) AssignRoles(project string, users []string) error {
-	return nil
+func TestListProjectsCommand(t *testing.T) {
+	b := &fakeBot{}
+	response, err := command.ListProjectsCommand(b, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "📁 No projects found in your organization.", response)
+
+	b.projects = []*command.Project{{Name: "payments-api", Description: "Payments"}, {Name: "search"}}
+	response, err = command.ListProjectsCommand(b, nil)
+	require.NoError(t, err)
+	assert.Contains(t, response, "Found 2 project(s)")
+	assert.Contains(t, response, "**payments-api** - Payments")
+	assert.Contains(t, response, "**search**")
 }
 
 func TestParseCommand(t *testing.T) {
 	tests := []struct {
-		name     string
-		input    string
-		expected *Command
-		err      bool
+		name  string
+		input string
+		cmd   string
+		args  []string
 	}{
-		{
-			name:  "Create command",
-			input: "/create test-project",
-			expected: &Command{
-				Name: "create",
-				Args: []string{"test-project"},
-			},
-			err: false,
-		},
-		{
-			name:  "Assign command",
-			input: "/assign test-project user@example.com",
-			expected: &Command{
-				Name: "assign",
-				Args: []string{"test-project", "user@example.com"},
-			},
-			err: false,
-		},
-		{
-			name:  "List command",
-			input: "/list",
-			expected: &Command{
-				Name: "list",
-				Args: []string{},
-			},
-			err: false,
-		},
-		{
-			name:  "Help command",
-			input: "/help",
-			expected: &Command{
-				Name: "help",
-				Args: []string{},
-			},
-			err: false,
-		},
-		{
-			name:  "Invalid command",
-			input: "not a command",
-			expected: nil,
-			err: true,
-		},
-		{
-			name:  "Empty command",
-			input: "",
-			expected: nil,
-			err: true,
-		},
+		{name: "command with arguments", input: "/assign-role payments-api jane@example.com", cmd: "assign-role", args: []string{"payments-api", "jane@example.com"}},
+		{name: "quoted argument", input: `/create-project "payments api"`, cmd: "create-project", args: []string{"payments api"}},
+		{name: "no arguments", input: "  /help  ", cmd: "help", args: []string{}},
+		{name: "not a command", input: "not a command"},
+		{name: "empty", input: ""},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			cmd, err := ParseCommand(tt.input)
-			if tt.err {
-				assert.Error(t, err)
+			cmd, args, err := command.ParseCommand(tt.input)
+			require.NoError(t, err)
+			if tt.cmd == "" {
 				assert.Nil(t, cmd)
-			} else {
-				require.NoError(t, err)
-				assert.Equal(t, tt.expected.Name, cmd.Name)
-				assert.Equal(t, tt.expected.Args, cmd.Args)
+				return
 			}
+			require.NotNil(t, cmd)
+			assert.Equal(t, tt.cmd, cmd.Name)
+			assert.Equal(t, tt.args, args)
 		})
 	}
-}
-
-func TestValidateCommand(t *testing.T) {
-	tests := []struct {
-		name     string
-		cmd      *Command
-		expected error
-	}{
-		{
-			name: "Valid create command",
-			cmd: &Command{
-				Name: "create",
-				Args: []string{"test-project"},
-			},
-			expected: nil,
-		},
-		{
-			name: "Invalid create command - missing args",
-			cmd: &Command{
-				Name: "create",
-				Args: []string{},
-			},
-			expected: ErrMissingArgs,
-		},
-		{
-			name: "Valid assign command",
-			cmd: &Command{
-				Name: "assign",
-				Args: []string{"test-project", "user@example.com"},
-			},
-			expected: nil,
-		},
-		{
-			name: "Invalid assign command - missing args",
-			cmd: &Command{
-				Name: "assign",
-				Args: []string{"test-project"},
-			},
-			expected: ErrMissingArgs,
-		},
-		{
-			name: "Valid list command",
-			cmd: &Command{
-				Name: "list",
-				Args: []string{},
-			},
-			expected: nil,
-		},
-		{
-			name: "Valid help command",
-			cmd: &Command{
-				Name: "help",
-				Args: []string{},
-			},
-			expected: nil,
-		},
-		{
-			name: "Invalid command name",
-			cmd: &Command{
-				Name: "invalid",
-				Args: []string{},
-			},
-			expected: ErrUnknownCommand,
-		},
-	}
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			err := ValidateCommand(tt.cmd)
-			if tt.expected == nil {
-				assert.NoError(t, err)
-			} else {
-				assert.Error(t, err)
-				assert.Equal(t, tt.expected, err)
-			}
-		})
-	}
+	_, _, err := command.ParseCommand(`/create-project "payments`)
+	assert.Error(t, err)
 }
 
-func TestFormatResponse(t *testing.T) {
-	tests := []struct {
-		name     string
-		response *Response
-		expected string
-	}{
-		{
-			name: "Success response",
-			response: &Response{
-				Type:    ResponseTypeSuccess,
-				Message: "Project created successfully",
-			},
-			expected: "✅ Project created successfully",
-		},
-		{
-			name: "Error response",
-			response: &Response{
-				Type:    ResponseTypeError,
-				Message: "Project already exists",
-			},
-			expected: "❌ Project already exists",
-		},
-		{
-			name: "Info response",
-			response: &Response{
-				Type:    ResponseTypeInfo,
-				Message: "Please provide a display name",
-			},
-			expected: "ℹ️ Please provide a display name",
-		},
-		{
-			name: "Warning response",
-			response: &Response{
-				Type:    ResponseTypeWarning,
-				Message: "Invalid project name",
-			},
-			expected: "⚠️ Invalid project name",
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			formatted := FormatResponse(tt.response)
-			assert.Equal(t, tt.expected, formatted)
-		})
-	}
-}
-
-func TestFormatList(t *testing.T) {
-	tests := []struct {
-		name     string
-		items    []string
-		expected string
-	}{
-		{
-			name:     "Empty list",
-			items:    []string{},
-			expected: "No items found",
-		},
-		{
-			name:     "Single item",
-			items:    []string{"item1"},
-			expected: "• item1",
-		},
-		{
-			name:     "Multiple items",
-			items:    []string{"item1", "item2", "item3"},
-			expected: "• item1\n• item2\n• item3",
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			formatted := FormatList(tt.items)
-			assert.Equal(t, tt.expected, formatted)
-		})
-	}
+func TestDefaultCommand(t *testing.T) {
+	response, err := command.DefaultCommand(&fakeBot{}, nil)
+	require.NoError(t, err)
+	assert.Contains(t, response, "unknown command")
 }
-
-func TestFormatHelp(t *testing.T) {
-	expected := `Available commands:
-• /create <project-name> - Create a new project
-• /assign <project-name> <user-email> - Assign roles to a user
-• /list - List all projects
-• /help - Show this help message`
-
-	formatted := FormatHelp()
-	assert.Equal(t, expected, formatted)
-} 
\ No newline at end of file
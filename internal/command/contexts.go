@@ -0,0 +1,88 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/dfaile/backstage-nobl9/internal/errors"
+)
+
+// Nobl9Context describes a context from the sloctl config.toml
+type Nobl9Context struct {
+	Name         string `json:"name"`
+	Organization string `json:"organization,omitempty"`
+	URL          string `json:"url,omitempty"`
+	Current      bool   `json:"current"` // The conversation uses this context
+	Default      bool   `json:"default"` // The file's defaultContext
+}
+
+// ContextsCommand lists the Nobl9 contexts the bot can switch between
+func ContextsCommand(b BotCommander, args []string) (string, error) {
+	if len(args) != 0 {
+		return "", errors.NewValidationError("usage: contexts", nil)
+	}
+
+	contexts, err := b.ListContexts()
+	if err != nil {
+		return "", err
+	}
+	if len(contexts) == 0 {
+		return "📭 No Nobl9 contexts are configured.", nil
+	}
+
+	var sb strings.Builder
+	w := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CURRENT\tNAME\tORGANIZATION\tURL")
+	for _, context := range contexts {
+		marker := ""
+		if context.Current {
+			marker = "*"
+		}
+		name := context.Name
+		if context.Default {
+			name += " (default)"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", marker, name, context.Organization, context.URL)
+	}
+	w.Flush()
+
+	return strings.TrimRight(sb.String(), "\n"), nil
+}
+
+// UseContextCommand switches the conversation to another Nobl9 context
+func UseContextCommand(b BotCommander, args []string) (string, error) {
+	if len(args) != 1 {
+		return "", errors.NewValidationError("usage: use-context <name>", nil)
+	}
+
+	if err := b.UseContext(args[0]); err != nil {
+		return "", err
+	}
+	context, err := b.CurrentContext()
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("🔀 Switched to context '%s'%s", context.Name, describeOrganization(context)), nil
+}
+
+// CurrentContextCommand shows the Nobl9 context the conversation uses
+func CurrentContextCommand(b BotCommander, args []string) (string, error) {
+	if len(args) != 0 {
+		return "", errors.NewValidationError("usage: current-context", nil)
+	}
+
+	context, err := b.CurrentContext()
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("📍 Current context: %s%s", context.Name, describeOrganization(context)), nil
+}
+
+// describeOrganization names the context's organization, if known
+func describeOrganization(context Nobl9Context) string {
+	if context.Organization == "" {
+		return ""
+	}
+	return fmt.Sprintf(" (organization %s)", context.Organization)
+}
@@ -0,0 +1,53 @@
+package command
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/dfaile/backstage-nobl9/internal/errors"
+)
+
+// LogLevelCommand shows the log levels, or changes them while the bot runs.
+// With one argument it sets the default level, with two the level of a package,
+// such as bot or nobl9.
+func LogLevelCommand(b BotCommander, args []string) (string, error) {
+	usage := "usage: log-level [<level>] | log-level <package> <level|reset>"
+
+	switch len(args) {
+	case 0:
+		level, packages := b.LogLevels()
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "📝 Log level: %s", level)
+		names := make([]string, 0, len(packages))
+		for pkg := range packages {
+			names = append(names, pkg)
+		}
+		sort.Strings(names)
+		for _, pkg := range names {
+			fmt.Fprintf(&sb, "\n• %s: %s", pkg, packages[pkg])
+		}
+		return sb.String(), nil
+	case 1:
+		level := strings.ToLower(args[0])
+		if err := b.SetLogLevel("", level); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("📝 Log level set to %s", level), nil
+	case 2:
+		pkg, level := args[0], strings.ToLower(args[1])
+		if level == "reset" {
+			if err := b.ResetLogLevel(pkg); err != nil {
+				return "", err
+			}
+			defaultLevel, _ := b.LogLevels()
+			return fmt.Sprintf("📝 Package %s logs at the default level (%s) again", pkg, defaultLevel), nil
+		}
+		if err := b.SetLogLevel(pkg, level); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("📝 Log level of package %s set to %s", pkg, level), nil
+	default:
+		return "", errors.NewValidationError(usage, nil)
+	}
+}
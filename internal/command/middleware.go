@@ -0,0 +1,88 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/dfaile/backstage-nobl9/internal/errors"
+	"github.com/dfaile/backstage-nobl9/internal/identity"
+	"github.com/dfaile/backstage-nobl9/internal/tracing"
+)
+
+// Request is a command on its way through the middleware chain
+type Request struct {
+	Context context.Context
+	Command *Command
+	Args    []string
+	Caller  identity.Caller
+	Step    bool // Answers a prompt of the command's wizard instead of starting the command
+}
+
+// HandlerFunc runs a command request and returns the response for the user
+type HandlerFunc func(*Request) (string, error)
+
+// Middleware wraps a handler with a concern shared by every command, such as
+// authorization or metrics
+type Middleware func(HandlerFunc) HandlerFunc
+
+// Chain wraps handler in middlewares. The first middleware is the outermost, so
+// it sees the request first and the response last.
+func Chain(handler HandlerFunc, middlewares ...Middleware) HandlerFunc {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+	return handler
+}
+
+// Recover turns a panic in a handler into an internal error, so a bug in one
+// command does not take the bot down
+func Recover(onPanic func(req *Request, value interface{}, stack []byte)) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(req *Request) (response string, err error) {
+			defer func() {
+				if value := recover(); value != nil {
+					if onPanic != nil {
+						onPanic(req, value, debug.Stack())
+					}
+					response = ""
+					err = errors.NewInternalError(fmt.Sprintf("command '%s' failed unexpectedly", req.Command.Name), fmt.Errorf("panic: %v", value))
+				}
+			}()
+			return next(req)
+		}
+	}
+}
+
+// Validate checks the arguments against the command's spec and with its Validate
+// function, if it has them
+func Validate() Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(req *Request) (string, error) {
+			if _, err := req.Command.ParseArgs(req.Args); err != nil {
+				return "", err
+			}
+			if req.Command.Validate != nil {
+				if err := req.Command.Validate(req.Args); err != nil {
+					return "", err
+				}
+			}
+			return next(req)
+		}
+	}
+}
+
+// Trace runs each command in a bot.command span
+func Trace() Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(req *Request) (string, error) {
+			ctx, span := tracing.Start(req.Context, "bot.command", attribute.String("bot.command", req.Command.Name))
+			req.Context = ctx
+			response, err := next(req)
+			tracing.End(span, err)
+			return response, err
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package command_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+
+	"github.com/dfaile/backstage-nobl9/internal/command"
+	"github.com/dfaile/backstage-nobl9/internal/errors"
+	"github.com/dfaile/backstage-nobl9/internal/tracing/tracingtest"
+)
+
+func TestChainOrder(t *testing.T) {
+	var calls []string
+	named := func(name string) command.Middleware {
+		return func(next command.HandlerFunc) command.HandlerFunc {
+			return func(req *command.Request) (string, error) {
+				calls = append(calls, name+" before")
+				response, err := next(req)
+				calls = append(calls, name+" after")
+				return response, err
+			}
+		}
+	}
+
+	handler := command.Chain(func(req *command.Request) (string, error) {
+		calls = append(calls, "handler")
+		return "done", nil
+	}, named("outer"), named("inner"))
+
+	response, err := handler(&command.Request{Context: context.Background(), Command: &command.Command{Name: "help"}})
+	require.NoError(t, err)
+	assert.Equal(t, "done", response)
+	assert.Equal(t, []string{"outer before", "inner before", "handler", "inner after", "outer after"}, calls)
+}
+
+func TestValidate(t *testing.T) {
+	ran := false
+	handler := command.Chain(func(req *command.Request) (string, error) {
+		ran = true
+		return "", nil
+	}, command.Validate())
+
+	cmd := &command.Command{
+		Name: "approve",
+		Validate: func(args []string) error {
+			if len(args) != 1 {
+				return errors.NewValidationError("usage: approve <id>", nil)
+			}
+			return nil
+		},
+	}
+	_, err := handler(&command.Request{Context: context.Background(), Command: cmd})
+	assert.True(t, errors.IsValidationError(err))
+	assert.False(t, ran)
+
+	_, err = handler(&command.Request{Context: context.Background(), Command: cmd, Args: []string{"42"}})
+	require.NoError(t, err)
+	assert.True(t, ran)
+
+	// Commands without a Validate function are not checked
+	_, err = handler(&command.Request{Context: context.Background(), Command: &command.Command{Name: "help"}, Args: []string{"a", "b"}})
+	assert.NoError(t, err)
+}
+
+func TestRecover(t *testing.T) {
+	var recovered interface{}
+	handler := command.Chain(func(req *command.Request) (string, error) {
+		panic("nil map")
+	}, command.Recover(func(req *command.Request, value interface{}, stack []byte) {
+		recovered = value
+		assert.NotEmpty(t, stack)
+	}))
+
+	response, err := handler(&command.Request{Context: context.Background(), Command: &command.Command{Name: "apply"}})
+	assert.Empty(t, response)
+	assert.True(t, errors.IsInternalError(err))
+	assert.Contains(t, err.Error(), "command 'apply' failed unexpectedly")
+	assert.Equal(t, "nil map", recovered)
+}
+
+func TestTrace(t *testing.T) {
+	recorder := tracingtest.NewRecorder()
+
+	handler := command.Chain(func(req *command.Request) (string, error) {
+		return "", errors.NewNotFoundError("project not found", nil)
+	}, command.Trace())
+	_, err := handler(&command.Request{Context: context.Background(), Command: &command.Command{Name: "export-project"}})
+	require.Error(t, err)
+
+	span, ok := recorder.Span("bot.command")
+	require.True(t, ok)
+	assert.Contains(t, span.Attributes, attribute.String("bot.command", "export-project"))
+	assert.Equal(t, codes.Error, span.Status.Code)
+}
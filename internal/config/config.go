@@ -1,67 +1,457 @@
 package config
 
 import (
-	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+
+	"github.com/dfaile/backstage-nobl9/internal/errors"
+	"github.com/dfaile/backstage-nobl9/internal/logging"
+	"github.com/dfaile/backstage-nobl9/internal/tracing"
 )
 
-// Config represents the Nobl9 configuration following SDK conventions
+// Config represents the bot configuration. It is loaded in layers: defaults, then a
+// YAML, TOML or JSON file, then environment variables, then command line flags.
 type Config struct {
-	ClientID     string `json:"client_id"`
-	ClientSecret string `json:"client_secret"`
-	Organization string `json:"organization"`
-	URL          string `json:"url"` // Changed from BaseURL to URL to match SDK
+	// Nobl9 holds the API credentials, taken from the SDK's own config when empty
+	Nobl9 Nobl9Config `json:"nobl9,omitempty"`
+	// RateLimits throttles API calls and controls how rate limited calls are retried
+	RateLimits RateLimitConfig `json:"rate_limits,omitempty"`
+	// Retry holds the retry policies for other transient errors, keyed by error kind
+	Retry map[string]RetryPolicy `json:"retry,omitempty"`
+	// HelpResources links users to documentation from help and error messages
+	HelpResources HelpResources `json:"help_resources,omitempty"`
+	// RoleMappings lists the roles users can pick and the Nobl9 project role each grants
+	RoleMappings []RoleMapping `json:"role_mappings,omitempty"`
+	// Frontend customizes the interactive CLI
+	Frontend FrontendConfig `json:"frontend,omitempty"`
+	// Logging configures the bot's own logs
+	Logging LoggingConfig `json:"logging,omitempty"`
+	// Tracing exports OpenTelemetry traces of message handling and Nobl9 calls
+	Tracing TracingConfig `json:"tracing,omitempty"`
+
+	// ProjectLabels lists the labels the create-project wizard asks for
+	ProjectLabels []LabelPolicy `json:"project_labels,omitempty"`
+	// TemplatesDir is the directory holding create-project templates
+	TemplatesDir string `json:"templates_dir,omitempty" path:"true"`
+	// NamingPolicy holds the org's project naming conventions
+	NamingPolicy NamingPolicy `json:"naming_policy,omitempty"`
+	// ApplyAllowedKinds lists the object kinds the apply command accepts
+	ApplyAllowedKinds []string `json:"apply_allowed_kinds,omitempty"`
+	// ApplyFilesDir is the only directory apply reads manifest files from, reading files is off when empty
+	ApplyFilesDir string `json:"apply_files_dir,omitempty" path:"true"`
+	// CLIEmail is the Nobl9 email of the person running the interactive CLI. The
+	// CLI cannot verify emails, so only whoever controls the config sets it.
+	CLIEmail string `json:"cli_email,omitempty"`
+	// GitOps commits changes to a git repository instead of applying them when RepoDir is set
+	GitOps GitOpsConfig `json:"gitops,omitempty"`
+	// Approvals lists the rules that send requests to the approval queue
+	Approvals ApprovalPolicy `json:"approvals,omitempty"`
+	// Authorization maps caller identities to bot roles and their permissions
+	Authorization AuthorizationPolicy `json:"authorization,omitempty"`
+	// Audit configures the append-only log of every mutation
+	Audit AuditConfig `json:"audit,omitempty"`
+	// Tenants serves several Nobl9 organizations from one bot
+	Tenants TenantsConfig `json:"tenants,omitempty"`
+	// Secrets configures the stores secret references are read from
+	Secrets SecretsConfig `json:"secrets,omitempty"`
+	// Plugins configures where plugin commands are found and how long they may run
+	Plugins PluginsConfig `json:"plugins,omitempty"`
+
+	// Deprecated: top-level credentials from older config files are moved into Nobl9 when loaded
+	ClientID     string `json:"client_id,omitempty"`
+	ClientSecret string `json:"client_secret,omitempty" secret:"true"`
+	Organization string `json:"organization,omitempty"`
+	URL          string `json:"url,omitempty"`
+
+	// Source is the file the config was loaded from, empty when no file was found
+	Source string `json:"-"`
 }
 
-// DefaultConfigPath returns the default path for the config file
-// Following Nobl9 SDK convention of ~/.nobl9/config.toml, but using JSON for simplicity
-func DefaultConfigPath() (string, error) {
-	homeDir, err := os.UserHomeDir()
+// Nobl9Config holds the Nobl9 API credentials. Settings left empty come from the
+// selected context of the sloctl config.toml. The client secret may be a secret
+// reference, see SecretsConfig.
+type Nobl9Config struct {
+	ClientID     string `json:"client_id,omitempty"`
+	ClientSecret string `json:"client_secret,omitempty" secret:"true"`
+	Organization string `json:"organization,omitempty"`
+	URL          string `json:"base_url,omitempty"`                // e.g. https://app.nobl9.com
+	Context      string `json:"context,omitempty"`                 // sloctl context, defaults to the file's defaultContext
+	ConfigFile   string `json:"config_file,omitempty" path:"true"` // sloctl config.toml, see ContextsPath
+}
+
+// RateLimitConfig throttles Nobl9 API calls and sets the backoff for rate limited calls
+type RateLimitConfig struct {
+	RequestsPerSecond int `json:"requests_per_second,omitempty"` // 0 disables client-side throttling
+	CommandsPerMinute int `json:"commands_per_minute,omitempty"` // Per caller, 0 allows any number
+	InitialDelay      int `json:"initial_delay,omitempty"`       // Milliseconds before the first retry
+	MaxDelay          int `json:"max_delay,omitempty"`           // Milliseconds, the delay doubles up to this
+	MaxRetries        int `json:"max_retries,omitempty"`
+}
+
+// RetryPolicy describes how an error kind is retried
+type RetryPolicy struct {
+	MaxAttempts int `json:"max_attempts"`        // 0 disables retries
+	Delay       int `json:"delay"`               // Milliseconds before the first retry
+	MaxDelay    int `json:"max_delay,omitempty"` // Milliseconds, the delay doubles up to this
+}
+
+// Error kinds that can have a retry policy
+const (
+	RetryTimeout  = "timeout"
+	RetryInternal = "internal"
+)
+
+// HelpResources links users to documentation
+type HelpResources struct {
+	UserSetup string `json:"user_setup,omitempty"` // Shown when a user cannot be found
+	ErrorHelp string `json:"error_help,omitempty"` // Shown with errors
+}
+
+// RoleMapping maps a role name users pick to the Nobl9 project role it grants
+type RoleMapping struct {
+	Name string `json:"name"`
+	Role string `json:"role"` // project-owner, project-editor or project-viewer
+}
+
+// ProjectRoles lists the Nobl9 project roles role mappings may grant
+var ProjectRoles = []string{"project-owner", "project-editor", "project-viewer"}
+
+// FrontendConfig customizes the interactive CLI
+type FrontendConfig struct {
+	Prompt  string `json:"prompt,omitempty"`  // Input prompt, defaults to "> "
+	Welcome string `json:"welcome,omitempty"` // Replaces the built-in welcome message
+}
+
+// LoggingConfig configures the bot's logs
+type LoggingConfig struct {
+	Level  string            `json:"level,omitempty"`              // debug, info, warn or error
+	Format string            `json:"format,omitempty"`             // json, console or pretty
+	Output string            `json:"output,omitempty" path:"true"` // stdout, stderr or a file path, replaced by Sinks when set
+	Sinks  []LogSinkConfig   `json:"sinks,omitempty"`              // Several destinations, each with its own format
+	Levels map[string]string `json:"levels,omitempty"`             // Package, such as bot or nobl9, to level
+	Fields LogFieldsConfig   `json:"fields,omitempty"`             // How personal data in log fields is logged
+}
+
+// LogSinkConfig describes one destination for the bot's logs
+type LogSinkConfig struct {
+	Type       string `json:"type"`                       // stdout, stderr, file or udp
+	Format     string `json:"format,omitempty"`           // Defaults to logging.format
+	Path       string `json:"path,omitempty" path:"true"` // File sinks
+	MaxBytes   int64  `json:"max_bytes,omitempty"`        // File sinks rotate at this size, 0 disables rotation
+	MaxBackups int    `json:"max_backups,omitempty"`      // Rotated files kept, 0 keeps them all
+	Address    string `json:"address,omitempty"`          // UDP sinks, e.g. 127.0.0.1:514
+}
+
+// Options returns the logger options the settings describe
+func (c LoggingConfig) Options() (logging.Options, error) {
+	packages := make(map[string]logging.Level, len(c.Levels))
+	for pkg, level := range c.Levels {
+		packages[pkg] = logging.Level(level)
+	}
+	levels, err := logging.NewLevels(logging.Level(c.Level), packages)
 	if err != nil {
-		return "", fmt.Errorf("failed to get user home directory: %w", err)
+		return logging.Options{}, err
+	}
+
+	sinks := make([]logging.Sink, 0, len(c.Sinks))
+	for _, sink := range c.Sinks {
+		sinks = append(sinks, logging.Sink{
+			Type:       sink.Type,
+			Format:     sink.Format,
+			Path:       sink.Path,
+			MaxBytes:   sink.MaxBytes,
+			MaxBackups: sink.MaxBackups,
+			Address:    sink.Address,
+		})
 	}
-	return filepath.Join(homeDir, ".nobl9", "config.json"), nil
+	return logging.Options{
+		Level:  logging.Level(c.Level),
+		Format: c.Format,
+		Output: c.Output,
+		Sinks:  sinks,
+		Levels: levels,
+		Fields: c.Fields.Policy(),
+	}, nil
 }
 
-// LoadConfig loads the configuration from the specified path or default location
-func LoadConfig(path string) (*Config, error) {
-	if path == "" {
-		var err error
-		path, err = DefaultConfigPath()
-		if err != nil {
-			return nil, err
+// LogFieldsConfig controls how personal data in log fields, such as emails and the
+// text users type, is logged. By default free text is truncated and the rest kept.
+// Fields are named by their log key, e.g. requester or description.
+type LogFieldsConfig struct {
+	Strict     bool     `json:"strict,omitempty"`      // Drop raw input, hash identities and emails, truncate free text
+	HashEmails bool     `json:"hash_emails,omitempty"` // Hash email addresses in every field
+	MaxLength  int      `json:"max_length,omitempty"`  // Truncated fields are cut to this many characters
+	Hash       []string `json:"hash,omitempty"`
+	Truncate   []string `json:"truncate,omitempty"`
+	Drop       []string `json:"drop,omitempty"`
+	Keep       []string `json:"keep,omitempty"` // Logged whole, even in strict mode
+}
+
+// Policy returns the logging field policy the settings describe
+func (c LogFieldsConfig) Policy() logging.FieldPolicy {
+	policy := logging.DefaultFieldPolicy()
+	if c.Strict {
+		policy = logging.StrictFieldPolicy()
+	}
+	if c.HashEmails {
+		policy.HashEmails = true
+	}
+	if c.MaxLength > 0 {
+		policy.MaxLength = c.MaxLength
+	}
+	for action, keys := range map[logging.FieldAction][]string{
+		logging.FieldHash:     c.Hash,
+		logging.FieldTruncate: c.Truncate,
+		logging.FieldDrop:     c.Drop,
+		logging.FieldKeep:     c.Keep,
+	} {
+		for _, key := range keys {
+			policy.Fields[key] = action
+		}
+	}
+	return policy
+}
+
+// Default returns the configuration used for settings that are not configured
+func Default() *Config {
+	return &Config{
+		RateLimits: RateLimitConfig{
+			InitialDelay: 5000,
+			MaxDelay:     5000,
+			MaxRetries:   3,
+		},
+		Retry: map[string]RetryPolicy{
+			RetryTimeout: {MaxAttempts: 2, Delay: 2000},
+		},
+		RoleMappings: []RoleMapping{
+			{Name: "admin", Role: "project-owner"},
+			{Name: "member", Role: "project-editor"},
+			{Name: "viewer", Role: "project-viewer"},
+		},
+		Frontend: FrontendConfig{
+			Prompt: "> ",
+		},
+		Logging: LoggingConfig{
+			Level:  "warn",
+			Format: "json",
+			Output: "stderr",
+		},
+		Tracing: TracingConfig{
+			SampleRatio: 1,
+		},
+		Plugins: PluginsConfig{
+			Timeout: 30000,
+		},
+	}
+}
+
+// TracingConfig describes where traces are exported. Tracing is off when no
+// endpoint is set.
+type TracingConfig struct {
+	Endpoint    string            `json:"endpoint,omitempty"`              // OTLP/HTTP endpoint, e.g. http://localhost:4318
+	Headers     map[string]string `json:"headers,omitempty" secret:"true"` // Sent with every export, values may be secret references
+	ServiceName string            `json:"service_name,omitempty"`          // Defaults to nobl9-bot
+	SampleRatio float64           `json:"sample_ratio,omitempty"`          // Share of traces recorded, from 0 to 1
+}
+
+// Enabled reports whether traces should be exported
+func (t TracingConfig) Enabled() bool {
+	return t.Endpoint != ""
+}
+
+// Options returns the tracing options the settings describe
+func (t TracingConfig) Options() tracing.Options {
+	return tracing.Options{
+		Endpoint:    t.Endpoint,
+		Headers:     t.Headers,
+		ServiceName: t.ServiceName,
+		SampleRatio: t.SampleRatio,
+	}
+}
+
+// SecretsConfig configures where secret references are resolved. A setting tagged as
+// a secret, such as nobl9.client_secret, may hold the secret itself or a reference:
+// file:/run/secrets/nobl9, env:NOBL9_CLIENT_SECRET or vault:secret/data/nobl9#client_secret.
+type SecretsConfig struct {
+	Vault VaultConfig `json:"vault,omitempty"`
+}
+
+// VaultConfig describes the Vault server vault: references are read from
+type VaultConfig struct {
+	Address   string `json:"address,omitempty"`             // Defaults to VAULT_ADDR, vault: references fail when neither is set
+	Token     string `json:"token,omitempty" secret:"true"` // Defaults to VAULT_TOKEN, may be a file: or env: reference
+	Namespace string `json:"namespace,omitempty"`           // Vault Enterprise namespace
+}
+
+// MinAuditKeyLength is the shortest key accepted for signing the audit log
+const MinAuditKeyLength = 32
+
+// AuditConfig describes where the audit log is written and when it is rotated
+type AuditConfig struct {
+	Path     string `json:"path,omitempty" path:"true"` // Defaults to ~/.nobl9/audit.jsonl
+	MaxBytes int64  `json:"max_bytes,omitempty"`        // Rotate once the log reaches this size, 0 disables rotation
+	// Key signs the hash chain and the log's head, so the chain cannot be rebuilt
+	// or truncated without it. It may be a secret reference.
+	Key string `json:"key,omitempty" secret:"true"`
+}
+
+// PluginsConfig describes where plugin commands are found. A plugin is an
+// executable named nobl9-bot-<command>, looked for in Dir and then on PATH.
+type PluginsConfig struct {
+	Disabled   bool   `json:"disabled,omitempty"`        // Load no plugins
+	Dir        string `json:"dir,omitempty" path:"true"` // Defaults to ~/.nobl9/plugins
+	IgnorePath bool   `json:"ignore_path,omitempty"`     // Only load plugins from Dir
+	Timeout    int    `json:"timeout,omitempty"`         // Milliseconds a plugin may run, 0 for the 30s default, plugins may ask for less
+}
+
+// AuthorizationPolicy maps caller identities to bot roles. Identities take the form
+// source:id, such as cli:alice, http:alice@example.com or slack:U123, and source:*
+// matches every caller from a source. Authorization is off when no roles are defined.
+type AuthorizationPolicy struct {
+	Roles        map[string][]string `json:"roles,omitempty"`         // Bot role to permissions, "*" grants every permission
+	Identities   map[string][]string `json:"identities,omitempty"`    // Identity to bot roles
+	DefaultRoles []string            `json:"default_roles,omitempty"` // Roles for identities that are not listed
+}
+
+// ApprovalPolicy describes which requests need approval and who may approve them.
+// Current project owners may always approve requests for their project.
+type ApprovalPolicy struct {
+	Roles         []string          `json:"roles,omitempty"`          // Roles that need approval, e.g. project-owner
+	ProjectLabels map[string]string `json:"project_labels,omitempty"` // Projects with any of these labels need approval, e.g. tier=critical
+	MaxUsers      int               `json:"max_users,omitempty"`      // Requests granting roles to more users than this need approval
+	Approvers     []string          `json:"approvers,omitempty"`      // Emails allowed to approve any request
+}
+
+// Enabled reports whether any approval rule is configured
+func (p ApprovalPolicy) Enabled() bool {
+	return len(p.Roles) > 0 || len(p.ProjectLabels) > 0 || p.MaxUsers > 0
+}
+
+// GitOpsConfig describes the git working tree changes are committed to for review
+type GitOpsConfig struct {
+	RepoDir      string `json:"repo_dir,omitempty" path:"true"` // Local working tree, GitOps mode is off when empty
+	Layout       string `json:"layout,omitempty"`               // File path template, e.g. {{ .Project }}/{{ .Kind }}-{{ .Name }}.yaml
+	BaseBranch   string `json:"base_branch,omitempty"`          // Branch new branches start from, defaults to main
+	BranchPrefix string `json:"branch_prefix,omitempty"`        // Prefix for created branches, defaults to nobl9-bot/
+	Remote       string `json:"remote,omitempty"`               // Remote to fetch from and push to, branches stay local when empty
+	AuthorName   string `json:"author_name,omitempty"`
+	AuthorEmail  string `json:"author_email,omitempty"`
+}
+
+// Enabled reports whether changes should be committed instead of applied
+func (g GitOpsConfig) Enabled() bool {
+	return g.RepoDir != ""
+}
+
+// NamingPolicy describes org naming conventions for projects, on top of RFC-1123
+type NamingPolicy struct {
+	Pattern       string   `json:"pattern,omitempty"`      // Regular expression names must match
+	PatternHint   string   `json:"pattern_hint,omitempty"` // Human readable form of Pattern, e.g. team-service-env
+	ReservedWords []string `json:"reserved_words,omitempty"`
+	MinLength     int      `json:"min_length,omitempty"`
+	MaxLength     int      `json:"max_length,omitempty"`
+}
+
+// LabelPolicy describes a project label and the values it may take
+type LabelPolicy struct {
+	Key           string   `json:"key"`
+	Description   string   `json:"description,omitempty"`
+	Required      bool     `json:"required"`
+	AllowedValues []string `json:"allowed_values,omitempty"`
+	Default       string   `json:"default,omitempty"`
+}
+
+// Allows reports whether value is permitted for the label
+func (p LabelPolicy) Allows(value string) bool {
+	if len(p.AllowedValues) == 0 {
+		return true
+	}
+	for _, allowed := range p.AllowedValues {
+		if value == allowed {
+			return true
 		}
 	}
+	return false
+}
 
-	// Check if file exists
-	if _, err := os.Stat(path); os.IsNotExist(err) {
-		return &Config{
-			URL: "https://app.nobl9.com",
-		}, nil
+// CheckLabels verifies that labels satisfy the configured label policies.
+// Every required label must be present and every value must be allowed.
+func CheckLabels(policies []LabelPolicy, labels map[string]string) error {
+	if problems := LabelProblems(policies, labels); len(problems) > 0 {
+		return errors.NewValidationError(strings.Join(problems, "; "), nil)
 	}
+	return nil
+}
+
+// LabelProblems returns a message for each way labels break the label policies
+func LabelProblems(policies []LabelPolicy, labels map[string]string) []string {
+	var problems []string
+	for _, policy := range policies {
+		value, ok := labels[policy.Key]
+		if !ok || value == "" {
+			if policy.Required {
+				problems = append(problems, fmt.Sprintf("label '%s' is required", policy.Key))
+			}
+			continue
+		}
+		if !policy.Allows(value) {
+			problems = append(problems, fmt.Sprintf("label '%s' must be one of: %s", policy.Key, strings.Join(policy.AllowedValues, ", ")))
+		}
+	}
+	return problems
+}
+
+// DefaultConfigPath returns the default path for the config file
+func DefaultConfigPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".nobl9", "bot.yaml"), nil
+}
 
-	// Read and parse config file
-	data, err := os.ReadFile(path)
+// DefaultConfigPaths returns the paths searched for a config file, in order:
+// config.yaml in the working directory, ~/.nobl9/bot.yaml and the older ~/.nobl9/config.json
+func DefaultConfigPaths() ([]string, error) {
+	homeDir, err := os.UserHomeDir()
 	if err != nil {
-		return nil, fmt.Errorf("failed to read config file: %w", err)
+		return nil, fmt.Errorf("failed to get user home directory: %w", err)
 	}
+	return []string{
+		"config.yaml",
+		filepath.Join(homeDir, ".nobl9", "bot.yaml"),
+		filepath.Join(homeDir, ".nobl9", "config.json"),
+	}, nil
+}
 
-	var config Config
-	if err := json.Unmarshal(data, &config); err != nil {
-		return nil, fmt.Errorf("failed to parse config file: %w", err)
+// DefaultAuditPath returns the default path for the audit log
+func DefaultAuditPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
 	}
+	return filepath.Join(homeDir, ".nobl9", "audit.jsonl"), nil
+}
 
-	// Set default URL if not specified
-	if config.URL == "" {
-		config.URL = "https://app.nobl9.com"
+// DefaultPluginsDir returns the default directory plugin commands are loaded from
+func DefaultPluginsDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
 	}
+	return filepath.Join(homeDir, ".nobl9", "plugins"), nil
+}
 
-	return &config, nil
+// LoadConfig loads the configuration from the specified path or the first default
+// path that exists, then applies environment variables
+func LoadConfig(path string) (*Config, error) {
+	return Load(LoadOptions{Path: path})
 }
 
-// SaveConfig saves the configuration to the specified path
+// SaveConfig saves the configuration to the specified path, as YAML, TOML or JSON
+// depending on its extension
 func SaveConfig(config *Config, path string) error {
 	if path == "" {
 		var err error
@@ -77,8 +467,7 @@ func SaveConfig(config *Config, path string) error {
 		return fmt.Errorf("failed to create config directory: %w", err)
 	}
 
-	// Marshal and write config
-	data, err := json.MarshalIndent(config, "", "  ")
+	data, err := encode(config, path)
 	if err != nil {
 		return fmt.Errorf("failed to marshal config: %w", err)
 	}
@@ -93,4 +482,4 @@ func SaveConfig(config *Config, path string) error {
 // Save saves the configuration to the config file
 func (c *Config) Save() error {
 	return SaveConfig(c, "")
-} 
\ No newline at end of file
+}
@@ -0,0 +1,245 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dfaile/backstage-nobl9/internal/config"
+	"github.com/dfaile/backstage-nobl9/internal/errors"
+	"github.com/dfaile/backstage-nobl9/internal/logging"
+)
+
+func TestLoadConfigProjectLabels(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	data := `{
+		"organization": "acme",
+		"project_labels": [
+			{"key": "team", "required": true},
+			{"key": "env", "required": true, "allowed_values": ["dev", "prod"]},
+			{"key": "cost-center", "required": false, "default": "cc-000"}
+		]
+	}`
+	require.NoError(t, os.WriteFile(path, []byte(data), 0600))
+
+	cfg, err := config.LoadConfig(path)
+	require.NoError(t, err)
+	require.Len(t, cfg.ProjectLabels, 3)
+	assert.Equal(t, "team", cfg.ProjectLabels[0].Key)
+	assert.True(t, cfg.ProjectLabels[0].Required)
+	assert.Equal(t, []string{"dev", "prod"}, cfg.ProjectLabels[1].AllowedValues)
+	assert.Equal(t, "cc-000", cfg.ProjectLabels[2].Default)
+}
+
+func TestCheckLabels(t *testing.T) {
+	policies := []config.LabelPolicy{
+		{Key: "team", Required: true},
+		{Key: "env", Required: true, AllowedValues: []string{"dev", "prod"}},
+		{Key: "cost-center"},
+	}
+
+	tests := []struct {
+		name    string
+		labels  map[string]string
+		wantErr string
+	}{
+		{
+			name:   "all labels present",
+			labels: map[string]string{"team": "payments", "env": "prod", "cost-center": "cc-1"},
+		},
+		{
+			name:   "optional label omitted",
+			labels: map[string]string{"team": "payments", "env": "dev"},
+		},
+		{
+			name:    "required label missing",
+			labels:  map[string]string{"env": "dev"},
+			wantErr: "label 'team' is required",
+		},
+		{
+			name:    "required label empty",
+			labels:  map[string]string{"team": "", "env": "dev"},
+			wantErr: "label 'team' is required",
+		},
+		{
+			name:    "value not allowed",
+			labels:  map[string]string{"team": "payments", "env": "staging"},
+			wantErr: "label 'env' must be one of: dev, prod",
+		},
+		{
+			name:    "no labels",
+			labels:  nil,
+			wantErr: "label 'team' is required; label 'env' is required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := config.CheckLabels(policies, tt.labels)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.True(t, errors.IsValidationError(err))
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestLabelPolicyAllows(t *testing.T) {
+	open := config.LabelPolicy{Key: "team"}
+	assert.True(t, open.Allows("anything"))
+
+	restricted := config.LabelPolicy{Key: "env", AllowedValues: []string{"dev"}}
+	assert.True(t, restricted.Allows("dev"))
+	assert.False(t, restricted.Allows("prod"))
+}
+
+func TestLogFieldsPolicy(t *testing.T) {
+	path := writeFile(t, "config.yaml", `
+logging:
+  fields:
+    strict: true
+    max_length: 20
+    drop: [description]
+    keep: [requester]
+`)
+	cfg, err := config.Load(config.LoadOptions{Path: path, LookupEnv: env(map[string]string{"NOBL9_BOT_LOGGING_FIELDS_HASH": "project"})})
+	require.NoError(t, err)
+
+	policy := cfg.Logging.Fields.Policy()
+	assert.True(t, policy.HashEmails)
+	assert.Equal(t, 20, policy.MaxLength)
+	assert.Equal(t, logging.FieldDrop, policy.Action("message"))
+	assert.Equal(t, logging.FieldDrop, policy.Action("description"))
+	assert.Equal(t, logging.FieldKeep, policy.Action("requester"))
+	assert.Equal(t, logging.FieldHash, policy.Action("approver"))
+	assert.Equal(t, logging.FieldHash, policy.Action("project"))
+
+	// Without settings free text is truncated and everything else kept
+	policy = config.Default().Logging.Fields.Policy()
+	assert.False(t, policy.HashEmails)
+	assert.Equal(t, logging.FieldTruncate, policy.Action("message"))
+	assert.Equal(t, logging.FieldKeep, policy.Action("requester"))
+
+	path = writeFile(t, "config.yaml", "logging:\n  fields:\n    max_length: -1\n    hash: [user]\n    keep: [user]\n")
+	_, err = config.Load(config.LoadOptions{Path: path, LookupEnv: env(nil)})
+	require.Error(t, err)
+	assert.True(t, errors.IsValidationError(err))
+	assert.Contains(t, err.Error(), "logging.fields.max_length: must not be negative")
+	assert.Contains(t, err.Error(), "logging.fields.keep: field 'user' is already listed in hash")
+}
+
+func TestLogSinks(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "bot.log")
+	path := writeFile(t, "config.yaml", `
+logging:
+  level: info
+  format: pretty
+  sinks:
+    - type: stderr
+    - type: file
+      format: json
+      path: `+logPath+`
+      max_bytes: 1048576
+      max_backups: 5
+  levels:
+    nobl9: debug
+`)
+	cfg, err := config.Load(config.LoadOptions{Path: path, LookupEnv: env(nil)})
+	require.NoError(t, err)
+
+	options, err := cfg.Logging.Options()
+	require.NoError(t, err)
+	require.Len(t, options.Sinks, 2)
+	assert.Equal(t, logging.Sink{Type: "file", Format: "json", Path: logPath, MaxBytes: 1048576, MaxBackups: 5}, options.Sinks[1])
+	assert.Equal(t, logging.LevelInfo, options.Levels.Level())
+	assert.Equal(t, map[string]logging.Level{"nobl9": logging.LevelDebug}, options.Levels.Packages())
+
+	path = writeFile(t, "config.yaml", `
+logging:
+  format: xml
+  sinks:
+    - type: file
+    - type: udp
+      address: localhost
+      max_backups: -1
+    - type: kafka
+  levels:
+    bot: verbose
+`)
+	_, err = config.Load(config.LoadOptions{Path: path, LookupEnv: env(nil)})
+	require.Error(t, err)
+	assert.True(t, errors.IsValidationError(err))
+	for _, problem := range []string{
+		`logging.format: must be json, console or pretty, got "xml"`,
+		"logging.sinks[0].path: is required for file sinks",
+		`logging.sinks[1].address: must be host:port, got "localhost"`,
+		"logging.sinks[1].max_backups: must not be negative",
+		`logging.sinks[2].type: must be stdout, stderr, file or udp, got "kafka"`,
+		`logging.levels.bot: must be debug, info, warn or error, got "verbose"`,
+	} {
+		assert.Contains(t, err.Error(), problem)
+	}
+}
+
+func TestTracingConfig(t *testing.T) {
+	assert.False(t, config.Default().Tracing.Enabled())
+
+	path := writeFile(t, "config.yaml", `
+tracing:
+  endpoint: http://localhost:4318
+  headers:
+    X-Api-Key: env:COLLECTOR_KEY
+`)
+	cfg, err := config.Load(config.LoadOptions{Path: path, LookupEnv: env(map[string]string{
+		"COLLECTOR_KEY":                  "collector-key",
+		"NOBL9_BOT_TRACING_SAMPLE_RATIO": "0.25",
+	})})
+	require.NoError(t, err)
+
+	options := cfg.Tracing.Options()
+	assert.True(t, cfg.Tracing.Enabled())
+	assert.Equal(t, "http://localhost:4318", options.Endpoint)
+	assert.Equal(t, map[string]string{"X-Api-Key": "collector-key"}, options.Headers)
+	assert.Equal(t, 0.25, options.SampleRatio)
+	assert.NotContains(t, cfg.String(), "collector-key")
+
+	path = writeFile(t, "config.yaml", "tracing:\n  endpoint: localhost:4318\n  sample_ratio: 2\n")
+	_, err = config.Load(config.LoadOptions{Path: path, LookupEnv: env(nil)})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `tracing.endpoint: must be an http or https URL, got "localhost:4318"`)
+	assert.Contains(t, err.Error(), "tracing.sample_ratio: must be between 0 and 1, got 2")
+}
+
+func TestCommandRateLimit(t *testing.T) {
+	assert.Zero(t, config.Default().RateLimits.CommandsPerMinute)
+
+	path := writeFile(t, "config.yaml", "rate_limits:\n  commands_per_minute: 30\n")
+	cfg, err := config.Load(config.LoadOptions{Path: path, LookupEnv: env(nil)})
+	require.NoError(t, err)
+	assert.Equal(t, 30, cfg.RateLimits.CommandsPerMinute)
+
+	cfg, err = config.Load(config.LoadOptions{Path: path, LookupEnv: env(map[string]string{"NOBL9_BOT_RATE_LIMITS_COMMANDS_PER_MINUTE": "-1"})})
+	require.Error(t, err)
+	assert.Nil(t, cfg)
+	assert.Contains(t, err.Error(), "rate_limits.commands_per_minute: must not be negative")
+}
+
+func TestPlugins(t *testing.T) {
+	assert.Equal(t, config.PluginsConfig{Timeout: 30000}, config.Default().Plugins)
+
+	path := writeFile(t, "config.yaml", "plugins:\n  dir: /opt/nobl9-bot/plugins\n  ignore_path: true\n  timeout: 10000\n")
+	cfg, err := config.Load(config.LoadOptions{Path: path, LookupEnv: env(nil)})
+	require.NoError(t, err)
+	assert.Equal(t, config.PluginsConfig{Dir: "/opt/nobl9-bot/plugins", IgnorePath: true, Timeout: 10000}, cfg.Plugins)
+
+	cfg, err = config.Load(config.LoadOptions{Path: path, LookupEnv: env(map[string]string{"NOBL9_BOT_PLUGINS_TIMEOUT": "-1"})})
+	require.Error(t, err)
+	assert.Nil(t, cfg)
+	assert.Contains(t, err.Error(), "plugins.timeout: must not be negative")
+}
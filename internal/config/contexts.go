@@ -0,0 +1,89 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/BurntSushi/toml"
+	"github.com/nobl9/nobl9-go/sdk"
+
+	"github.com/dfaile/backstage-nobl9/internal/errors"
+)
+
+// Contexts is the config.toml file sloctl and the Nobl9 SDK share. It holds the
+// credentials for each Nobl9 context, such as a dev and a prod organization, and
+// names the context used by default.
+type Contexts struct {
+	sdk.FileConfig
+	path string
+}
+
+// ContextsPath returns the sloctl config.toml the bot uses: settings.ConfigFile, which
+// NOBL9_SDK_CONFIG_FILE_PATH also sets, then ~/.nobl9/config.toml if it exists, then
+// the SDK's own default of ~/.config/nobl9/config.toml
+func ContextsPath(settings Nobl9Config) (string, error) {
+	if settings.ConfigFile != "" {
+		return settings.ConfigFile, nil
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	path := filepath.Join(homeDir, ".nobl9", "config.toml")
+	if _, err := os.Stat(path); err == nil {
+		return path, nil
+	}
+	return sdk.GetDefaultConfigPath()
+}
+
+// LoadContexts reads a sloctl config.toml
+func LoadContexts(path string) (*Contexts, error) {
+	contexts := &Contexts{path: path}
+	if _, err := toml.DecodeFile(path, &contexts.FileConfig); err != nil {
+		if os.IsNotExist(err) {
+			return nil, errors.NewNotFoundError(fmt.Sprintf("no Nobl9 contexts are configured, %s does not exist", path), err)
+		}
+		return nil, errors.NewValidationError(fmt.Sprintf("invalid contexts file %s", path), err)
+	}
+	return contexts, nil
+}
+
+// Path returns the file the contexts were loaded from
+func (c *Contexts) Path() string {
+	return c.path
+}
+
+// Names returns the context names, sorted
+func (c *Contexts) Names() []string {
+	names := make([]string, 0, len(c.Contexts))
+	for name := range c.Contexts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Get returns the named context
+func (c *Contexts) Get(name string) (sdk.ContextConfig, bool) {
+	context, ok := c.Contexts[name]
+	return context, ok
+}
+
+// SetDefault makes name the context sloctl and the bot use when none is chosen
+func (c *Contexts) SetDefault(name string) error {
+	if _, ok := c.Contexts[name]; !ok {
+		return errors.NewNotFoundError(fmt.Sprintf("context '%s' not found in %s", name, c.path), nil)
+	}
+	c.DefaultContext = name
+	return nil
+}
+
+// Save writes the contexts back to their file in the sloctl format
+func (c *Contexts) Save() error {
+	if err := c.FileConfig.Save(c.path); err != nil {
+		return fmt.Errorf("failed to write contexts file: %w", err)
+	}
+	return nil
+}
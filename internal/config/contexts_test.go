@@ -0,0 +1,79 @@
+package config_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dfaile/backstage-nobl9/internal/config"
+	"github.com/dfaile/backstage-nobl9/internal/errors"
+)
+
+const sloctlConfig = `defaultContext = "dev"
+
+[contexts.dev]
+clientId = "dev-id"
+clientSecret = "dev-secret"
+organization = "acme-dev"
+url = "https://app.nobl9.com"
+
+[contexts.prod]
+clientId = "prod-id"
+clientSecret = "prod-secret"
+organization = "acme"
+timeout = "30s"
+`
+
+func TestContexts(t *testing.T) {
+	path := writeFile(t, "config.toml", sloctlConfig)
+
+	contexts, err := config.LoadContexts(path)
+	require.NoError(t, err)
+	assert.Equal(t, path, contexts.Path())
+	assert.Equal(t, "dev", contexts.DefaultContext)
+	assert.Equal(t, []string{"dev", "prod"}, contexts.Names())
+
+	prod, ok := contexts.Get("prod")
+	require.True(t, ok)
+	assert.Equal(t, "acme", prod.Organization)
+	assert.Equal(t, "prod-id", prod.ClientID)
+
+	err = contexts.SetDefault("staging")
+	assert.True(t, errors.IsNotFoundError(err))
+	assert.Equal(t, "dev", contexts.DefaultContext)
+
+	// Switching the default writes the file back in the sloctl format
+	require.NoError(t, contexts.SetDefault("prod"))
+	require.NoError(t, contexts.Save())
+
+	reloaded, err := config.LoadContexts(path)
+	require.NoError(t, err)
+	assert.Equal(t, "prod", reloaded.DefaultContext)
+	assert.Equal(t, contexts.Contexts, reloaded.Contexts)
+}
+
+func TestLoadContextsErrors(t *testing.T) {
+	_, err := config.LoadContexts(filepath.Join(t.TempDir(), "config.toml"))
+	assert.True(t, errors.IsNotFoundError(err))
+
+	_, err = config.LoadContexts(writeFile(t, "config.toml", "[contexts.dev\n"))
+	assert.True(t, errors.IsValidationError(err))
+}
+
+func TestContextsPath(t *testing.T) {
+	path, err := config.ContextsPath(config.Nobl9Config{ConfigFile: "/etc/nobl9/config.toml"})
+	require.NoError(t, err)
+	assert.Equal(t, "/etc/nobl9/config.toml", path)
+
+	cfg, err := config.Load(config.LoadOptions{
+		Path: writeFile(t, "bot.yaml", "{}\n"),
+		LookupEnv: env(map[string]string{
+			"NOBL9_SDK_CONFIG_FILE_PATH": "/tmp/config.toml",
+			"NOBL9_SDK_DEFAULT_CONTEXT":  "prod",
+		}),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, config.Nobl9Config{Context: "prod", ConfigFile: "/tmp/config.toml"}, cfg.Nobl9)
+}
@@ -0,0 +1,468 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+
+	"github.com/dfaile/backstage-nobl9/internal/errors"
+	"github.com/dfaile/backstage-nobl9/internal/secrets"
+)
+
+// EnvPrefix prefixes the environment variables that override config settings.
+// A setting's variable is its dotted path in upper case with dots replaced by
+// underscores, e.g. NOBL9_BOT_RATE_LIMITS_MAX_RETRIES for rate_limits.max_retries.
+const EnvPrefix = "NOBL9_BOT_"
+
+// EnvConfigPath names the environment variable holding the config file path
+const EnvConfigPath = "NOBL9_BOT_CONFIG"
+
+// sdkEnv maps the Nobl9 SDK's environment variables to the settings they fill
+var sdkEnv = map[string]string{
+	"NOBL9_SDK_CLIENT_ID":        "nobl9.client_id",
+	"NOBL9_SDK_CLIENT_SECRET":    "nobl9.client_secret",
+	"NOBL9_SDK_ORGANIZATION":     "nobl9.organization",
+	"NOBL9_SDK_URL":              "nobl9.base_url",
+	"NOBL9_SDK_DEFAULT_CONTEXT":  "nobl9.context",
+	"NOBL9_SDK_CONFIG_FILE_PATH": "nobl9.config_file",
+}
+
+// LoadOptions controls where Load reads configuration from
+type LoadOptions struct {
+	Path      string                      // Config file, the first default path that exists when empty
+	LookupEnv func(string) (string, bool) // Defaults to os.LookupEnv
+	Overrides map[string]string           // Setting paths to values, e.g. from command line flags
+
+	// SecretProviders adds stores for secret references, keyed by scheme. file:,
+	// env: and, when secrets.vault is configured, vault: references always work.
+	SecretProviders map[string]secrets.Provider
+}
+
+// Overrides collects key=value settings from a repeatable command line flag
+type Overrides map[string]string
+
+// String implements flag.Value
+func (o Overrides) String() string {
+	pairs := make([]string, 0, len(o))
+	for key, value := range o {
+		pairs = append(pairs, key+"="+value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// Set implements flag.Value
+func (o Overrides) Set(pair string) error {
+	key, value, found := strings.Cut(pair, "=")
+	if !found || key == "" {
+		return fmt.Errorf("expected setting=value, got %q", pair)
+	}
+	o[key] = value
+	return nil
+}
+
+// Load builds the configuration from defaults, then the config file, then the
+// environment, then opts.Overrides, expands ~ in paths, resolves secret references
+// and validates the result
+func Load(opts LoadOptions) (*Config, error) {
+	lookup := opts.LookupEnv
+	if lookup == nil {
+		lookup = os.LookupEnv
+	}
+
+	cfg := Default()
+
+	path, err := findConfigFile(opts.Path, lookup)
+	if err != nil {
+		return nil, err
+	}
+	if path != "" {
+		if err := cfg.loadFile(path); err != nil {
+			return nil, err
+		}
+	}
+	if err := cfg.applyEnv(lookup); err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(opts.Overrides))
+	for key := range opts.Overrides {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if err := cfg.Set(key, opts.Overrides[key]); err != nil {
+			return nil, errors.NewValidationError(fmt.Sprintf("invalid override: %v", err), nil)
+		}
+	}
+
+	cfg.migrateCredentials()
+
+	if err := cfg.expandPaths(); err != nil {
+		return nil, errors.NewValidationError(err.Error(), nil)
+	}
+
+	if err := cfg.resolveSecrets(opts, lookup); err != nil {
+		if path != "" {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		return nil, err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		if path != "" {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		return nil, err
+	}
+	cfg.Source = path
+	return cfg, nil
+}
+
+// findConfigFile returns the config file to load, or an empty path when there is none
+func findConfigFile(path string, lookup func(string) (string, bool)) (string, error) {
+	if path == "" {
+		path, _ = lookup(EnvConfigPath)
+	}
+	if path != "" {
+		path, err := ExpandHome(path)
+		if err != nil {
+			return "", err
+		}
+		if _, err := os.Stat(path); err != nil {
+			return "", fmt.Errorf("failed to read config file: %w", err)
+		}
+		return path, nil
+	}
+
+	candidates, err := DefaultConfigPaths()
+	if err != nil {
+		return "", err
+	}
+	for _, candidate := range candidates {
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate, nil
+		}
+	}
+	return "", nil
+}
+
+// loadFile layers a YAML, TOML or JSON file over c
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var raw map[string]interface{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &raw)
+	case ".toml":
+		err = toml.Unmarshal(data, &raw)
+	case ".json":
+		err = json.Unmarshal(data, &raw)
+	default:
+		return errors.NewValidationError(fmt.Sprintf("unsupported config file format '%s', use .yaml, .toml or .json", filepath.Ext(path)), nil)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	// Report every misspelled setting rather than silently ignoring it
+	var problems []string
+	checkKeys(raw, reflect.TypeOf(Config{}), "", &problems)
+	if len(problems) > 0 {
+		return errors.NewValidationError(fmt.Sprintf("invalid config file %s:\n  - %s", path, strings.Join(problems, "\n  - ")), nil)
+	}
+
+	// The formats share the JSON field names, so every format is decoded as JSON
+	normalized, err := json.Marshal(raw)
+	if err != nil {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	if err := json.Unmarshal(normalized, c); err != nil {
+		if typeErr, ok := err.(*json.UnmarshalTypeError); ok {
+			return errors.NewValidationError(fmt.Sprintf("invalid config file %s: %s must be %s, got %s", path, typeErr.Field, describeType(typeErr.Type), typeErr.Value), nil)
+		}
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// migrateCredentials moves top-level credentials from older config files into Nobl9
+func (c *Config) migrateCredentials() {
+	move := func(legacy *string, target *string) {
+		if *target == "" {
+			*target = *legacy
+		}
+		*legacy = ""
+	}
+	move(&c.ClientID, &c.Nobl9.ClientID)
+	move(&c.ClientSecret, &c.Nobl9.ClientSecret)
+	move(&c.Organization, &c.Nobl9.Organization)
+	move(&c.URL, &c.Nobl9.URL)
+}
+
+// applyEnv layers the SDK credential variables and NOBL9_BOT_ variables over c. Each
+// variable may instead name a file holding its value with the _FILE suffix.
+func (c *Config) applyEnv(lookup func(string) (string, bool)) error {
+	names := make([]string, 0, len(sdkEnv))
+	for name := range sdkEnv {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		value, ok, err := lookupEnvFile(lookup, name)
+		if err != nil {
+			return err
+		}
+		if ok && value != "" {
+			if err := c.Set(sdkEnv[name], value); err != nil {
+				return errors.NewValidationError(fmt.Sprintf("invalid environment variable %s: %v", name, err), nil)
+			}
+		}
+	}
+
+	for _, key := range settingPaths(reflect.TypeOf(Config{}), "") {
+		name := EnvPrefix + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+		value, ok, err := lookupEnvFile(lookup, name)
+		if err != nil {
+			return err
+		}
+		if ok {
+			if err := c.Set(key, value); err != nil {
+				return errors.NewValidationError(fmt.Sprintf("invalid environment variable %s: %v", name, err), nil)
+			}
+		}
+	}
+	return nil
+}
+
+// Set sets the setting at a dotted path, such as rate_limits.max_retries or
+// retry.timeout.max_attempts, from its string form. Lists are comma separated.
+func (c *Config) Set(key, value string) error {
+	if key == "" {
+		return fmt.Errorf("setting name is required")
+	}
+	return setValue(reflect.ValueOf(c).Elem(), strings.Split(key, "."), key, value)
+}
+
+// setValue walks parts through v and sets the final value
+func setValue(v reflect.Value, parts []string, key, value string) error {
+	if len(parts) == 0 {
+		return setScalar(v, key, value)
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		field, ok := fieldByName(v.Type(), parts[0])
+		if !ok {
+			return fmt.Errorf("%s: unknown setting", key)
+		}
+		return setValue(v.FieldByIndex(field.Index), parts[1:], key, value)
+	case reflect.Map:
+		if v.IsNil() {
+			v.Set(reflect.MakeMap(v.Type()))
+		}
+		mapKey := reflect.ValueOf(parts[0])
+		elem := reflect.New(v.Type().Elem()).Elem()
+		if existing := v.MapIndex(mapKey); existing.IsValid() {
+			elem.Set(existing)
+		}
+		if err := setValue(elem, parts[1:], key, value); err != nil {
+			return err
+		}
+		v.SetMapIndex(mapKey, elem)
+		return nil
+	default:
+		return fmt.Errorf("%s: unknown setting", key)
+	}
+}
+
+// setScalar parses value into a string, number, boolean or string list
+func setScalar(v reflect.Value, key, value string) error {
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+		if err != nil {
+			return fmt.Errorf("%s must be a number, got %q", key, value)
+		}
+		v.SetInt(n)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return fmt.Errorf("%s must be a number, got %q", key, value)
+		}
+		v.SetFloat(f)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("%s must be true or false, got %q", key, value)
+		}
+		v.SetBool(b)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("%s can only be set in the config file", key)
+		}
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("%s can only be set in the config file", key)
+	}
+	return nil
+}
+
+// settingPaths lists the dotted paths of the struct settings that can be set from
+// a string. Maps and lists of objects are left to the config file.
+func settingPaths(t reflect.Type, prefix string) []string {
+	var paths []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := jsonName(field)
+		if name == "" {
+			continue
+		}
+		path := name
+		if prefix != "" {
+			path = prefix + "." + name
+		}
+		switch field.Type.Kind() {
+		case reflect.Struct:
+			paths = append(paths, settingPaths(field.Type, path)...)
+		case reflect.String, reflect.Int, reflect.Int64, reflect.Float64, reflect.Bool:
+			paths = append(paths, path)
+		case reflect.Slice:
+			if field.Type.Elem().Kind() == reflect.String {
+				paths = append(paths, path)
+			}
+		}
+	}
+	return paths
+}
+
+// checkKeys reports keys in raw that do not match a setting of t
+func checkKeys(raw interface{}, t reflect.Type, path string, problems *[]string) {
+	switch t.Kind() {
+	case reflect.Struct:
+		values, ok := raw.(map[string]interface{})
+		if !ok {
+			return
+		}
+		keys := make([]string, 0, len(values))
+		for key := range values {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			keyPath := key
+			if path != "" {
+				keyPath = path + "." + key
+			}
+			field, ok := fieldByName(t, key)
+			if !ok {
+				*problems = append(*problems, fmt.Sprintf("%s: unknown setting", keyPath))
+				continue
+			}
+			checkKeys(values[key], field.Type, keyPath, problems)
+		}
+	case reflect.Map:
+		values, ok := raw.(map[string]interface{})
+		if !ok {
+			return
+		}
+		for key, value := range values {
+			checkKeys(value, t.Elem(), path+"."+key, problems)
+		}
+	case reflect.Slice:
+		items, ok := raw.([]interface{})
+		if !ok {
+			return
+		}
+		for i, item := range items {
+			checkKeys(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i), problems)
+		}
+	}
+}
+
+// fieldByName finds the struct field with the given JSON name
+func fieldByName(t reflect.Type, name string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		if jsonName(t.Field(i)) == name {
+			return t.Field(i), true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// jsonName returns a field's JSON name, or an empty string for skipped fields
+func jsonName(field reflect.StructField) string {
+	tag := field.Tag.Get("json")
+	if tag == "-" || !field.IsExported() {
+		return ""
+	}
+	name, _, _ := strings.Cut(tag, ",")
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
+
+// describeType names a setting's type for error messages
+func describeType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Int, reflect.Int64:
+		return "a number"
+	case reflect.Bool:
+		return "true or false"
+	case reflect.Slice:
+		return "a list"
+	default:
+		return "an object"
+	}
+}
+
+// encode renders c in the format matching path's extension
+func encode(c *Config, path string) ([]byte, error) {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext != ".yaml" && ext != ".yml" && ext != ".toml" {
+		return data, nil
+	}
+
+	// Go through a generic map so the JSON field names are kept
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	if ext == ".toml" {
+		var buf bytes.Buffer
+		if err := toml.NewEncoder(&buf).Encode(raw); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+	return yaml.Marshal(raw)
+}
@@ -0,0 +1,278 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dfaile/backstage-nobl9/internal/config"
+	"github.com/dfaile/backstage-nobl9/internal/errors"
+)
+
+// env returns a LookupEnv function backed by vars
+func env(vars map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		value, ok := vars[name]
+		return value, ok
+	}
+}
+
+func writeFile(t *testing.T, name, data string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(data), 0600))
+	return path
+}
+
+func TestLoadFormats(t *testing.T) {
+	files := map[string]string{
+		"config.yaml": `
+nobl9:
+  organization: acme
+  base_url: https://app.nobl9.com
+rate_limits:
+  initial_delay: 1000
+  max_delay: 30000
+  max_retries: 4
+help_resources:
+  user_setup: https://docs.nobl9.com/getting-started
+role_mappings:
+  - name: owner
+    role: project-owner
+  - name: viewer
+    role: project-viewer
+`,
+		"config.toml": `
+role_mappings = [
+  { name = "owner", role = "project-owner" },
+  { name = "viewer", role = "project-viewer" },
+]
+
+[nobl9]
+organization = "acme"
+base_url = "https://app.nobl9.com"
+
+[rate_limits]
+initial_delay = 1000
+max_delay = 30000
+max_retries = 4
+
+[help_resources]
+user_setup = "https://docs.nobl9.com/getting-started"
+`,
+		"config.json": `{
+  "nobl9": {"organization": "acme", "base_url": "https://app.nobl9.com"},
+  "rate_limits": {"initial_delay": 1000, "max_delay": 30000, "max_retries": 4},
+  "help_resources": {"user_setup": "https://docs.nobl9.com/getting-started"},
+  "role_mappings": [{"name": "owner", "role": "project-owner"}, {"name": "viewer", "role": "project-viewer"}]
+}`,
+	}
+
+	for name, data := range files {
+		t.Run(name, func(t *testing.T) {
+			path := writeFile(t, name, data)
+			cfg, err := config.Load(config.LoadOptions{Path: path, LookupEnv: env(nil)})
+			require.NoError(t, err)
+
+			assert.Equal(t, path, cfg.Source)
+			assert.Equal(t, "acme", cfg.Nobl9.Organization)
+			assert.Equal(t, config.RateLimitConfig{InitialDelay: 1000, MaxDelay: 30000, MaxRetries: 4}, cfg.RateLimits)
+			assert.Equal(t, "https://docs.nobl9.com/getting-started", cfg.HelpResources.UserSetup)
+			assert.Equal(t, []config.RoleMapping{{Name: "owner", Role: "project-owner"}, {Name: "viewer", Role: "project-viewer"}}, cfg.RoleMappings)
+
+			// Settings the file leaves out keep their defaults
+			assert.Equal(t, "warn", cfg.Logging.Level)
+			assert.Equal(t, 2, cfg.Retry[config.RetryTimeout].MaxAttempts)
+		})
+	}
+}
+
+func TestLoadLayers(t *testing.T) {
+	path := writeFile(t, "config.yaml", `
+nobl9:
+  organization: from-file
+logging:
+  level: info
+rate_limits:
+  max_retries: 2
+`)
+
+	cfg, err := config.Load(config.LoadOptions{
+		Path: path,
+		LookupEnv: env(map[string]string{
+			"NOBL9_SDK_ORGANIZATION":            "from-sdk-env",
+			"NOBL9_BOT_LOGGING_LEVEL":           "debug",
+			"NOBL9_BOT_APPLY_ALLOWED_KINDS":     "Project, RoleBinding",
+			"NOBL9_BOT_RATE_LIMITS_MAX_RETRIES": "5",
+		}),
+		Overrides: map[string]string{
+			"rate_limits.max_retries":     "7",
+			"retry.internal.max_attempts": "1",
+		},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "from-sdk-env", cfg.Nobl9.Organization)
+	assert.Equal(t, "debug", cfg.Logging.Level)
+	assert.Equal(t, []string{"Project", "RoleBinding"}, cfg.ApplyAllowedKinds)
+	assert.Equal(t, 7, cfg.RateLimits.MaxRetries)
+	assert.Equal(t, 1, cfg.Retry[config.RetryInternal].MaxAttempts)
+}
+
+func TestLoadLegacyCredentials(t *testing.T) {
+	path := writeFile(t, "config.json", `{"client_id": "id", "organization": "acme", "url": "https://app.nobl9.com"}`)
+
+	cfg, err := config.Load(config.LoadOptions{Path: path, LookupEnv: env(nil)})
+	require.NoError(t, err)
+	assert.Equal(t, config.Nobl9Config{ClientID: "id", Organization: "acme", URL: "https://app.nobl9.com"}, cfg.Nobl9)
+	assert.Empty(t, cfg.Organization)
+}
+
+func TestLoadExpandsHome(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	require.NoError(t, os.WriteFile(filepath.Join(home, "bot.yaml"), []byte(`
+templates_dir: ~/templates
+nobl9:
+  config_file: ~/.nobl9/config.toml
+logging:
+  output: stderr
+  sinks:
+    - type: file
+      path: ~/logs/bot.log
+audit:
+  path: ~/audit.jsonl
+plugins:
+  dir: ~bob/plugins
+tenants:
+  default: payments
+  orgs:
+    payments:
+      nobl9:
+        config_file: ~/payments.toml
+`), 0600))
+
+	cfg, err := config.Load(config.LoadOptions{
+		Path:      "~/bot.yaml",
+		LookupEnv: env(map[string]string{"NOBL9_BOT_APPLY_FILES_DIR": "~/manifests"}),
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, filepath.Join(home, "templates"), cfg.TemplatesDir)
+	assert.Equal(t, filepath.Join(home, ".nobl9", "config.toml"), cfg.Nobl9.ConfigFile)
+	assert.Equal(t, "stderr", cfg.Logging.Output)
+	assert.Equal(t, filepath.Join(home, "logs", "bot.log"), cfg.Logging.Sinks[0].Path)
+	assert.Equal(t, filepath.Join(home, "audit.jsonl"), cfg.Audit.Path)
+	assert.Equal(t, "~bob/plugins", cfg.Plugins.Dir)
+	assert.Equal(t, filepath.Join(home, "payments.toml"), cfg.Tenants.Orgs["payments"].Nobl9.ConfigFile)
+	assert.Equal(t, filepath.Join(home, "manifests"), cfg.ApplyFilesDir)
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		file      string
+		data      string
+		env       map[string]string
+		overrides map[string]string
+		want      []string
+	}{
+		{
+			name: "unknown settings",
+			file: "config.yaml",
+			data: "rate_limit:\n  max_retries: 3\nnobl9:\n  api_key: secret\n",
+			want: []string{"nobl9.api_key: unknown setting", "rate_limit: unknown setting"},
+		},
+		{
+			name: "wrong type",
+			file: "config.yaml",
+			data: "rate_limits:\n  max_retries: three\n",
+			want: []string{"rate_limits.max_retries must be a number, got string"},
+		},
+		{
+			name: "invalid values",
+			file: "config.yaml",
+			data: `
+rate_limits:
+  initial_delay: 2000
+  max_delay: 1000
+retry:
+  rate_limit:
+    max_attempts: 3
+role_mappings:
+  - name: admin
+    role: org-admin
+logging:
+  level: verbose
+naming_policy:
+  pattern: "["
+`,
+			want: []string{
+				"rate_limits.max_delay: must be at least initial_delay (2000)",
+				"retry.rate_limit: unknown error kind",
+				`role_mappings[0].role: must be one of project-owner, project-editor, project-viewer, got "org-admin"`,
+				`logging.level: must be debug, info, warn or error, got "verbose"`,
+				"naming_policy.pattern: invalid regular expression",
+			},
+		},
+		{
+			name: "unsupported format",
+			file: "config.ini",
+			data: "[nobl9]\n",
+			want: []string{"unsupported config file format '.ini'"},
+		},
+		{
+			name: "invalid environment variable",
+			file: "config.yaml",
+			data: "{}\n",
+			env:  map[string]string{"NOBL9_BOT_RATE_LIMITS_MAX_RETRIES": "lots"},
+			want: []string{"NOBL9_BOT_RATE_LIMITS_MAX_RETRIES", `rate_limits.max_retries must be a number, got "lots"`},
+		},
+		{
+			name:      "unknown override",
+			file:      "config.yaml",
+			data:      "{}\n",
+			overrides: map[string]string{"rate_limits.speed": "1"},
+			want:      []string{"rate_limits.speed: unknown setting"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeFile(t, tt.file, tt.data)
+			_, err := config.Load(config.LoadOptions{Path: path, LookupEnv: env(tt.env), Overrides: tt.overrides})
+			require.Error(t, err)
+			assert.True(t, errors.IsValidationError(err), err.Error())
+			for _, want := range tt.want {
+				assert.Contains(t, err.Error(), want)
+			}
+		})
+	}
+}
+
+func TestLoadMissingFile(t *testing.T) {
+	_, err := config.Load(config.LoadOptions{Path: filepath.Join(t.TempDir(), "missing.yaml"), LookupEnv: env(nil)})
+	assert.Error(t, err)
+}
+
+func TestSaveConfigRoundTrip(t *testing.T) {
+	for _, name := range []string{"bot.yaml", "bot.toml", "bot.json"} {
+		t.Run(name, func(t *testing.T) {
+			cfg := config.Default()
+			cfg.Nobl9.Organization = "acme"
+			cfg.ProjectLabels = []config.LabelPolicy{{Key: "team", Required: true}}
+
+			path := filepath.Join(t.TempDir(), name)
+			require.NoError(t, config.SaveConfig(cfg, path))
+
+			loaded, err := config.Load(config.LoadOptions{Path: path, LookupEnv: env(nil)})
+			require.NoError(t, err)
+			assert.Equal(t, "acme", loaded.Nobl9.Organization)
+			assert.Equal(t, cfg.ProjectLabels, loaded.ProjectLabels)
+			assert.Equal(t, cfg.RoleMappings, loaded.RoleMappings)
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+)
+
+// ExpandHome replaces a leading ~ in path with the current user's home directory.
+// Paths naming another user's home, such as ~bob/bot.yaml, are left as they are.
+func ExpandHome(path string) (string, error) {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path, nil
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to expand %s: %w", path, err)
+	}
+	return filepath.Join(homeDir, path[1:]), nil
+}
+
+// expandPaths expands ~ in every setting tagged path:"true". The shell expands ~
+// on the command line but nothing does in a config file or environment variable.
+func (c *Config) expandPaths() error {
+	var err error
+	walkPaths(reflect.ValueOf(c).Elem(), "", func(path string, value *string) {
+		if err != nil {
+			return
+		}
+		expanded, expandErr := ExpandHome(*value)
+		if expandErr != nil {
+			err = fmt.Errorf("%s: %w", path, expandErr)
+			return
+		}
+		*value = expanded
+	})
+	return err
+}
+
+// walkPaths calls fn with the setting path and address of every string field tagged
+// path:"true" reachable from v, including those in slices and maps of structs
+func walkPaths(v reflect.Value, prefix string, fn func(path string, value *string)) {
+	switch v.Kind() {
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name := jsonName(field)
+			if name == "" {
+				continue
+			}
+			path := name
+			if prefix != "" {
+				path = prefix + "." + name
+			}
+			value := v.Field(i)
+			if field.Tag.Get("path") == "true" && value.Kind() == reflect.String {
+				fn(path, value.Addr().Interface().(*string))
+				continue
+			}
+			walkPaths(value, path, fn)
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			walkPaths(v.Index(i), fmt.Sprintf("%s.%d", prefix, i), fn)
+		}
+	case reflect.Map:
+		if v.Type().Elem().Kind() != reflect.Struct {
+			return
+		}
+		// Map values cannot be changed in place, so each is copied and stored back
+		for _, key := range v.MapKeys() {
+			elem := reflect.New(v.Type().Elem()).Elem()
+			elem.Set(v.MapIndex(key))
+			walkPaths(elem, prefix+"."+key.String(), fn)
+			v.SetMapIndex(key, elem)
+		}
+	}
+}
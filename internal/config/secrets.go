@@ -0,0 +1,174 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/dfaile/backstage-nobl9/internal/errors"
+	"github.com/dfaile/backstage-nobl9/internal/logging"
+	"github.com/dfaile/backstage-nobl9/internal/secrets"
+)
+
+// EnvFileSuffix marks an environment variable naming a file that holds the value of
+// the variable without the suffix, e.g. NOBL9_SDK_CLIENT_SECRET_FILE=/run/secrets/nobl9
+const EnvFileSuffix = "_FILE"
+
+// lookupEnvFile returns the value of the named variable, reading it from the file
+// named by its _FILE variable when that is set instead
+func lookupEnvFile(lookup func(string) (string, bool), name string) (string, bool, error) {
+	value, ok := lookup(name)
+	path, fileOK := lookup(name + EnvFileSuffix)
+	if !fileOK || path == "" {
+		return value, ok, nil
+	}
+	if ok && value != "" {
+		return "", false, errors.NewValidationError(fmt.Sprintf("set either %s or %s%s, not both", name, name, EnvFileSuffix), nil)
+	}
+	value, err := secrets.ReadFile(path)
+	if err != nil {
+		return "", false, errors.NewValidationError(fmt.Sprintf("invalid environment variable %s%s", name, EnvFileSuffix), err)
+	}
+	return value, true, nil
+}
+
+// resolveSecrets replaces the secret references in c with the secrets they point to.
+// Every secret, referenced or not, is registered with the logging package so it is
+// redacted from logs, errors and chat responses.
+func (c *Config) resolveSecrets(opts LoadOptions, lookup func(string) (string, bool)) error {
+	ctx := context.Background()
+	resolver := secrets.NewResolver()
+	resolver.Register("env", secrets.EnvProvider{LookupEnv: lookup})
+
+	var problems []string
+
+	// The Vault token is resolved first, it may itself live in a file
+	vault := c.Secrets.Vault
+	if vault.Address == "" {
+		vault.Address, _ = lookup("VAULT_ADDR")
+	}
+	if vault.Token == "" {
+		vault.Token, _ = lookup("VAULT_TOKEN")
+	}
+	if vault.Address != "" {
+		token, err := resolver.Resolve(ctx, vault.Token)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("secrets.vault.token: failed to resolve secret: %v", err))
+		}
+		provider := secrets.NewVaultProvider(vault.Address, token)
+		provider.Namespace = vault.Namespace
+		resolver.Register("vault", provider)
+	}
+
+	for scheme, provider := range opts.SecretProviders {
+		resolver.Register(scheme, provider)
+	}
+
+	walkSecrets(reflect.ValueOf(c).Elem(), "", func(path string, value *string) {
+		if *value == "" {
+			return
+		}
+		if strings.HasPrefix(*value, "vault:") && !resolver.IsReference(*value) {
+			problems = append(problems, fmt.Sprintf("%s: vault references need secrets.vault.address or VAULT_ADDR", path))
+			return
+		}
+		secret, err := resolver.Resolve(ctx, *value)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: failed to resolve secret: %v", path, err))
+			return
+		}
+		*value = secret
+	})
+	if len(problems) > 0 {
+		return errors.NewValidationError(fmt.Sprintf("invalid config:\n  - %s", strings.Join(problems, "\n  - ")), nil)
+	}
+	return nil
+}
+
+// walkSecrets calls fn with the dotted path and value of every setting tagged
+// secret:"true" in v, including those in maps such as tenants.orgs and the values
+// of secret maps
+func walkSecrets(v reflect.Value, prefix string, fn func(path string, value *string)) {
+	switch v.Kind() {
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name := jsonName(field)
+			if name == "" {
+				continue
+			}
+			path := name
+			if prefix != "" {
+				path = prefix + "." + name
+			}
+			value := v.Field(i)
+			if field.Tag.Get("secret") == "true" && value.Kind() == reflect.String {
+				fn(path, value.Addr().Interface().(*string))
+				continue
+			}
+			// Every value of a secret map, such as tracing.headers, is a secret
+			if field.Tag.Get("secret") == "true" && value.Kind() == reflect.Map && value.Type().Elem().Kind() == reflect.String {
+				for _, key := range value.MapKeys() {
+					secret := value.MapIndex(key).String()
+					fn(path+"."+key.String(), &secret)
+					value.SetMapIndex(key, reflect.ValueOf(secret))
+				}
+				continue
+			}
+			walkSecrets(value, path, fn)
+		}
+	case reflect.Map:
+		if v.Type().Elem().Kind() != reflect.Struct {
+			return
+		}
+		// Map values cannot be changed in place, so each is copied and stored back
+		for _, key := range v.MapKeys() {
+			elem := reflect.New(v.Type().Elem()).Elem()
+			elem.Set(v.MapIndex(key))
+			walkSecrets(elem, prefix+"."+key.String(), fn)
+			v.SetMapIndex(key, elem)
+		}
+	}
+}
+
+// Redacted returns a copy of c with every secret replaced by a placeholder, safe to
+// print or log
+func (c *Config) Redacted() *Config {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return &Config{}
+	}
+	result := &Config{}
+	if err := json.Unmarshal(data, result); err != nil {
+		return &Config{}
+	}
+	result.Source = c.Source
+	walkSecrets(reflect.ValueOf(result).Elem(), "", func(_ string, value *string) {
+		if *value != "" {
+			*value = logging.Redacted
+		}
+	})
+	return result
+}
+
+// String renders the config as JSON with its secrets redacted
+func (c *Config) String() string {
+	data, err := json.MarshalIndent(c.Redacted(), "", "  ")
+	if err != nil {
+		return fmt.Sprintf("invalid config: %v", err)
+	}
+	return string(data)
+}
+
+// String describes the Nobl9 connection without its client secret
+func (n Nobl9Config) String() string {
+	secret := ""
+	if n.ClientSecret != "" {
+		secret = logging.Redacted
+	}
+	return fmt.Sprintf("{client_id:%s client_secret:%s organization:%s base_url:%s context:%s config_file:%s}",
+		n.ClientID, secret, n.Organization, n.URL, n.Context, n.ConfigFile)
+}
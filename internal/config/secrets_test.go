@@ -0,0 +1,130 @@
+package config_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dfaile/backstage-nobl9/internal/config"
+	"github.com/dfaile/backstage-nobl9/internal/errors"
+	"github.com/dfaile/backstage-nobl9/internal/logging"
+	"github.com/dfaile/backstage-nobl9/internal/secrets"
+	"github.com/dfaile/backstage-nobl9/internal/secrets/vaulttest"
+)
+
+func TestLoadEnvFile(t *testing.T) {
+	secretFile := writeFile(t, "client-secret", "from-secret-file\n")
+	path := writeFile(t, "config.yaml", "{}\n")
+
+	cfg, err := config.Load(config.LoadOptions{
+		Path: path,
+		LookupEnv: env(map[string]string{
+			"NOBL9_SDK_CLIENT_ID":          "client-id",
+			"NOBL9_SDK_CLIENT_SECRET_FILE": secretFile,
+		}),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "client-id", cfg.Nobl9.ClientID)
+	assert.Equal(t, "from-secret-file", cfg.Nobl9.ClientSecret)
+
+	_, err = config.Load(config.LoadOptions{
+		Path: path,
+		LookupEnv: env(map[string]string{
+			"NOBL9_BOT_NOBL9_CLIENT_SECRET":      "inline",
+			"NOBL9_BOT_NOBL9_CLIENT_SECRET_FILE": secretFile,
+		}),
+	})
+	require.Error(t, err)
+	assert.True(t, errors.IsValidationError(err))
+	assert.Contains(t, err.Error(), "set either NOBL9_BOT_NOBL9_CLIENT_SECRET or NOBL9_BOT_NOBL9_CLIENT_SECRET_FILE")
+}
+
+func TestLoadSecretReferences(t *testing.T) {
+	vault := vaulttest.NewServer("vault-root-token")
+	defer vault.Close()
+	vault.Put("secret/nobl9", map[string]string{"client_secret": "from-vault-secret"})
+
+	tokenFile := writeFile(t, "vault-token", "vault-root-token\n")
+	path := writeFile(t, "config.yaml", fmt.Sprintf(`
+nobl9:
+  client_id: client-id
+  client_secret: vault:secret/data/nobl9#client_secret
+secrets:
+  vault:
+    address: %s
+    token: file:%s
+tenants:
+  default: search
+  orgs:
+    search:
+      nobl9:
+        client_id: search-id
+        client_secret: env:SEARCH_SECRET
+`, vault.URL, tokenFile))
+
+	cfg, err := config.Load(config.LoadOptions{
+		Path:      path,
+		LookupEnv: env(map[string]string{"SEARCH_SECRET": "from-env-secret"}),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "from-vault-secret", cfg.Nobl9.ClientSecret)
+	assert.Equal(t, "from-env-secret", cfg.Tenants.Orgs["search"].Nobl9.ClientSecret)
+
+	// Resolved secrets never make it into logs or printed config
+	assert.Equal(t, "client "+logging.Redacted+" rejected", logging.Redact("client from-vault-secret rejected"))
+	assert.NotContains(t, cfg.String(), "from-vault-secret")
+	assert.NotContains(t, cfg.String(), "from-env-secret")
+	assert.Contains(t, cfg.String(), `"client_id": "search-id"`)
+	assert.NotContains(t, fmt.Sprint(cfg.Nobl9), "from-vault-secret")
+	assert.Equal(t, "from-vault-secret", cfg.Nobl9.ClientSecret, "redacting a copy leaves the config alone")
+}
+
+func TestLoadSecretReferenceErrors(t *testing.T) {
+	vault := vaulttest.NewServer("vault-root-token")
+	defer vault.Close()
+
+	path := writeFile(t, "config.yaml", fmt.Sprintf(`
+nobl9:
+  client_secret: vault:secret/data/missing#client_secret
+secrets:
+  vault:
+    address: %s
+    token: wrong-token
+tenants:
+  orgs:
+    search:
+      nobl9:
+        client_secret: env:UNSET_SECRET
+`, vault.URL))
+
+	_, err := config.Load(config.LoadOptions{Path: path, LookupEnv: env(nil)})
+	require.Error(t, err)
+	assert.True(t, errors.IsValidationError(err))
+	assert.Contains(t, err.Error(), "nobl9.client_secret: failed to resolve secret")
+	assert.Contains(t, err.Error(), "vault denied access to secret/data/missing")
+	assert.Contains(t, err.Error(), "tenants.orgs.search.nobl9.client_secret: failed to resolve secret")
+	assert.Contains(t, err.Error(), "environment variable UNSET_SECRET is not set")
+
+	path = writeFile(t, "config.yaml", "nobl9:\n  client_secret: vault:secret/data/nobl9#client_secret\n")
+	_, err = config.Load(config.LoadOptions{Path: path, LookupEnv: env(nil)})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "nobl9.client_secret: vault references need secrets.vault.address or VAULT_ADDR")
+}
+
+func TestLoadSecretProviders(t *testing.T) {
+	path := writeFile(t, "config.yaml", "nobl9:\n  client_secret: custom:nobl9\n")
+	provider := secrets.ProviderFunc(func(_ context.Context, ref string) (string, error) {
+		return "custom-" + ref + "-secret", nil
+	})
+
+	cfg, err := config.Load(config.LoadOptions{
+		Path:            path,
+		LookupEnv:       env(nil),
+		SecretProviders: map[string]secrets.Provider{"custom": provider},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "custom-nobl9-secret", cfg.Nobl9.ClientSecret)
+}
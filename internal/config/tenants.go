@@ -0,0 +1,57 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// TenantsConfig serves several Nobl9 organizations from one bot. Each caller is
+// matched to a tenant by an HTTP header, their identity or their chat channel, in
+// that order. Tenant mode is off when no tenants are defined.
+type TenantsConfig struct {
+	Header  string                  `json:"header,omitempty"`  // HTTP header naming the caller's tenant, e.g. X-Nobl9-Tenant
+	Default string                  `json:"default,omitempty"` // Tenant for callers no rule matches, they are refused when empty
+	Orgs    map[string]TenantConfig `json:"orgs,omitempty"`    // Tenants by name
+}
+
+// TenantConfig describes one tenant's Nobl9 organization and who belongs to it
+type TenantConfig struct {
+	Nobl9             Nobl9Config `json:"nobl9,omitempty"`
+	Callers           []string    `json:"callers,omitempty"`             // Caller identities, source:* matches every caller from a source
+	Channels          []string    `json:"channels,omitempty"`            // Chat channel IDs
+	Domains           []string    `json:"domains,omitempty"`             // Domains of callers' platform-verified emails, e.g. payments.example.com
+	RequestsPerSecond int         `json:"requests_per_second,omitempty"` // Overrides rate_limits.requests_per_second
+}
+
+// Enabled reports whether the bot serves several tenants
+func (t TenantsConfig) Enabled() bool {
+	return len(t.Orgs) > 0
+}
+
+// ForTenant returns the config one tenant's bot runs with: the shared policies with
+// the tenant's Nobl9 connection, rate limit and its own audit log file
+func (c *Config) ForTenant(name string) (*Config, error) {
+	tenant, ok := c.Tenants.Orgs[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown tenant '%s'", name)
+	}
+
+	auditPath := c.Audit.Path
+	if auditPath == "" {
+		var err error
+		if auditPath, err = DefaultAuditPath(); err != nil {
+			return nil, err
+		}
+	}
+	ext := filepath.Ext(auditPath)
+
+	result := *c
+	result.Nobl9 = tenant.Nobl9
+	if tenant.RequestsPerSecond > 0 {
+		result.RateLimits.RequestsPerSecond = tenant.RequestsPerSecond
+	}
+	result.Audit.Path = fmt.Sprintf("%s-%s%s", strings.TrimSuffix(auditPath, ext), name, ext)
+	result.Tenants = TenantsConfig{}
+	return &result, nil
+}
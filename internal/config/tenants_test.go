@@ -0,0 +1,82 @@
+package config_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dfaile/backstage-nobl9/internal/config"
+	"github.com/dfaile/backstage-nobl9/internal/errors"
+)
+
+func TestForTenant(t *testing.T) {
+	path := writeFile(t, "config.yaml", `
+rate_limits:
+  requests_per_second: 20
+audit:
+  path: /var/lib/nobl9-bot/audit.jsonl
+tenants:
+  header: X-Nobl9-Tenant
+  default: payments
+  orgs:
+    payments:
+      nobl9:
+        context: payments
+      callers: ["slack:*"]
+      requests_per_second: 5
+    search:
+      nobl9:
+        client_id: search-id
+        client_secret: search-secret
+      channels: [C123]
+`)
+	cfg, err := config.Load(config.LoadOptions{Path: path, LookupEnv: env(nil)})
+	require.NoError(t, err)
+	require.True(t, cfg.Tenants.Enabled())
+
+	payments, err := cfg.ForTenant("payments")
+	require.NoError(t, err)
+	assert.Equal(t, config.Nobl9Config{Context: "payments"}, payments.Nobl9)
+	assert.Equal(t, 5, payments.RateLimits.RequestsPerSecond)
+	assert.Equal(t, filepath.FromSlash("/var/lib/nobl9-bot/audit-payments.jsonl"), payments.Audit.Path)
+	assert.False(t, payments.Tenants.Enabled())
+
+	search, err := cfg.ForTenant("search")
+	require.NoError(t, err)
+	assert.Equal(t, "search-id", search.Nobl9.ClientID)
+	assert.Equal(t, 20, search.RateLimits.RequestsPerSecond)
+
+	_, err = cfg.ForTenant("billing")
+	assert.Error(t, err)
+}
+
+func TestValidateTenants(t *testing.T) {
+	path := writeFile(t, "config.yaml", `
+gitops:
+  repo_dir: /srv/manifests
+tenants:
+  header: "X Tenant"
+  default: billing
+  orgs:
+    Payments:
+      callers: ["slack:U1"]
+      requests_per_second: -1
+    search:
+      callers: ["slack:U1"]
+`)
+	_, err := config.Load(config.LoadOptions{Path: path, LookupEnv: env(nil)})
+	require.Error(t, err)
+	assert.True(t, errors.IsValidationError(err))
+	for _, want := range []string{
+		`tenants.header: must be an HTTP header name, got "X Tenant"`,
+		"tenants.default: tenant 'billing' is not defined in tenants.orgs",
+		"gitops.repo_dir: GitOps mode cannot be used with tenants",
+		"tenants.orgs.Payments: tenant names must be lowercase letters, digits and dashes",
+		"tenants.orgs.Payments.requests_per_second: must not be negative",
+		`tenants.orgs.search.callers[0]: "slack:U1" is already in tenant 'Payments'`,
+	} {
+		assert.Contains(t, err.Error(), want)
+	}
+}
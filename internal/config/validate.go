@@ -0,0 +1,301 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/dfaile/backstage-nobl9/internal/errors"
+	"github.com/dfaile/backstage-nobl9/internal/logging"
+)
+
+// Validate checks the configuration and reports every problem found, each prefixed
+// with the path of the setting at fault
+func (c *Config) Validate() error {
+	var problems []string
+	add := func(path, format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf("%s: %s", path, fmt.Sprintf(format, args...)))
+	}
+
+	if c.Nobl9.URL != "" && !isURL(c.Nobl9.URL) {
+		add("nobl9.base_url", "must be an http or https URL, got %q", c.Nobl9.URL)
+	}
+
+	limits := c.RateLimits
+	if limits.RequestsPerSecond < 0 {
+		add("rate_limits.requests_per_second", "must not be negative")
+	}
+	if limits.CommandsPerMinute < 0 {
+		add("rate_limits.commands_per_minute", "must not be negative")
+	}
+	if limits.InitialDelay < 0 {
+		add("rate_limits.initial_delay", "must not be negative")
+	}
+	if limits.MaxDelay < limits.InitialDelay {
+		add("rate_limits.max_delay", "must be at least initial_delay (%d)", limits.InitialDelay)
+	}
+	if limits.MaxRetries < 0 {
+		add("rate_limits.max_retries", "must not be negative")
+	}
+
+	kinds := make([]string, 0, len(c.Retry))
+	for kind := range c.Retry {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	for _, kind := range kinds {
+		policy := c.Retry[kind]
+		path := "retry." + kind
+		if kind != RetryTimeout && kind != RetryInternal {
+			add(path, "unknown error kind, use %s or %s. Rate limit retries are set in rate_limits", RetryTimeout, RetryInternal)
+			continue
+		}
+		if policy.MaxAttempts < 0 {
+			add(path+".max_attempts", "must not be negative")
+		}
+		if policy.Delay < 0 {
+			add(path+".delay", "must not be negative")
+		}
+		if policy.MaxDelay != 0 && policy.MaxDelay < policy.Delay {
+			add(path+".max_delay", "must be at least delay (%d)", policy.Delay)
+		}
+	}
+
+	if c.HelpResources.UserSetup != "" && !isURL(c.HelpResources.UserSetup) {
+		add("help_resources.user_setup", "must be an http or https URL, got %q", c.HelpResources.UserSetup)
+	}
+	if c.HelpResources.ErrorHelp != "" && !isURL(c.HelpResources.ErrorHelp) {
+		add("help_resources.error_help", "must be an http or https URL, got %q", c.HelpResources.ErrorHelp)
+	}
+
+	if len(c.RoleMappings) == 0 {
+		add("role_mappings", "at least one role is required")
+	}
+	names := make(map[string]bool)
+	for i, mapping := range c.RoleMappings {
+		path := fmt.Sprintf("role_mappings[%d]", i)
+		name := strings.ToLower(mapping.Name)
+		switch {
+		case name == "":
+			add(path+".name", "is required")
+		case names[name]:
+			add(path+".name", "%q is listed more than once", mapping.Name)
+		}
+		names[name] = true
+		if !contains(ProjectRoles, mapping.Role) {
+			add(path+".role", "must be one of %s, got %q", strings.Join(ProjectRoles, ", "), mapping.Role)
+		}
+	}
+
+	if !contains([]string{"debug", "info", "warn", "error"}, c.Logging.Level) {
+		add("logging.level", "must be debug, info, warn or error, got %q", c.Logging.Level)
+	}
+	logFormats := []string{"json", "console", "pretty"}
+	if !contains(logFormats, c.Logging.Format) {
+		add("logging.format", "must be json, console or pretty, got %q", c.Logging.Format)
+	}
+	if c.Logging.Output == "" && len(c.Logging.Sinks) == 0 {
+		add("logging.output", "must be stdout, stderr or a file path")
+	}
+	for i, sink := range c.Logging.Sinks {
+		path := fmt.Sprintf("logging.sinks[%d]", i)
+		switch sink.Type {
+		case "stdout", "stderr":
+		case "file":
+			if sink.Path == "" {
+				add(path+".path", "is required for file sinks")
+			}
+		case "udp":
+			if _, _, err := net.SplitHostPort(sink.Address); err != nil {
+				add(path+".address", "must be host:port, got %q", sink.Address)
+			}
+		default:
+			add(path+".type", "must be stdout, stderr, file or udp, got %q", sink.Type)
+		}
+		if sink.Format != "" && !contains(logFormats, sink.Format) {
+			add(path+".format", "must be json, console or pretty, got %q", sink.Format)
+		}
+		if sink.MaxBytes < 0 {
+			add(path+".max_bytes", "must not be negative")
+		}
+		if sink.MaxBackups < 0 {
+			add(path+".max_backups", "must not be negative")
+		}
+	}
+	packages := make([]string, 0, len(c.Logging.Levels))
+	for pkg := range c.Logging.Levels {
+		packages = append(packages, pkg)
+	}
+	sort.Strings(packages)
+	for _, pkg := range packages {
+		if _, err := logging.ParseLevel(c.Logging.Levels[pkg]); err != nil {
+			add("logging.levels."+pkg, "must be debug, info, warn or error, got %q", c.Logging.Levels[pkg])
+		}
+	}
+	fields := c.Logging.Fields
+	if fields.MaxLength < 0 {
+		add("logging.fields.max_length", "must not be negative")
+	}
+	listed := make(map[string]string)
+	for _, list := range []struct {
+		name string
+		keys []string
+	}{{"hash", fields.Hash}, {"truncate", fields.Truncate}, {"drop", fields.Drop}, {"keep", fields.Keep}} {
+		for _, key := range list.keys {
+			if other, ok := listed[key]; ok && other != list.name {
+				add("logging.fields."+list.name, "field '%s' is already listed in %s", key, other)
+				continue
+			}
+			listed[key] = list.name
+		}
+	}
+
+	naming := c.NamingPolicy
+	if naming.Pattern != "" {
+		if _, err := regexp.Compile(naming.Pattern); err != nil {
+			add("naming_policy.pattern", "invalid regular expression: %v", err)
+		}
+	}
+	if naming.MinLength < 0 {
+		add("naming_policy.min_length", "must not be negative")
+	}
+	if naming.MaxLength < 0 || naming.MaxLength > 63 {
+		add("naming_policy.max_length", "must be between 0 and 63, the longest name Nobl9 accepts")
+	}
+	if naming.MaxLength > 0 && naming.MinLength > naming.MaxLength {
+		add("naming_policy.min_length", "must not be more than max_length (%d)", naming.MaxLength)
+	}
+
+	keys := make(map[string]bool)
+	for i, policy := range c.ProjectLabels {
+		path := fmt.Sprintf("project_labels[%d]", i)
+		switch {
+		case policy.Key == "":
+			add(path+".key", "is required")
+		case keys[policy.Key]:
+			add(path+".key", "%q is listed more than once", policy.Key)
+		}
+		keys[policy.Key] = true
+		if policy.Default != "" && !policy.Allows(policy.Default) {
+			add(path+".default", "%q is not one of the allowed values", policy.Default)
+		}
+	}
+
+	if c.Approvals.MaxUsers < 0 {
+		add("approvals.max_users", "must not be negative")
+	}
+	for i, role := range c.Approvals.Roles {
+		if !contains(ProjectRoles, role) {
+			add(fmt.Sprintf("approvals.roles[%d]", i), "must be one of %s, got %q", strings.Join(ProjectRoles, ", "), role)
+		}
+	}
+	if c.Tracing.Endpoint != "" && !isURL(c.Tracing.Endpoint) {
+		add("tracing.endpoint", "must be an http or https URL, got %q", c.Tracing.Endpoint)
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		add("tracing.sample_ratio", "must be between 0 and 1, got %v", c.Tracing.SampleRatio)
+	}
+	if c.Audit.MaxBytes < 0 {
+		add("audit.max_bytes", "must not be negative")
+	}
+	if c.Audit.Key != "" && len(c.Audit.Key) < MinAuditKeyLength {
+		add("audit.key", "must be at least %d characters long", MinAuditKeyLength)
+	}
+	if c.Plugins.Timeout < 0 {
+		add("plugins.timeout", "must not be negative")
+	}
+
+	if c.Tenants.Enabled() {
+		c.validateTenants(add)
+	}
+
+	if len(problems) > 0 {
+		return errors.NewValidationError(fmt.Sprintf("invalid config:\n  - %s", strings.Join(problems, "\n  - ")), nil)
+	}
+	return nil
+}
+
+// tenantNamePattern matches tenant names, which also name each tenant's audit log file
+var tenantNamePattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]*[a-z0-9])?$`)
+
+// headerPattern matches HTTP header names
+var headerPattern = regexp.MustCompile(`^[A-Za-z0-9-]+$`)
+
+// validateTenants checks the tenants section, reporting each problem with add
+func (c *Config) validateTenants(add func(path, format string, args ...interface{})) {
+	tenants := c.Tenants
+	if tenants.Header != "" && !headerPattern.MatchString(tenants.Header) {
+		add("tenants.header", "must be an HTTP header name, got %q", tenants.Header)
+	}
+	if _, ok := tenants.Orgs[tenants.Default]; tenants.Default != "" && !ok {
+		add("tenants.default", "tenant '%s' is not defined in tenants.orgs", tenants.Default)
+	}
+	if c.GitOps.Enabled() {
+		add("gitops.repo_dir", "GitOps mode cannot be used with tenants")
+	}
+
+	names := make([]string, 0, len(tenants.Orgs))
+	for name := range tenants.Orgs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// A caller, channel or domain in two tenants would make routing ambiguous
+	callers := make(map[string]string)
+	channels := make(map[string]string)
+	domains := make(map[string]string)
+	for _, name := range names {
+		tenant := tenants.Orgs[name]
+		path := "tenants.orgs." + name
+		if !tenantNamePattern.MatchString(name) {
+			add(path, "tenant names must be lowercase letters, digits and dashes")
+		}
+		if tenant.Nobl9.URL != "" && !isURL(tenant.Nobl9.URL) {
+			add(path+".nobl9.base_url", "must be an http or https URL, got %q", tenant.Nobl9.URL)
+		}
+		if tenant.RequestsPerSecond < 0 {
+			add(path+".requests_per_second", "must not be negative")
+		}
+		for i, caller := range tenant.Callers {
+			if other, ok := callers[caller]; ok {
+				add(fmt.Sprintf("%s.callers[%d]", path, i), "%q is already in tenant '%s'", caller, other)
+			}
+			callers[caller] = name
+		}
+		for i, channel := range tenant.Channels {
+			if other, ok := channels[channel]; ok {
+				add(fmt.Sprintf("%s.channels[%d]", path, i), "%q is already in tenant '%s'", channel, other)
+			}
+			channels[channel] = name
+		}
+		for i, domain := range tenant.Domains {
+			domain = strings.ToLower(domain)
+			if domain == "" || strings.Contains(domain, "@") {
+				add(fmt.Sprintf("%s.domains[%d]", path, i), "must be an email domain, got %q", tenant.Domains[i])
+			}
+			if other, ok := domains[domain]; ok {
+				add(fmt.Sprintf("%s.domains[%d]", path, i), "%q is already in tenant '%s'", domain, other)
+			}
+			domains[domain] = name
+		}
+	}
+}
+
+// isURL reports whether value is an absolute http or https URL
+func isURL(value string) bool {
+	u, err := url.Parse(value)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// contains reports whether values holds value
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,101 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"sync"
+	"time"
+)
+
+// DefaultWatchInterval is how often a Watcher checks the config file for changes
+const DefaultWatchInterval = 2 * time.Second
+
+// Watcher reloads the config when its file changes or when Reload is triggered, and
+// hands each valid new config to apply. Invalid config is reported and not applied.
+type Watcher struct {
+	options LoadOptions
+	apply   func(*Config) error
+	trigger chan struct{}
+
+	mu      sync.Mutex
+	content []byte // File content last loaded, to spot changes
+}
+
+// NewWatcher creates a watcher that loads config with options. options.Path should
+// name the file the running config came from, see Config.Source.
+func NewWatcher(options LoadOptions, apply func(*Config) error) *Watcher {
+	w := &Watcher{
+		options: options,
+		apply:   apply,
+		trigger: make(chan struct{}, 1),
+	}
+	w.content, _ = w.read()
+	return w
+}
+
+// Trigger asks a running watcher to reload even if the file is unchanged
+func (w *Watcher) Trigger() {
+	select {
+	case w.trigger <- struct{}{}:
+	default: // A reload is already pending
+	}
+}
+
+// Reload loads and validates the config and applies it. The running config is left
+// in place when loading or applying fails.
+func (w *Watcher) Reload() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	content, _ := w.read()
+	w.content = content
+
+	cfg, err := Load(w.options)
+	if err != nil {
+		return err
+	}
+	return w.apply(cfg)
+}
+
+// Run checks the file every interval until ctx is done. Changes and triggers are
+// reloaded and failures are passed to onError.
+func (w *Watcher) Run(ctx context.Context, interval time.Duration, onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-w.trigger:
+		case <-ticker.C:
+			if !w.changed() {
+				continue
+			}
+		}
+		if err := w.Reload(); err != nil {
+			onError(err)
+		}
+	}
+}
+
+// changed reports whether the file content differs from the last load
+func (w *Watcher) changed() bool {
+	content, err := w.read()
+	if err != nil {
+		// A file being replaced can briefly be missing, wait for the new one
+		return false
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return !bytes.Equal(content, w.content)
+}
+
+// read returns the watched file's content
+func (w *Watcher) read() ([]byte, error) {
+	if w.options.Path == "" {
+		return nil, os.ErrNotExist
+	}
+	return os.ReadFile(w.options.Path)
+}
@@ -0,0 +1,65 @@
+package config_test
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dfaile/backstage-nobl9/internal/config"
+)
+
+func TestWatcher(t *testing.T) {
+	path := writeFile(t, "config.yaml", "frontend:\n  prompt: \"one> \"\n")
+
+	applied := make(chan *config.Config, 1)
+	rejected := make(chan error, 1)
+	watcher := config.NewWatcher(config.LoadOptions{Path: path, LookupEnv: env(nil)}, func(cfg *config.Config) error {
+		applied <- cfg
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go watcher.Run(ctx, 10*time.Millisecond, func(err error) { rejected <- err })
+
+	// Nothing is reloaded until the file changes
+	select {
+	case <-applied:
+		t.Fatal("unchanged config was reloaded")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	require.NoError(t, os.WriteFile(path, []byte("frontend:\n  prompt: \"two> \"\n"), 0600))
+	select {
+	case cfg := <-applied:
+		assert.Equal(t, "two> ", cfg.Frontend.Prompt)
+	case <-time.After(time.Second):
+		t.Fatal("changed config was not reloaded")
+	}
+
+	// Invalid config is reported and never applied
+	require.NoError(t, os.WriteFile(path, []byte("logging:\n  level: verbose\n"), 0600))
+	select {
+	case err := <-rejected:
+		assert.Contains(t, err.Error(), "logging.level")
+	case <-applied:
+		t.Fatal("invalid config was applied")
+	case <-time.After(time.Second):
+		t.Fatal("invalid config was not reported")
+	}
+
+	// A trigger reloads without a file change
+	require.NoError(t, os.WriteFile(path, []byte("frontend:\n  prompt: \"three> \"\n"), 0600))
+	<-applied
+	watcher.Trigger()
+	select {
+	case cfg := <-applied:
+		assert.Equal(t, "three> ", cfg.Frontend.Prompt)
+	case <-time.After(time.Second):
+		t.Fatal("triggered reload did not happen")
+	}
+}
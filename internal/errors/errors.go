@@ -3,6 +3,8 @@ package errors
 import (
 	"errors"
 	"fmt"
+
+	"github.com/dfaile/backstage-nobl9/internal/logging"
 )
 
 // ErrorType represents the type of error
@@ -21,6 +23,8 @@ const (
 	ErrorTypeInternal ErrorType = "internal_error"
 	// ErrorTypeTimeout represents timeout errors
 	ErrorTypeTimeout ErrorType = "timeout_error"
+	// ErrorTypePermission represents errors for actions the user is not allowed to take
+	ErrorTypePermission ErrorType = "permission_error"
 )
 
 // BotError represents a bot-specific error
@@ -30,12 +34,13 @@ type BotError struct {
 	Err     error
 }
 
-// Error implements the error interface
+// Error implements the error interface. Secrets and tokens in the message or the
+// underlying error are redacted, since errors end up in logs and chat responses.
 func (e *BotError) Error() string {
 	if e.Err != nil {
-		return fmt.Sprintf("%s: %s (%v)", e.Type, e.Message, e.Err)
+		return logging.Redact(fmt.Sprintf("%s: %s (%v)", e.Type, e.Message, e.Err))
 	}
-	return fmt.Sprintf("%s: %s", e.Type, e.Message)
+	return logging.Redact(fmt.Sprintf("%s: %s", e.Type, e.Message))
 }
 
 // Unwrap returns the underlying error
@@ -115,6 +120,18 @@ func IsTimeoutError(err error) bool {
 	return botErr.Type == ErrorTypeTimeout
 }
 
+// IsPermissionError checks if the error is a permission error
+func IsPermissionError(err error) bool {
+	var botErr *BotError
+	if err == nil {
+		return false
+	}
+	if ok := errors.As(err, &botErr); !ok {
+		return false
+	}
+	return botErr.Type == ErrorTypePermission
+}
+
 // NewValidationError creates a new validation error
 func NewValidationError(message string, err error) error {
 	return &BotError{
@@ -167,4 +184,13 @@ func NewTimeoutError(message string, err error) error {
 		Message: message,
 		Err:     err,
 	}
-} 
\ No newline at end of file
+}
+
+// NewPermissionError creates a new permission error
+func NewPermissionError(message string, err error) error {
+	return &BotError{
+		Type:    ErrorTypePermission,
+		Message: message,
+		Err:     err,
+	}
+}
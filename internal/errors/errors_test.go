@@ -1,7 +1,7 @@
 package errors_test
 
 import (
-	"errors"
+	stderrors "errors"
 	"testing"
 
 	"github.com/dfaile/backstage-nobl9/internal/errors"
@@ -9,24 +9,24 @@ import (
 
 func TestBotError(t *testing.T) {
 	// Test error without underlying error
-	err := NewValidationError("invalid input", nil)
+	err := errors.NewValidationError("invalid input", nil)
 	if err.Error() != "validation_error: invalid input" {
 		t.Errorf("Expected error message 'validation_error: invalid input', got '%s'", err.Error())
 	}
 
 	// Test error with underlying error
-	underlyingErr := errors.New("underlying error")
-	err = NewValidationError("invalid input", underlyingErr)
+	underlyingErr := stderrors.New("underlying error")
+	err = errors.NewValidationError("invalid input", underlyingErr)
 	if err.Error() != "validation_error: invalid input (underlying error)" {
 		t.Errorf("Expected error message 'validation_error: invalid input (underlying error)', got '%s'", err.Error())
 	}
 
 	// Test error unwrapping
-	var botErr *BotError
-	if !errors.As(err, &botErr) {
-		t.Error("Expected error to be a BotError")
+	var botErr *errors.BotError
+	if !stderrors.As(err, &botErr) {
+		t.Error("Expected error to be a errors.BotError")
 	}
-	if botErr.Type != ErrorTypeValidation {
+	if botErr.Type != errors.ErrorTypeValidation {
 		t.Errorf("Expected error type 'validation_error', got '%s'", botErr.Type)
 	}
 	if botErr.Message != "invalid input" {
@@ -46,50 +46,50 @@ func TestErrorTypeChecks(t *testing.T) {
 	}{
 		{
 			name:     "validation error",
-			err:      NewValidationError("test", nil),
-			checkFn:  IsValidationError,
+			err:      errors.NewValidationError("test", nil),
+			checkFn:  errors.IsValidationError,
 			expected: true,
 		},
 		{
 			name:     "not found error",
-			err:      NewNotFoundError("test", nil),
-			checkFn:  IsNotFoundError,
+			err:      errors.NewNotFoundError("test", nil),
+			checkFn:  errors.IsNotFoundError,
 			expected: true,
 		},
 		{
 			name:     "conflict error",
-			err:      NewConflictError("test", nil),
-			checkFn:  IsConflictError,
+			err:      errors.NewConflictError("test", nil),
+			checkFn:  errors.IsConflictError,
 			expected: true,
 		},
 		{
 			name:     "rate limit error",
-			err:      NewRateLimitError("test", nil),
-			checkFn:  IsRateLimitError,
+			err:      errors.NewRateLimitError("test", nil),
+			checkFn:  errors.IsRateLimitError,
 			expected: true,
 		},
 		{
 			name:     "internal error",
-			err:      NewInternalError("test", nil),
-			checkFn:  IsInternalError,
+			err:      errors.NewInternalError("test", nil),
+			checkFn:  errors.IsInternalError,
 			expected: true,
 		},
 		{
 			name:     "nil error",
 			err:      nil,
-			checkFn:  IsValidationError,
+			checkFn:  errors.IsValidationError,
 			expected: false,
 		},
 		{
 			name:     "non-bot error",
-			err:      errors.New("test"),
-			checkFn:  IsValidationError,
+			err:      stderrors.New("test"),
+			checkFn:  errors.IsValidationError,
 			expected: false,
 		},
 		{
 			name:     "wrong error type",
-			err:      NewValidationError("test", nil),
-			checkFn:  IsNotFoundError,
+			err:      errors.NewValidationError("test", nil),
+			checkFn:  errors.IsNotFoundError,
 			expected: false,
 		},
 	}
@@ -105,29 +105,29 @@ func TestErrorTypeChecks(t *testing.T) {
 
 func TestErrorWrapping(t *testing.T) {
 	// Create a chain of errors
-	err1 := errors.New("error 1")
-	err2 := NewValidationError("error 2", err1)
-	err3 := NewInternalError("error 3", err2)
+	err1 := stderrors.New("error 1")
+	err2 := errors.NewValidationError("error 2", err1)
+	err3 := errors.NewInternalError("error 3", err2)
 
 	// Test unwrapping
-	var botErr *BotError
-	if !errors.As(err3, &botErr) {
-		t.Error("Expected error to be a BotError")
+	var botErr *errors.BotError
+	if !stderrors.As(err3, &botErr) {
+		t.Error("Expected error to be a errors.BotError")
 	}
-	if botErr.Type != ErrorTypeInternal {
+	if botErr.Type != errors.ErrorTypeInternal {
 		t.Errorf("Expected error type 'internal_error', got '%s'", botErr.Type)
 	}
 
 	// Test unwrapping to get the validation error
-	if !errors.As(err3, &botErr) {
-		t.Error("Expected error to be a BotError")
+	if !stderrors.As(err3, &botErr) {
+		t.Error("Expected error to be a errors.BotError")
 	}
-	if !IsValidationError(botErr.Err) {
+	if !errors.IsValidationError(botErr.Err) {
 		t.Error("Expected underlying error to be a validation error")
 	}
 
 	// Test unwrapping to get the original error
-	if !errors.Is(err3, err1) {
+	if !stderrors.Is(err3, err1) {
 		t.Error("Expected error to wrap the original error")
 	}
-} 
\ No newline at end of file
+}
@@ -0,0 +1,242 @@
+package gitops
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/nobl9/nobl9-go/manifest"
+	"github.com/nobl9/nobl9-go/manifest/v1alpha/project"
+	"github.com/nobl9/nobl9-go/manifest/v1alpha/rolebinding"
+	"github.com/nobl9/nobl9-go/sdk"
+
+	"github.com/dfaile/backstage-nobl9/internal/config"
+	"github.com/dfaile/backstage-nobl9/internal/errors"
+)
+
+const (
+	// DefaultLayout places each object in a directory named after its project
+	DefaultLayout = "{{ .Project }}/{{ .Kind }}-{{ .Name }}.yaml"
+	// DefaultBaseBranch is the branch new branches start from
+	DefaultBaseBranch = "main"
+	// DefaultBranchPrefix is prepended to every branch the bot creates
+	DefaultBranchPrefix = "nobl9-bot/"
+	// DefaultAuthorName and DefaultAuthorEmail identify the bot's commits
+	DefaultAuthorName  = "Nobl9 Project Bot"
+	DefaultAuthorEmail = "nobl9-bot@localhost"
+)
+
+// branchUnsafe matches characters that are replaced in branch names
+var branchUnsafe = regexp.MustCompile(`[^a-z0-9._-]+`)
+
+// PathData holds the fields available to the file layout template
+type PathData struct {
+	Project string
+	Kind    string
+	Name    string
+}
+
+// Repository writes manifests into a git working tree and commits them on a new branch
+type Repository struct {
+	cfg    config.GitOpsConfig
+	layout *template.Template
+	mu     sync.Mutex // Serializes use of the single working tree
+}
+
+// NewRepository creates a repository from the GitOps settings, filling in defaults
+func NewRepository(cfg config.GitOpsConfig) (*Repository, error) {
+	if cfg.RepoDir == "" {
+		return nil, fmt.Errorf("gitops repo_dir is required")
+	}
+	if cfg.Layout == "" {
+		cfg.Layout = DefaultLayout
+	}
+	if cfg.BaseBranch == "" {
+		cfg.BaseBranch = DefaultBaseBranch
+	}
+	if cfg.BranchPrefix == "" {
+		cfg.BranchPrefix = DefaultBranchPrefix
+	}
+	if cfg.AuthorName == "" {
+		cfg.AuthorName = DefaultAuthorName
+	}
+	if cfg.AuthorEmail == "" {
+		cfg.AuthorEmail = DefaultAuthorEmail
+	}
+
+	layout, err := template.New("layout").Option("missingkey=error").Parse(cfg.Layout)
+	if err != nil {
+		return nil, fmt.Errorf("invalid gitops layout: %w", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(cfg.RepoDir, ".git")); err != nil {
+		return nil, fmt.Errorf("gitops repo_dir %s is not a git working tree: %w", cfg.RepoDir, err)
+	}
+
+	return &Repository{
+		cfg:    cfg,
+		layout: layout,
+	}, nil
+}
+
+// Path returns the file path of an object relative to the repository root
+func (r *Repository) Path(obj manifest.Object) (string, error) {
+	data := PathData{
+		Kind: obj.GetKind().String(),
+		Name: obj.GetName(),
+	}
+	switch o := obj.(type) {
+	case project.Project:
+		data.Project = o.GetName()
+	case rolebinding.RoleBinding:
+		data.Project = o.Spec.ProjectRef
+	case manifest.ProjectScopedObject:
+		data.Project = o.GetProject()
+	}
+
+	var buf bytes.Buffer
+	if err := r.layout.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render path for %s %s: %w", data.Kind, data.Name, err)
+	}
+
+	path := filepath.Clean(buf.String())
+	if path == "." || filepath.IsAbs(path) || strings.HasPrefix(path, "..") {
+		return "", errors.NewValidationError(fmt.Sprintf("layout renders %s %s outside the repository: %s", data.Kind, data.Name, buf.String()), nil)
+	}
+	return path, nil
+}
+
+// Commit writes one file per object on a new branch, commits them with message and
+// pushes the branch when a remote is configured. It returns the branch name.
+func (r *Repository) Commit(ctx context.Context, name, message string, objects []manifest.Object) (string, error) {
+	if len(objects) == 0 {
+		return "", errors.NewValidationError("no objects to commit", nil)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	start := r.cfg.BaseBranch
+	if r.cfg.Remote != "" {
+		if _, err := r.git(ctx, "fetch", "--quiet", r.cfg.Remote, r.cfg.BaseBranch); err != nil {
+			return "", err
+		}
+		start = r.cfg.Remote + "/" + r.cfg.BaseBranch
+	}
+
+	branch := r.branchName(ctx, name)
+	if _, err := r.git(ctx, "checkout", "--quiet", "-b", branch, start); err != nil {
+		return "", err
+	}
+
+	if err := r.commit(ctx, message, objects); err != nil {
+		r.abandon(branch, start)
+		return "", err
+	}
+
+	if r.cfg.Remote != "" {
+		if _, err := r.git(ctx, "push", "--quiet", r.cfg.Remote, branch); err != nil {
+			r.abandon(branch, start)
+			return "", err
+		}
+	}
+
+	// Leave the working tree where it started so the next change begins cleanly
+	if _, err := r.git(ctx, "checkout", "--quiet", "--detach", start); err != nil {
+		return "", err
+	}
+
+	return branch, nil
+}
+
+// commit writes the object files on the current branch and commits them
+func (r *Repository) commit(ctx context.Context, message string, objects []manifest.Object) error {
+	paths := make([]string, 0, len(objects))
+	for _, obj := range objects {
+		path, err := r.Path(obj)
+		if err != nil {
+			return err
+		}
+
+		var buf bytes.Buffer
+		if err := sdk.EncodeObject(obj, &buf, manifest.ObjectFormatYAML); err != nil {
+			return fmt.Errorf("failed to encode %s %s: %w", obj.GetKind(), obj.GetName(), err)
+		}
+
+		fullPath := filepath.Join(r.cfg.RepoDir, path)
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			return fmt.Errorf("failed to create directory for %s: %w", path, err)
+		}
+		if err := os.WriteFile(fullPath, buf.Bytes(), 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		paths = append(paths, path)
+	}
+
+	if _, err := r.git(ctx, append([]string{"add", "--"}, paths...)...); err != nil {
+		return err
+	}
+	if _, err := r.git(ctx, "commit", "--quiet", "-m", message); err != nil {
+		return err
+	}
+	return nil
+}
+
+// abandon discards a half-made branch after a failure. Errors are ignored because
+// the original failure is the one worth reporting.
+func (r *Repository) abandon(branch, start string) {
+	ctx := context.Background()
+	_, _ = r.git(ctx, "reset", "--quiet", "--hard")
+	_, _ = r.git(ctx, "checkout", "--quiet", "--detach", start)
+	_, _ = r.git(ctx, "branch", "--quiet", "-D", branch)
+}
+
+// branchName builds a unique branch name from a short description of the change
+func (r *Repository) branchName(ctx context.Context, name string) string {
+	slug := strings.Trim(branchUnsafe.ReplaceAllString(strings.ToLower(name), "-"), "-.")
+	if slug == "" {
+		slug = "change"
+	}
+	base := fmt.Sprintf("%s%s-%s", r.cfg.BranchPrefix, slug, time.Now().UTC().Format("20060102-150405"))
+
+	// Changes made within the same second get a numeric suffix
+	branch := base
+	for i := 2; r.branchExists(ctx, branch); i++ {
+		branch = fmt.Sprintf("%s-%d", base, i)
+	}
+	return branch
+}
+
+// branchExists reports whether a local branch already exists
+func (r *Repository) branchExists(ctx context.Context, branch string) bool {
+	_, err := r.git(ctx, "rev-parse", "--verify", "--quiet", "refs/heads/"+branch)
+	return err == nil
+}
+
+// git runs a git command in the working tree and returns its trimmed output
+func (r *Repository) git(ctx context.Context, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = r.cfg.RepoDir
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME="+r.cfg.AuthorName,
+		"GIT_AUTHOR_EMAIL="+r.cfg.AuthorEmail,
+		"GIT_COMMITTER_NAME="+r.cfg.AuthorName,
+		"GIT_COMMITTER_EMAIL="+r.cfg.AuthorEmail,
+	)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", errors.NewInternalError(fmt.Sprintf("git %s failed: %s", args[0], strings.TrimSpace(stderr.String())), err)
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
@@ -0,0 +1,133 @@
+package gitops_test
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nobl9/nobl9-go/manifest"
+	"github.com/nobl9/nobl9-go/manifest/v1alpha/project"
+	"github.com/nobl9/nobl9-go/manifest/v1alpha/rolebinding"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dfaile/backstage-nobl9/internal/config"
+	"github.com/dfaile/backstage-nobl9/internal/gitops"
+)
+
+// runGit runs git in dir and fails the test on error
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+		"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com",
+	)
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+	return strings.TrimSpace(string(out))
+}
+
+// newRemote creates a bare repository with one commit on main and a clone of it
+func newRemote(t *testing.T) (bare, work string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	root := t.TempDir()
+	bare = filepath.Join(root, "manifests.git")
+	work = filepath.Join(root, "work")
+
+	runGit(t, root, "init", "--quiet", "--bare", "--initial-branch=main", bare)
+	runGit(t, root, "clone", "--quiet", bare, work)
+	require.NoError(t, os.WriteFile(filepath.Join(work, "README.md"), []byte("manifests\n"), 0644))
+	runGit(t, work, "add", "README.md")
+	runGit(t, work, "commit", "--quiet", "-m", "initial")
+	runGit(t, work, "push", "--quiet", "origin", "HEAD:main")
+	return bare, work
+}
+
+func testObjects() []manifest.Object {
+	user := "owner@example.com"
+	return []manifest.Object{
+		project.New(project.Metadata{Name: "payments-api"}, project.Spec{Description: "Payments"}),
+		rolebinding.New(rolebinding.Metadata{Name: "payments-api-owner"}, rolebinding.Spec{
+			User:       &user,
+			RoleRef:    "project-owner",
+			ProjectRef: "payments-api",
+		}),
+	}
+}
+
+func TestCommitPushesBranch(t *testing.T) {
+	bare, work := newRemote(t)
+
+	repo, err := gitops.NewRepository(config.GitOpsConfig{RepoDir: work, Remote: "origin"})
+	require.NoError(t, err)
+
+	branch, err := repo.Commit(context.Background(), "create-project payments-api", "Create project payments-api", testObjects())
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(branch, "nobl9-bot/create-project-payments-api-"), branch)
+
+	files := runGit(t, bare, "ls-tree", "-r", "--name-only", branch)
+	assert.Contains(t, files, "payments-api/Project-payments-api.yaml")
+	assert.Contains(t, files, "payments-api/RoleBinding-payments-api-owner.yaml")
+	assert.Contains(t, files, "README.md")
+
+	content := runGit(t, bare, "show", branch+":payments-api/Project-payments-api.yaml")
+	assert.Contains(t, content, "kind: Project")
+	assert.Contains(t, content, "description: Payments")
+
+	assert.Equal(t, "Create project payments-api", runGit(t, bare, "log", "-1", "--format=%s", branch))
+	assert.Equal(t, "Nobl9 Project Bot", runGit(t, bare, "log", "-1", "--format=%an", branch))
+
+	// main is untouched and the working tree is clean
+	assert.NotContains(t, runGit(t, bare, "ls-tree", "-r", "--name-only", "main"), "payments-api")
+	assert.Empty(t, runGit(t, work, "status", "--porcelain"))
+}
+
+func TestCommitLocalBranch(t *testing.T) {
+	_, work := newRemote(t)
+
+	repo, err := gitops.NewRepository(config.GitOpsConfig{
+		RepoDir:      work,
+		Layout:       "projects/{{ .Project }}/{{ .Name }}.yaml",
+		BranchPrefix: "review/",
+	})
+	require.NoError(t, err)
+
+	branch, err := repo.Commit(context.Background(), "assign-role", "Assign roles", testObjects()[1:])
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(branch, "review/assign-role-"), branch)
+
+	files := runGit(t, work, "ls-tree", "-r", "--name-only", branch)
+	assert.Contains(t, files, "projects/payments-api/payments-api-owner.yaml")
+
+	// A second change in the same second gets its own branch
+	second, err := repo.Commit(context.Background(), "assign-role", "Assign roles", testObjects()[1:])
+	require.NoError(t, err)
+	assert.NotEqual(t, branch, second)
+}
+
+func TestPathRejectsEscapingLayout(t *testing.T) {
+	_, work := newRemote(t)
+
+	repo, err := gitops.NewRepository(config.GitOpsConfig{RepoDir: work, Layout: "../{{ .Name }}.yaml"})
+	require.NoError(t, err)
+
+	_, err = repo.Path(testObjects()[0])
+	assert.Error(t, err)
+}
+
+func TestNewRepositoryRequiresWorkingTree(t *testing.T) {
+	_, err := gitops.NewRepository(config.GitOpsConfig{})
+	assert.Error(t, err)
+
+	_, err = gitops.NewRepository(config.GitOpsConfig{RepoDir: t.TempDir()})
+	assert.Error(t, err)
+}
@@ -0,0 +1,73 @@
+package identity
+
+import (
+	"context"
+	"os"
+	"os/user"
+)
+
+// Sources of callers
+const (
+	SourceCLI   = "cli"
+	SourceHTTP  = "http"
+	SourceSlack = "slack"
+)
+
+// Caller represents the person sending a message to the bot
+type Caller struct {
+	ID          string `json:"id,omitempty"`    // Platform user ID, e.g. the OS user name or a Slack member ID
+	Email       string `json:"email,omitempty"` // Nobl9 email, empty when the platform does not know it
+	DisplayName string `json:"display_name,omitempty"`
+	Source      string `json:"source,omitempty"`  // Platform the caller is talking through, e.g. cli, http or slack
+	Channel     string `json:"channel,omitempty"` // Chat channel the message came from, if any
+	Tenant      string `json:"tenant,omitempty"`  // Tenant named by the integration, e.g. from an HTTP header
+}
+
+// Identity returns the caller's authorization identity in source:id form,
+// or an empty string for an anonymous caller
+func (c Caller) Identity() string {
+	if c.ID == "" {
+		return ""
+	}
+	return c.Source + ":" + c.ID
+}
+
+// String returns a human readable name for the caller
+func (c Caller) String() string {
+	switch {
+	case c.DisplayName != "":
+		return c.DisplayName
+	case c.Email != "":
+		return c.Email
+	case c.ID != "":
+		return c.Identity()
+	default:
+		return "anonymous"
+	}
+}
+
+// CLI returns the caller for the OS user running the CLI
+func CLI() Caller {
+	caller := Caller{Source: SourceCLI}
+	if current, err := user.Current(); err == nil {
+		caller.ID = current.Username
+		caller.DisplayName = current.Name
+	} else {
+		caller.ID = os.Getenv("USER")
+	}
+	return caller
+}
+
+// callerKey is the context key for the caller
+type callerKey struct{}
+
+// NewContext returns a context carrying caller
+func NewContext(ctx context.Context, caller Caller) context.Context {
+	return context.WithValue(ctx, callerKey{}, caller)
+}
+
+// FromContext returns the caller stored in ctx
+func FromContext(ctx context.Context) (Caller, bool) {
+	caller, ok := ctx.Value(callerKey{}).(Caller)
+	return caller, ok
+}
@@ -0,0 +1,39 @@
+package identity_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dfaile/backstage-nobl9/internal/identity"
+)
+
+func TestCallerIdentity(t *testing.T) {
+	caller := identity.Caller{ID: "U123", Email: "alice@example.com", Source: identity.SourceSlack}
+	assert.Equal(t, "slack:U123", caller.Identity())
+	assert.Equal(t, "alice@example.com", caller.String())
+
+	caller.DisplayName = "Alice"
+	assert.Equal(t, "Alice", caller.String())
+
+	anonymous := identity.Caller{}
+	assert.Empty(t, anonymous.Identity())
+	assert.Equal(t, "anonymous", anonymous.String())
+}
+
+func TestCLI(t *testing.T) {
+	caller := identity.CLI()
+	assert.Equal(t, identity.SourceCLI, caller.Source)
+	assert.NotEmpty(t, caller.ID)
+}
+
+func TestContext(t *testing.T) {
+	_, ok := identity.FromContext(context.Background())
+	assert.False(t, ok)
+
+	caller := identity.Caller{ID: "alice", Source: identity.SourceCLI}
+	got, ok := identity.FromContext(identity.NewContext(context.Background(), caller))
+	assert.True(t, ok)
+	assert.Equal(t, caller, got)
+}
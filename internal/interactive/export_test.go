@@ -0,0 +1,4 @@
+package interactive
+
+// FormatDuration exposes formatDuration to the interactive_test package
+var FormatDuration = formatDuration
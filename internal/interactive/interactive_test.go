@@ -1,9 +1,9 @@
 package interactive_test
 
 import (
+	"github.com/dfaile/backstage-nobl9/internal/interactive"
 	"testing"
 	"time"
-	"github.com/dfaile/backstage-nobl9/internal/interactive"
 )
 
 func TestPrompt(t *testing.T) {
@@ -181,10 +181,10 @@ func TestFormatDuration(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := formatDuration(tt.duration)
+			got := interactive.FormatDuration(tt.duration)
 			if got != tt.want {
 				t.Errorf("formatDuration() = %v, want %v", got, tt.want)
 			}
 		})
 	}
-} 
\ No newline at end of file
+}
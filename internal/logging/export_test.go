@@ -0,0 +1,4 @@
+package logging
+
+// GetZapLevel exposes getZapLevel to the logging_test package
+var GetZapLevel = getZapLevel
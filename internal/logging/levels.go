@@ -0,0 +1,149 @@
+package logging
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// ParseLevel checks that s names a level
+func ParseLevel(s string) (Level, error) {
+	switch level := Level(s); level {
+	case LevelDebug, LevelInfo, LevelWarn, LevelError:
+		return level, nil
+	default:
+		return "", fmt.Errorf("unknown log level %q, use debug, info, warn or error", s)
+	}
+}
+
+// Levels holds the level a logger writes at, with overrides for individual packages.
+// A package is named by its directory, such as bot or nobl9. Levels can be changed
+// while the bot runs and loggers sharing them pick up the change at once.
+type Levels struct {
+	mu       sync.RWMutex
+	level    Level
+	packages map[string]Level
+}
+
+// NewLevels creates levels with a default level and per-package overrides
+func NewLevels(level Level, packages map[string]Level) (*Levels, error) {
+	l := &Levels{packages: make(map[string]Level)}
+	if err := l.Set("", level); err != nil {
+		return nil, err
+	}
+	for pkg, level := range packages {
+		if err := l.Set(pkg, level); err != nil {
+			return nil, fmt.Errorf("package %s: %w", pkg, err)
+		}
+	}
+	return l, nil
+}
+
+// Set changes the level of a package, or the default level when pkg is empty
+func (l *Levels) Set(pkg string, level Level) error {
+	level, err := ParseLevel(string(level))
+	if err != nil {
+		return err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if pkg == "" {
+		l.level = level
+	} else {
+		l.packages[pkg] = level
+	}
+	return nil
+}
+
+// Reset removes a package's override, so it logs at the default level again
+func (l *Levels) Reset(pkg string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.packages, pkg)
+}
+
+// Level returns the default level
+func (l *Levels) Level() Level {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.level
+}
+
+// Packages returns the per-package overrides
+func (l *Levels) Packages() map[string]Level {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	packages := make(map[string]Level, len(l.packages))
+	for pkg, level := range l.packages {
+		packages[pkg] = level
+	}
+	return packages
+}
+
+// Enabled reports whether an entry at level is written for pkg
+func (l *Levels) Enabled(pkg string, level zapcore.Level) bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	threshold, ok := l.packages[pkg]
+	if !ok {
+		threshold = l.level
+	}
+	return getZapLevel(threshold).Enabled(level)
+}
+
+// lowest returns the most verbose level any package logs at
+func (l *Levels) lowest() zapcore.Level {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	lowest := getZapLevel(l.level)
+	for _, level := range l.packages {
+		if zl := getZapLevel(level); zl < lowest {
+			lowest = zl
+		}
+	}
+	return lowest
+}
+
+// levelCore filters entries by the level of the package that logged them. The
+// package is only known from the caller once an entry is written, so Check lets
+// through anything the most verbose package would log and Write does the rest.
+type levelCore struct {
+	zapcore.Core
+	levels *Levels
+}
+
+// Enabled implements zapcore.Core
+func (c *levelCore) Enabled(level zapcore.Level) bool {
+	return c.levels.lowest().Enabled(level)
+}
+
+// With implements zapcore.Core
+func (c *levelCore) With(fields []zapcore.Field) zapcore.Core {
+	return &levelCore{Core: c.Core.With(fields), levels: c.levels}
+}
+
+// Check implements zapcore.Core
+func (c *levelCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+	return checked
+}
+
+// Write implements zapcore.Core
+func (c *levelCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	if !c.levels.Enabled(callerPackage(entry.Caller), entry.Level) {
+		return nil
+	}
+	return c.Core.Write(entry, fields)
+}
+
+// callerPackage names the package an entry was logged from by its directory
+func callerPackage(caller zapcore.EntryCaller) string {
+	if !caller.Defined {
+		return ""
+	}
+	return filepath.Base(filepath.Dir(caller.File))
+}
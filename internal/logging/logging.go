@@ -2,9 +2,10 @@ package logging
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
-	"os"
 	"time"
 
 	"go.uber.org/zap"
@@ -35,6 +36,51 @@ type Logger interface {
 	WithContext(ctx context.Context) Logger
 }
 
+// contextKey is the type of the context keys read by WithContext
+type contextKey string
+
+const (
+	requestIDKey      contextKey = "request_id"
+	userIDKey         contextKey = "user_id"
+	conversationIDKey contextKey = "conversation_id"
+)
+
+// WithRequestID returns a context carrying the request ID logged by WithContext
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey, requestID)
+}
+
+// WithUserID returns a context carrying the user ID logged by WithContext
+func WithUserID(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, userIDKey, userID)
+}
+
+// WithConversationID returns a context carrying the conversation ID logged by WithContext
+func WithConversationID(ctx context.Context, conversationID string) context.Context {
+	return context.WithValue(ctx, conversationIDKey, conversationID)
+}
+
+// NewRequestID returns a random ID for correlating the logs and audit records of one request
+func NewRequestID() string {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(buf)
+}
+
+// RequestID returns the request ID stored in ctx, if any
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+// ConversationID returns the conversation ID stored in ctx, if any
+func ConversationID(ctx context.Context) string {
+	id, _ := ctx.Value(conversationIDKey).(string)
+	return id
+}
+
 // Field represents a logging field
 type Field struct {
 	Key   string
@@ -50,55 +96,82 @@ func F(key string, value interface{}) Field {
 type zapLogger struct {
 	logger *zap.Logger
 	fields []Field
+	policy FieldPolicy
 }
 
-// NewLogger creates a new logger
+// Options configures a logger
+type Options struct {
+	Level  Level
+	Format string      // json, console or pretty, defaults to json
+	Output string      // stdout, stderr or a file path, defaults to stderr. Ignored when Sinks are set
+	Sinks  []Sink      // Destinations, each with its own format
+	Levels *Levels     // Per-package levels, which can change while the bot runs. Overrides Level
+	Fields FieldPolicy // How personal data in fields is logged, everything is kept when empty
+}
+
+// NewLogger creates a new logger with the default field policy
 func NewLogger(level Level) (Logger, error) {
-	// Create encoder config
-	encoderConfig := zapcore.EncoderConfig{
-		TimeKey:        "timestamp",
-		LevelKey:       "level",
-		NameKey:        "logger",
-		CallerKey:      "caller",
-		MessageKey:     "msg",
-		StacktraceKey:  "stacktrace",
-		LineEnding:     zapcore.DefaultLineEnding,
-		EncodeLevel:    zapcore.LowercaseLevelEncoder,
-		EncodeTime:     zapcore.ISO8601TimeEncoder,
-		EncodeDuration: zapcore.StringDurationEncoder,
-		EncodeCaller:   zapcore.ShortCallerEncoder,
+	return NewLoggerWithOptions(Options{Level: level, Fields: DefaultFieldPolicy()})
+}
+
+// NewLoggerWithOptions creates a logger writing to the given sinks. Logs go to
+// stderr by default, so they stay out of the interactive CLI's output.
+func NewLoggerWithOptions(opts Options) (Logger, error) {
+	sinks := opts.Sinks
+	if len(sinks) == 0 {
+		switch opts.Output {
+		case "", SinkStderr, SinkStdout:
+			sinks = []Sink{{Type: opts.Output}}
+		default:
+			sinks = []Sink{{Type: SinkFile, Path: opts.Output}}
+		}
+	}
+
+	cores := make([]zapcore.Core, 0, len(sinks))
+	for _, sink := range sinks {
+		core, err := newSinkCore(sink, opts.Format)
+		if err != nil {
+			return nil, err
+		}
+		cores = append(cores, core)
 	}
 
-	// Create core
-	core := zapcore.NewCore(
-		zapcore.NewJSONEncoder(encoderConfig),
-		zapcore.AddSync(os.Stdout),
-		zapcore.Level(getZapLevel(level)),
-	)
+	levels := opts.Levels
+	if levels == nil {
+		level := opts.Level
+		if level == "" {
+			level = LevelInfo
+		}
+		var err error
+		if levels, err = NewLevels(level, nil); err != nil {
+			return nil, err
+		}
+	}
 
-	// Create logger
-	logger := zap.New(core, zap.AddCaller(), zap.AddStacktrace(zapcore.ErrorLevel))
-	return &zapLogger{logger: logger}, nil
+	// Create logger, reporting the caller of the Logger methods rather than this file
+	core := &levelCore{Core: zapcore.NewTee(cores...), levels: levels}
+	logger := zap.New(core, zap.AddCaller(), zap.AddCallerSkip(1), zap.AddStacktrace(zapcore.ErrorLevel))
+	return &zapLogger{logger: logger, policy: opts.Fields}, nil
 }
 
 // Debug logs a debug message
 func (l *zapLogger) Debug(msg string, fields ...Field) {
-	l.logger.Debug(msg, l.getZapFields(fields)...)
+	l.logger.Debug(l.policy.Message(msg), l.getZapFields(fields)...)
 }
 
 // Info logs an info message
 func (l *zapLogger) Info(msg string, fields ...Field) {
-	l.logger.Info(msg, l.getZapFields(fields)...)
+	l.logger.Info(l.policy.Message(msg), l.getZapFields(fields)...)
 }
 
 // Warn logs a warning message
 func (l *zapLogger) Warn(msg string, fields ...Field) {
-	l.logger.Warn(msg, l.getZapFields(fields)...)
+	l.logger.Warn(l.policy.Message(msg), l.getZapFields(fields)...)
 }
 
 // Error logs an error message
 func (l *zapLogger) Error(msg string, fields ...Field) {
-	l.logger.Error(msg, l.getZapFields(fields)...)
+	l.logger.Error(l.policy.Message(msg), l.getZapFields(fields)...)
 }
 
 // With returns a logger with the given fields
@@ -106,31 +179,37 @@ func (l *zapLogger) With(fields ...Field) Logger {
 	return &zapLogger{
 		logger: l.logger.With(l.getZapFields(fields)...),
 		fields: append(l.fields, fields...),
+		policy: l.policy,
 	}
 }
 
 // WithContext returns a logger with context fields
 func (l *zapLogger) WithContext(ctx context.Context) Logger {
 	fields := []Field{
-		F("request_id", ctx.Value("request_id")),
-		F("user_id", ctx.Value("user_id")),
-		F("conversation_id", ctx.Value("conversation_id")),
+		F("request_id", ctx.Value(requestIDKey)),
+		F("user_id", ctx.Value(userIDKey)),
+		F("conversation_id", ctx.Value(conversationIDKey)),
 	}
 	return l.With(fields...)
 }
 
-// getZapFields converts Field to zap.Field
+// getZapFields converts Field to zap.Field, redacting secrets and applying the
+// field policy to the values
 func (l *zapLogger) getZapFields(fields []Field) []zap.Field {
 	zapFields := make([]zap.Field, 0, len(fields)+len(l.fields))
 
 	// Add logger fields
 	for _, f := range l.fields {
-		zapFields = append(zapFields, zap.Any(f.Key, f.Value))
+		if value, ok := l.policy.Apply(f.Key, f.Value); ok {
+			zapFields = append(zapFields, zap.Any(f.Key, value))
+		}
 	}
 
 	// Add message fields
 	for _, f := range fields {
-		zapFields = append(zapFields, zap.Any(f.Key, f.Value))
+		if value, ok := l.policy.Apply(f.Key, f.Value); ok {
+			zapFields = append(zapFields, zap.Any(f.Key, value))
+		}
 	}
 
 	return zapFields
@@ -165,11 +244,17 @@ type LogEvent struct {
 	ConversationID string             `json:"conversation_id,omitempty"`
 }
 
-// FormatEvent formats a log event as JSON
+// FormatEvent formats a log event as JSON, with secrets redacted and the default
+// field policy applied
 func FormatEvent(event LogEvent) (string, error) {
+	return DefaultFieldPolicy().FormatEvent(event)
+}
+
+// formatEvent marshals a log event whose fields are ready to log
+func formatEvent(event LogEvent) (string, error) {
 	data, err := json.Marshal(event)
 	if err != nil {
 		return "", fmt.Errorf("failed to marshal log event: %w", err)
 	}
-	return string(data), nil
+	return Redact(string(data)), nil
 } 
\ No newline at end of file
@@ -7,35 +7,37 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zapcore"
+
 	"github.com/dfaile/backstage-nobl9/internal/logging"
 )
 
 func TestNewLogger(t *testing.T) {
 	tests := []struct {
 		name  string
-		level Level
+		level logging.Level
 	}{
 		{
 			name:  "debug level",
-			level: LevelDebug,
+			level: logging.LevelDebug,
 		},
 		{
 			name:  "info level",
-			level: LevelInfo,
+			level: logging.LevelInfo,
 		},
 		{
 			name:  "warn level",
-			level: LevelWarn,
+			level: logging.LevelWarn,
 		},
 		{
 			name:  "error level",
-			level: LevelError,
+			level: logging.LevelError,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			logger, err := NewLogger(tt.level)
+			logger, err := logging.NewLogger(tt.level)
 			assert.NoError(t, err)
 			assert.NotNil(t, logger)
 		})
@@ -43,29 +45,29 @@ func TestNewLogger(t *testing.T) {
 }
 
 func TestLoggerWithFields(t *testing.T) {
-	logger, err := NewLogger(LevelInfo)
+	logger, err := logging.NewLogger(logging.LevelInfo)
 	assert.NoError(t, err)
 
 	// Test with single field
-	loggerWithField := logger.With(F("key", "value"))
+	loggerWithField := logger.With(logging.F("key", "value"))
 	assert.NotNil(t, loggerWithField)
 
 	// Test with multiple fields
 	loggerWithFields := logger.With(
-		F("key1", "value1"),
-		F("key2", "value2"),
+		logging.F("key1", "value1"),
+		logging.F("key2", "value2"),
 	)
 	assert.NotNil(t, loggerWithFields)
 }
 
 func TestLoggerWithContext(t *testing.T) {
-	logger, err := NewLogger(LevelInfo)
+	logger, err := logging.NewLogger(logging.LevelInfo)
 	assert.NoError(t, err)
 
 	// Create context with values
-	ctx := context.WithValue(context.Background(), "request_id", "123")
-	ctx = context.WithValue(ctx, "user_id", "user123")
-	ctx = context.WithValue(ctx, "conversation_id", "conv123")
+	ctx := logging.WithRequestID(context.Background(), "123")
+	ctx = logging.WithUserID(ctx, "user123")
+	ctx = logging.WithConversationID(ctx, "conv123")
 
 	// Test with context
 	loggerWithContext := logger.WithContext(ctx)
@@ -74,7 +76,7 @@ func TestLoggerWithContext(t *testing.T) {
 
 func TestFormatEvent(t *testing.T) {
 	now := time.Now()
-	event := LogEvent{
+	event := logging.LogEvent{
 		Timestamp:      now,
 		Level:          "info",
 		Message:        "test message",
@@ -87,12 +89,12 @@ func TestFormatEvent(t *testing.T) {
 	}
 
 	// Format event
-	formatted, err := FormatEvent(event)
+	formatted, err := logging.FormatEvent(event)
 	assert.NoError(t, err)
 	assert.NotEmpty(t, formatted)
 
 	// Parse formatted event
-	var parsed LogEvent
+	var parsed logging.LogEvent
 	err = json.Unmarshal([]byte(formatted), &parsed)
 	assert.NoError(t, err)
 
@@ -108,7 +110,7 @@ func TestFormatEvent(t *testing.T) {
 }
 
 func TestField(t *testing.T) {
-	field := F("key", "value")
+	field := logging.F("key", "value")
 	assert.Equal(t, "key", field.Key)
 	assert.Equal(t, "value", field.Value)
 }
@@ -116,27 +118,27 @@ func TestField(t *testing.T) {
 func TestGetZapLevel(t *testing.T) {
 	tests := []struct {
 		name     string
-		level    Level
+		level    logging.Level
 		expected zapcore.Level
 	}{
 		{
 			name:     "debug level",
-			level:    LevelDebug,
+			level:    logging.LevelDebug,
 			expected: zapcore.DebugLevel,
 		},
 		{
 			name:     "info level",
-			level:    LevelInfo,
+			level:    logging.LevelInfo,
 			expected: zapcore.InfoLevel,
 		},
 		{
 			name:     "warn level",
-			level:    LevelWarn,
+			level:    logging.LevelWarn,
 			expected: zapcore.WarnLevel,
 		},
 		{
 			name:     "error level",
-			level:    LevelError,
+			level:    logging.LevelError,
 			expected: zapcore.ErrorLevel,
 		},
 		{
@@ -148,8 +150,8 @@ func TestGetZapLevel(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			level := getZapLevel(tt.level)
+			level := logging.GetZapLevel(tt.level)
 			assert.Equal(t, tt.expected, level)
 		})
 	}
-} 
\ No newline at end of file
+}
@@ -0,0 +1,210 @@
+package logging
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// FieldAction says what happens to a field's value before it is logged
+type FieldAction string
+
+const (
+	// FieldKeep logs the value as is, apart from secrets and hashed emails
+	FieldKeep FieldAction = "keep"
+	// FieldHash logs a short hash of the value, so equal values can still be matched
+	FieldHash FieldAction = "hash"
+	// FieldTruncate cuts free text down to the policy's MaxLength
+	FieldTruncate FieldAction = "truncate"
+	// FieldDrop leaves the field out of the log
+	FieldDrop FieldAction = "drop"
+)
+
+// DefaultMaxLength is the length truncated fields are cut to when the policy sets none
+const DefaultMaxLength = 80
+
+// FreeTextFields are the fields holding text users typed, such as raw messages and
+// project descriptions
+var FreeTextFields = []string{"message", "response", "description", "summary", "args"}
+
+// IdentityFields are the fields naming people, by email or caller identity
+var IdentityFields = []string{"user", "user_id", "caller", "requester", "owner", "owners", "approver", "approvers"}
+
+// emailPattern matches email addresses inside field values
+var emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+
+// FieldPolicy controls how personal data in log fields is logged. Fields are
+// matched by key; keys without an action are kept.
+type FieldPolicy struct {
+	Fields     map[string]FieldAction // Action by field key
+	HashEmails bool                   // Hash email addresses found in any kept or truncated value
+	MaxLength  int                    // Length truncated fields are cut to, DefaultMaxLength when 0
+}
+
+// DefaultFieldPolicy truncates free text, so a pasted manifest or a long
+// description does not end up in the logs whole
+func DefaultFieldPolicy() FieldPolicy {
+	policy := FieldPolicy{Fields: make(map[string]FieldAction)}
+	for _, key := range FreeTextFields {
+		policy.Fields[key] = FieldTruncate
+	}
+	return policy
+}
+
+// StrictFieldPolicy logs no personal data: raw user input is dropped, identities
+// and emails are hashed and the remaining free text is truncated
+func StrictFieldPolicy() FieldPolicy {
+	policy := FieldPolicy{Fields: make(map[string]FieldAction), HashEmails: true, MaxLength: 32}
+	for _, key := range FreeTextFields {
+		policy.Fields[key] = FieldTruncate
+	}
+	for _, key := range IdentityFields {
+		policy.Fields[key] = FieldHash
+	}
+	policy.Fields["message"] = FieldDrop
+	policy.Fields["response"] = FieldDrop
+	policy.Fields["args"] = FieldDrop
+	return policy
+}
+
+// Action returns the action for a field key
+func (p FieldPolicy) Action(key string) FieldAction {
+	if action, ok := p.Fields[key]; ok && action != "" {
+		return action
+	}
+	return FieldKeep
+}
+
+// Apply returns the value to log for a field, and false if the field is dropped.
+// Secrets are redacted before the policy is applied.
+func (p FieldPolicy) Apply(key string, value interface{}) (interface{}, bool) {
+	action := p.Action(key)
+	if action == FieldDrop {
+		return nil, false
+	}
+
+	value = redactValue(value)
+	if value == nil {
+		return nil, true
+	}
+
+	switch action {
+	case FieldHash:
+		if values, ok := value.([]string); ok {
+			hashed := make([]string, len(values))
+			for i, v := range values {
+				hashed[i] = Hash(v)
+			}
+			return hashed, true
+		}
+		return Hash(stringValue(value)), true
+	case FieldTruncate:
+		return p.Truncate(p.hashEmails(stringValue(value))), true
+	}
+
+	switch v := value.(type) {
+	case string:
+		return p.hashEmails(v), true
+	case redactedJSON:
+		return redactedJSON(p.hashEmails(string(v))), true
+	case []string:
+		if !p.HashEmails {
+			return v, true
+		}
+		result := make([]string, len(v))
+		for i, s := range v {
+			result[i] = p.hashEmails(s)
+		}
+		return result, true
+	case error:
+		if p.HashEmails {
+			return policyError{err: v, message: p.hashEmails(v.Error())}, true
+		}
+	}
+	return value, true
+}
+
+// Message returns a log message with the policy applied
+func (p FieldPolicy) Message(msg string) string {
+	return p.hashEmails(Redact(msg))
+}
+
+// Truncate cuts s down to the policy's maximum length, noting how much was cut
+func (p FieldPolicy) Truncate(s string) string {
+	limit := p.MaxLength
+	if limit <= 0 {
+		limit = DefaultMaxLength
+	}
+	if utf8.RuneCountInString(s) <= limit {
+		return s
+	}
+	runes := []rune(s)
+	return fmt.Sprintf("%s… (%d more characters)", string(runes[:limit]), len(runes)-limit)
+}
+
+// FormatEvent formats a log event as JSON with the policy applied to its fields
+func (p FieldPolicy) FormatEvent(event LogEvent) (string, error) {
+	if event.Fields != nil {
+		fields := make(map[string]interface{}, len(event.Fields))
+		for key, value := range event.Fields {
+			if value, ok := p.Apply(key, value); ok {
+				fields[key] = value
+			}
+		}
+		event.Fields = fields
+	}
+	event.Message = p.Message(event.Message)
+	event.Error = p.Message(event.Error)
+	if p.Action("user_id") == FieldHash && event.UserID != "" {
+		event.UserID = Hash(event.UserID)
+	}
+	return formatEvent(event)
+}
+
+// hashEmails replaces email addresses in s with their hash, if the policy says so
+func (p FieldPolicy) hashEmails(s string) string {
+	if !p.HashEmails {
+		return s
+	}
+	return emailPattern.ReplaceAllStringFunc(s, Hash)
+}
+
+// Hash returns a short, stable hash of a value, such as an email, that lets logs be
+// correlated without showing the value
+func Hash(value string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(strings.TrimSpace(value))))
+	return "sha256:" + hex.EncodeToString(sum[:6])
+}
+
+// stringValue renders a field value as text
+func stringValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case redactedJSON:
+		return string(v)
+	case error:
+		return v.Error()
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// policyError is an error whose logged message had the field policy applied
+type policyError struct {
+	err     error
+	message string
+}
+
+// Error implements the error interface
+func (e policyError) Error() string {
+	return e.message
+}
+
+// Unwrap returns the original error
+func (e policyError) Unwrap() error {
+	return e.err
+}
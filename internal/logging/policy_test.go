@@ -0,0 +1,94 @@
+package logging_test
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dfaile/backstage-nobl9/internal/logging"
+)
+
+func TestFieldPolicyApply(t *testing.T) {
+	policy := logging.FieldPolicy{
+		Fields: map[string]logging.FieldAction{
+			"requester":   logging.FieldHash,
+			"owners":      logging.FieldHash,
+			"description": logging.FieldTruncate,
+			"message":     logging.FieldDrop,
+		},
+		HashEmails: true,
+		MaxLength:  10,
+	}
+
+	value, ok := policy.Apply("requester", "Alice@Example.com")
+	require.True(t, ok)
+	assert.Equal(t, logging.Hash("alice@example.com"), value)
+	assert.True(t, strings.HasPrefix(value.(string), "sha256:"))
+
+	value, _ = policy.Apply("owners", []string{"alice@example.com", "bob@example.com"})
+	assert.Equal(t, []string{logging.Hash("alice@example.com"), logging.Hash("bob@example.com")}, value)
+
+	value, _ = policy.Apply("description", "Payments team services and their SLOs")
+	assert.Equal(t, "Payments t… (27 more characters)", value)
+
+	_, ok = policy.Apply("message", "my email is alice@example.com")
+	assert.False(t, ok)
+
+	// Emails are hashed wherever they turn up
+	value, _ = policy.Apply("error", errors.New("user alice@example.com not found"))
+	assert.Equal(t, "user "+logging.Hash("alice@example.com")+" not found", value.(error).Error())
+	value, _ = policy.Apply("project", "payments")
+	assert.Equal(t, "payments", value)
+}
+
+func TestStrictFieldPolicy(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bot.log")
+	logger, err := logging.NewLoggerWithOptions(logging.Options{
+		Level:  logging.LevelInfo,
+		Output: path,
+		Fields: logging.StrictFieldPolicy(),
+	})
+	require.NoError(t, err)
+
+	logger.With(logging.F("user_id", "http:alice@example.com")).Warn("Invalid response",
+		logging.F("message", "assign bob@example.com as owner"),
+		logging.F("requester", "alice@example.com"),
+		logging.F("description", strings.Repeat("long description ", 10)),
+		logging.F("project", "payments"),
+	)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "@example.com")
+
+	var entry map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &entry))
+	assert.NotContains(t, entry, "message")
+	assert.Equal(t, logging.Hash("alice@example.com"), entry["requester"])
+	assert.Equal(t, logging.Hash("http:alice@example.com"), entry["user_id"])
+	assert.Contains(t, entry["description"], "more characters")
+	assert.Equal(t, "payments", entry["project"])
+}
+
+func TestDefaultFieldPolicy(t *testing.T) {
+	policy := logging.DefaultFieldPolicy()
+
+	value, _ := policy.Apply("requester", "alice@example.com")
+	assert.Equal(t, "alice@example.com", value)
+	value, _ = policy.Apply("response", strings.Repeat("x", 200))
+	assert.Len(t, value, logging.DefaultMaxLength+len("… (120 more characters)"))
+
+	formatted, err := logging.FormatEvent(logging.LogEvent{
+		Message: "Manifest received",
+		Fields:  map[string]interface{}{"message": strings.Repeat("kind: Project\n", 20), "project": "payments"},
+	})
+	require.NoError(t, err)
+	assert.Contains(t, formatted, "more characters")
+	assert.Contains(t, formatted, `"project":"payments"`)
+}
@@ -0,0 +1,148 @@
+package logging
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Redacted replaces secrets and tokens in logs, errors and chat responses
+const Redacted = "[REDACTED]"
+
+// minSecretLength keeps short values, which would match ordinary words, out of the registry
+const minSecretLength = 6
+
+// secrets holds the secret values known to the bot, such as resolved client secrets
+var secrets = struct {
+	sync.RWMutex
+	values []string // Longest first, so a secret containing another is replaced whole
+}{}
+
+// tokenPatterns match credentials the bot never registered, such as tokens echoed
+// back in API errors, in key=value and JSON form. The first group, if any, is kept.
+var tokenPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)((?:client[_-]?secret|secret|password|passwd|api[_-]?key|access[_-]?token|refresh[_-]?token|token)(?:=["']?|["']\s*:\s*["']))[^\s"',;&}]+`),
+	regexp.MustCompile(`(?i)((?:bearer|basic)\s+)[A-Za-z0-9._~+/=-]+`),
+	regexp.MustCompile(`eyJ[A-Za-z0-9_-]+\.eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`), // JWT
+	regexp.MustCompile(`\bhv[sbr]\.[A-Za-z0-9_-]{20,}`),                        // Vault token
+	regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}`),                       // Slack token
+}
+
+// RegisterSecret adds values that must never be logged or shown, such as a client
+// secret read from a file. Values shorter than six characters are ignored.
+func RegisterSecret(values ...string) {
+	secrets.Lock()
+	defer secrets.Unlock()
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if len(value) < minSecretLength || containsString(secrets.values, value) {
+			continue
+		}
+		secrets.values = append(secrets.values, value)
+	}
+	sort.SliceStable(secrets.values, func(i, j int) bool {
+		return len(secrets.values[i]) > len(secrets.values[j])
+	})
+}
+
+// Redact replaces registered secrets and anything that looks like a credential in s
+func Redact(s string) string {
+	if s == "" {
+		return s
+	}
+
+	secrets.RLock()
+	for _, value := range secrets.values {
+		s = strings.ReplaceAll(s, value, Redacted)
+	}
+	secrets.RUnlock()
+
+	for _, pattern := range tokenPatterns {
+		s = pattern.ReplaceAllString(s, "${1}"+Redacted)
+	}
+	return s
+}
+
+// RedactError returns err with secrets removed from its message. errors.Is and
+// errors.As still see the original error.
+func RedactError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := err.(*redactedError); ok {
+		return err
+	}
+	return &redactedError{err: err}
+}
+
+// redactedError hides secrets in the message of the error it wraps
+type redactedError struct {
+	err error
+}
+
+// Error implements the error interface
+func (e *redactedError) Error() string {
+	return Redact(e.err.Error())
+}
+
+// Unwrap returns the original error
+func (e *redactedError) Unwrap() error {
+	return e.err
+}
+
+// redactValue returns a field value that is safe to log
+func redactValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case string:
+		return Redact(v)
+	case []string:
+		result := make([]string, len(v))
+		for i, s := range v {
+			result[i] = Redact(s)
+		}
+		return result
+	case error:
+		return RedactError(v)
+	case time.Time, time.Duration, redactedJSON:
+		return v
+	case fmt.Stringer:
+		return Redact(v.String())
+	}
+
+	switch reflect.ValueOf(value).Kind() {
+	case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array, reflect.Ptr, reflect.Interface:
+		// Structs such as configs are logged as their redacted JSON
+		data, err := json.Marshal(value)
+		if err != nil {
+			return Redact(fmt.Sprintf("%+v", value))
+		}
+		return redactedJSON(Redact(string(data)))
+	default:
+		return value
+	}
+}
+
+// redactedJSON is a value already rendered as JSON and redacted, logged as is
+type redactedJSON string
+
+// MarshalJSON implements json.Marshaler
+func (r redactedJSON) MarshalJSON() ([]byte, error) {
+	return []byte(r), nil
+}
+
+// containsString reports whether values contains s
+func containsString(values []string, s string) bool {
+	for _, value := range values {
+		if value == s {
+			return true
+		}
+	}
+	return false
+}
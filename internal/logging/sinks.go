@@ -0,0 +1,262 @@
+package logging
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// Sink types
+const (
+	SinkStdout = "stdout"
+	SinkStderr = "stderr"
+	SinkFile   = "file" // Rotated once it reaches MaxBytes
+	SinkUDP    = "udp"  // Syslog-style datagrams to a local listener, such as a log shipper
+)
+
+// Sink is one destination logs are written to
+type Sink struct {
+	Type       string // stdout, stderr, file or udp
+	Format     string // json, console or pretty, defaults to the logger's format
+	Path       string // File sinks
+	MaxBytes   int64  // File sinks rotate at this size, 0 disables rotation
+	MaxBackups int    // Rotated files kept, 0 keeps them all
+	Address    string // UDP sinks, host:port
+}
+
+// newEncoder creates the encoder for a format. Pretty is the console format with
+// colored levels and short times, for reading logs in a terminal while developing.
+func newEncoder(format string) (zapcore.Encoder, error) {
+	encoderConfig := zapcore.EncoderConfig{
+		TimeKey:        "timestamp",
+		LevelKey:       "level",
+		NameKey:        "logger",
+		CallerKey:      "caller",
+		MessageKey:     "msg",
+		StacktraceKey:  "stacktrace",
+		LineEnding:     zapcore.DefaultLineEnding,
+		EncodeLevel:    zapcore.LowercaseLevelEncoder,
+		EncodeTime:     zapcore.ISO8601TimeEncoder,
+		EncodeDuration: zapcore.StringDurationEncoder,
+		EncodeCaller:   zapcore.ShortCallerEncoder,
+	}
+
+	switch format {
+	case "", "json":
+		return zapcore.NewJSONEncoder(encoderConfig), nil
+	case "console":
+		return zapcore.NewConsoleEncoder(encoderConfig), nil
+	case "pretty":
+		encoderConfig.EncodeLevel = zapcore.CapitalColorLevelEncoder
+		encoderConfig.EncodeTime = zapcore.TimeEncoderOfLayout("15:04:05.000")
+		encoderConfig.ConsoleSeparator = "  "
+		return zapcore.NewConsoleEncoder(encoderConfig), nil
+	default:
+		return nil, fmt.Errorf("unsupported log format %q, use json, console or pretty", format)
+	}
+}
+
+// newSinkCore creates the core writing to a sink. Every level is written, the
+// logger's Levels decide what reaches the sinks.
+func newSinkCore(sink Sink, format string) (zapcore.Core, error) {
+	if sink.Format != "" {
+		format = sink.Format
+	}
+	encoder, err := newEncoder(format)
+	if err != nil {
+		return nil, err
+	}
+
+	var output zapcore.WriteSyncer
+	switch sink.Type {
+	case "", SinkStderr:
+		output = zapcore.AddSync(os.Stderr)
+	case SinkStdout:
+		output = zapcore.AddSync(os.Stdout)
+	case SinkFile:
+		file, err := openRotatingFile(sink.Path, sink.MaxBytes, sink.MaxBackups)
+		if err != nil {
+			return nil, err
+		}
+		output = file
+	case SinkUDP:
+		return newUDPCore(sink.Address, encoder)
+	default:
+		return nil, fmt.Errorf("unsupported log sink %q, use stdout, stderr, file or udp", sink.Type)
+	}
+	return zapcore.NewCore(encoder, output, zapcore.DebugLevel), nil
+}
+
+// rotatingFile is a log file that is renamed aside once it reaches maxBytes, like
+// the audit log. The oldest rotated files beyond maxBackups are removed.
+type rotatingFile struct {
+	mu         sync.Mutex
+	path       string
+	maxBytes   int64
+	maxBackups int
+	file       *os.File
+	size       int64
+}
+
+// openRotatingFile opens a log file for appending
+func openRotatingFile(path string, maxBytes int64, maxBackups int) (*rotatingFile, error) {
+	if path == "" {
+		return nil, fmt.Errorf("file log sink needs a path")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create log directory: %w", err)
+	}
+	r := &rotatingFile{path: path, maxBytes: maxBytes, maxBackups: maxBackups}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// open opens the current file
+func (r *rotatingFile) open() error {
+	file, err := os.OpenFile(r.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	r.file = file
+	r.size = info.Size()
+	return nil
+}
+
+// Write appends p, rotating the file first if p would take it past maxBytes
+func (r *rotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.maxBytes > 0 && r.size > 0 && r.size+int64(len(p)) > r.maxBytes {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// Sync flushes the file
+func (r *rotatingFile) Sync() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.file.Sync()
+}
+
+// rotate renames the current file aside, starts a new one and prunes old files
+func (r *rotatingFile) rotate() error {
+	r.file.Close()
+	rotated := fmt.Sprintf("%s.%s", r.path, time.Now().UTC().Format("20060102T150405.000000000"))
+	if err := os.Rename(r.path, rotated); err != nil {
+		return fmt.Errorf("failed to rotate log file: %w", err)
+	}
+	if err := r.open(); err != nil {
+		return err
+	}
+	if r.maxBackups <= 0 {
+		return nil
+	}
+
+	backups, err := filepath.Glob(r.path + ".*")
+	if err != nil {
+		return nil
+	}
+	sort.Strings(backups)
+	for len(backups) > r.maxBackups {
+		os.Remove(backups[0])
+		backups = backups[1:]
+	}
+	return nil
+}
+
+// udpCore sends each entry as one syslog datagram (RFC 5424) with the severity
+// matching its level
+type udpCore struct {
+	zapcore.LevelEnabler
+	encoder  zapcore.Encoder
+	conn     net.Conn
+	hostname string
+}
+
+// newUDPCore connects a core to a syslog-style UDP listener
+func newUDPCore(address string, encoder zapcore.Encoder) (zapcore.Core, error) {
+	if address == "" {
+		return nil, fmt.Errorf("udp log sink needs an address")
+	}
+	conn, err := net.Dial("udp", address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect udp log sink: %w", err)
+	}
+	hostname, _ := os.Hostname()
+	if hostname == "" {
+		hostname = "-"
+	}
+	return &udpCore{LevelEnabler: zapcore.DebugLevel, encoder: encoder, conn: conn, hostname: hostname}, nil
+}
+
+// With implements zapcore.Core
+func (c *udpCore) With(fields []zapcore.Field) zapcore.Core {
+	encoder := c.encoder.Clone()
+	for _, field := range fields {
+		field.AddTo(encoder)
+	}
+	return &udpCore{LevelEnabler: c.LevelEnabler, encoder: encoder, conn: c.conn, hostname: c.hostname}
+}
+
+// Check implements zapcore.Core
+func (c *udpCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+	return checked
+}
+
+// Write implements zapcore.Core
+func (c *udpCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	buf, err := c.encoder.EncodeEntry(entry, fields)
+	if err != nil {
+		return err
+	}
+	defer buf.Free()
+
+	// Facility local0, as other daemons without a facility of their own use
+	priority := 16*8 + syslogSeverity(entry.Level)
+	message := fmt.Sprintf("<%d>1 %s %s nobl9-bot %d - - %s",
+		priority, entry.Time.UTC().Format(time.RFC3339Nano), c.hostname, os.Getpid(), strings.TrimRight(buf.String(), "\n"))
+	_, err = c.conn.Write([]byte(message))
+	return err
+}
+
+// Sync implements zapcore.Core
+func (c *udpCore) Sync() error {
+	return nil
+}
+
+// syslogSeverity maps a level to its syslog severity
+func syslogSeverity(level zapcore.Level) int {
+	switch {
+	case level >= zapcore.ErrorLevel:
+		return 3
+	case level == zapcore.WarnLevel:
+		return 4
+	case level == zapcore.InfoLevel:
+		return 6
+	default:
+		return 7
+	}
+}
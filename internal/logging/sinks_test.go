@@ -0,0 +1,116 @@
+package logging_test
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dfaile/backstage-nobl9/internal/logging"
+)
+
+func TestFileSinkRotation(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "bot.log")
+	logger, err := logging.NewLoggerWithOptions(logging.Options{
+		Level: logging.LevelInfo,
+		Sinks: []logging.Sink{{Type: logging.SinkFile, Path: path, MaxBytes: 300, MaxBackups: 2}},
+	})
+	require.NoError(t, err)
+
+	for i := 0; i < 20; i++ {
+		logger.Info("Project created", logging.F("project", strings.Repeat("p", 50)))
+	}
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.LessOrEqual(t, len(data), 300)
+	assert.Contains(t, string(data), "Project created")
+
+	backups, err := filepath.Glob(path + ".*")
+	require.NoError(t, err)
+	assert.Len(t, backups, 2)
+}
+
+func TestUDPSink(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	logger, err := logging.NewLoggerWithOptions(logging.Options{
+		Level: logging.LevelInfo,
+		Sinks: []logging.Sink{{Type: logging.SinkUDP, Address: conn.LocalAddr().String()}},
+	})
+	require.NoError(t, err)
+	logger.Error("Failed to apply manifest", logging.F("project", "payments"))
+
+	buf := make([]byte, 4096)
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	n, _, err := conn.ReadFrom(buf)
+	require.NoError(t, err)
+
+	message := string(buf[:n])
+	// local0.err
+	assert.True(t, strings.HasPrefix(message, "<131>1 "), message)
+	assert.Contains(t, message, " nobl9-bot ")
+	assert.Contains(t, message, `"msg":"Failed to apply manifest"`)
+	assert.Contains(t, message, `"project":"payments"`)
+}
+
+func TestPrettyFormat(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bot.log")
+	logger, err := logging.NewLoggerWithOptions(logging.Options{
+		Level:  logging.LevelInfo,
+		Format: "pretty",
+		Output: path,
+	})
+	require.NoError(t, err)
+	logger.Warn("Rate limited", logging.F("retry_in", "2s"))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "WARN")
+	assert.Contains(t, string(data), "Rate limited")
+	assert.Contains(t, string(data), `{"retry_in": "2s"}`)
+	// The caller is the code using the logger, not the logging package itself
+	assert.Contains(t, string(data), "logging/sinks_test.go")
+
+	_, err = logging.NewLoggerWithOptions(logging.Options{Format: "xml"})
+	assert.Error(t, err)
+	_, err = logging.NewLoggerWithOptions(logging.Options{Sinks: []logging.Sink{{Type: "kafka"}}})
+	assert.Error(t, err)
+}
+
+func TestPackageLevels(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bot.log")
+	levels, err := logging.NewLevels(logging.LevelWarn, map[string]logging.Level{"nobl9": logging.LevelDebug})
+	require.NoError(t, err)
+	logger, err := logging.NewLoggerWithOptions(logging.Options{Output: path, Levels: levels})
+	require.NoError(t, err)
+
+	logger.Info("first")
+	require.NoError(t, levels.Set("logging", logging.LevelDebug))
+	logger.Debug("second")
+	levels.Reset("logging")
+	logger.Debug("third")
+	require.NoError(t, levels.Set("", logging.LevelInfo))
+	logger.With(logging.F("tenant", "acme")).Info("fourth")
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "first")
+	assert.Contains(t, string(data), "second")
+	assert.NotContains(t, string(data), "third")
+	assert.Contains(t, string(data), "fourth")
+
+	assert.Equal(t, logging.LevelInfo, levels.Level())
+	assert.Equal(t, map[string]logging.Level{"nobl9": logging.LevelDebug}, levels.Packages())
+	assert.Error(t, levels.Set("bot", "verbose"))
+	_, err = logging.ParseLevel("trace")
+	assert.Error(t, err)
+}
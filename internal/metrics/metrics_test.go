@@ -1,10 +1,10 @@
-package metrics_test
+package metrics
 
 import (
 	"context"
+	"strings"
 	"testing"
 	"time"
-	"github.com/dfaile/backstage-nobl9/internal/metrics"
 )
 
 func TestNew(t *testing.T) {
@@ -75,7 +75,7 @@ func TestIncrement(t *testing.T) {
 
 	// Test non-existent metric
 	m.Increment("non_existent", 1.0)
-	if metric, exists := m.Get("non_existent"); exists {
+	if _, exists := m.Get("non_existent"); exists {
 		t.Error("expected metric to not exist")
 	}
 
@@ -105,7 +105,7 @@ func TestSet(t *testing.T) {
 
 	// Test non-existent metric
 	m.Set("non_existent", 1.0)
-	if metric, exists := m.Get("non_existent"); exists {
+	if _, exists := m.Get("non_existent"); exists {
 		t.Error("expected metric to not exist")
 	}
 
@@ -135,7 +135,7 @@ func TestObserve(t *testing.T) {
 
 	// Test non-existent metric
 	m.Observe("non_existent", 1.0)
-	if metric, exists := m.Get("non_existent"); exists {
+	if _, exists := m.Get("non_existent"); exists {
 		t.Error("expected metric to not exist")
 	}
 
@@ -198,4 +198,4 @@ func TestStartCollector(t *testing.T) {
 	if metric, _ := m.Get("test_metric"); metric.Value == 0.0 {
 		t.Error("expected metric to be updated by collector")
 	}
-} 
\ No newline at end of file
+}
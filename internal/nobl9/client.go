@@ -1,24 +1,42 @@
 package nobl9
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/nobl9/nobl9-go/manifest"
+	"github.com/nobl9/nobl9-go/manifest/v1alpha"
 	"github.com/nobl9/nobl9-go/manifest/v1alpha/project"
 	"github.com/nobl9/nobl9-go/manifest/v1alpha/rolebinding"
+	"github.com/nobl9/nobl9-go/manifest/v1alpha/service"
+	"github.com/nobl9/nobl9-go/manifest/v1alpha/slo"
 	"github.com/nobl9/nobl9-go/sdk"
 	objectsV1 "github.com/nobl9/nobl9-go/sdk/endpoints/objects/v1"
+
+	"github.com/dfaile/backstage-nobl9/internal/config"
+	"github.com/dfaile/backstage-nobl9/internal/errors"
+	"github.com/dfaile/backstage-nobl9/internal/gitops"
+	"github.com/dfaile/backstage-nobl9/internal/logging"
+	"github.com/dfaile/backstage-nobl9/internal/tracing"
 )
 
 // Project represents a Nobl9 project
 type Project struct {
-	Name        string    `json:"name"`
-	Description string    `json:"description"`
-	Owner       string    `json:"owner"`
-	CreatedAt   time.Time `json:"created_at"`
+	Name        string            `json:"name"`
+	Description string            `json:"description"`
+	Owner       string            `json:"owner"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	CreatedAt   time.Time         `json:"created_at"`
+	Branch      string            `json:"branch,omitempty"` // GitOps branch holding the change, empty when applied directly
 }
 
 // Role represents a Nobl9 role
@@ -34,10 +52,27 @@ type UserRole struct {
 	Roles     []string
 }
 
+// Nobl9 project roles used in RoleBindings
+const (
+	RoleProjectOwner  = "project-owner"
+	RoleProjectEditor = "project-editor"
+	RoleProjectViewer = "project-viewer"
+)
+
+// Annotations the bot stores on the projects it creates
+const (
+	AnnotationCreatedBy = "created-by"
+	AnnotationCreatedAt = "created-at"
+)
+
 // Client represents a Nobl9 API client using the official SDK
 type Client struct {
-	sdkClient *sdk.Client
-	org       string
+	mu                sync.RWMutex // Guards the SDK clients, which Reconnect replaces, and the GitOps repository
+	sdkClient         *sdk.Client
+	dryRunClient      *sdk.Client // Applies with server-side dry-run, nothing is persisted
+	settings          config.Nobl9Config
+	requestsPerSecond int
+	gitops            *gitops.Repository // Commits changes for review instead of applying them when set
 }
 
 // RateLimiter interface for handling rate limiting
@@ -47,24 +82,149 @@ type RateLimiter interface {
 	Failure()
 }
 
-// NewClient creates a new Nobl9 client using the official SDK
+// NewClient creates a new Nobl9 client using the official SDK. Empty arguments are
+// read by the SDK from its environment variables and the default sloctl context.
 func NewClient(clientID, clientSecret, org, baseURL string) (*Client, error) {
-	// The Nobl9 SDK recommends using sdk.DefaultClient() which handles configuration
-	// from environment variables, config files, and other sources automatically
-	sdkClient, err := sdk.DefaultClient()
+	return NewClientFromConfig(config.Nobl9Config{ClientID: clientID, ClientSecret: clientSecret, Organization: org, URL: baseURL})
+}
+
+// NewClientFromConfig creates a new Nobl9 client from the bot's nobl9 settings
+func NewClientFromConfig(settings config.Nobl9Config) (*Client, error) {
+	sdkClient, dryRunClient, err := newSDKClients(settings)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create Nobl9 SDK client: %w", err)
+		return nil, err
 	}
 
 	return &Client{
-		sdkClient: sdkClient,
-		org:       org,
+		sdkClient:    sdkClient,
+		dryRunClient: dryRunClient,
+		settings:     settings,
 	}, nil
 }
 
+// Reconnect replaces the SDK clients with ones built from settings. Calls already in
+// flight finish on the old clients. The current clients are kept if settings are rejected.
+func (c *Client) Reconnect(settings config.Nobl9Config) error {
+	sdkClient, dryRunClient, err := newSDKClients(settings)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sdkClient = sdkClient
+	c.dryRunClient = dryRunClient
+	c.settings = settings
+	c.limitTransports(c.requestsPerSecond)
+	return nil
+}
+
+// Settings returns the credentials and organization the client was last connected with
+func (c *Client) Settings() config.Nobl9Config {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.settings
+}
+
+// ForContext returns a new client connected with the named sloctl context. It
+// reads the same config file and shares this client's rate limit and GitOps
+// repository. The context's own credentials are used.
+func (c *Client) ForContext(name string) (*Client, error) {
+	c.mu.RLock()
+	settings := config.Nobl9Config{Context: name, ConfigFile: c.settings.ConfigFile}
+	requestsPerSecond, repo := c.requestsPerSecond, c.gitops
+	c.mu.RUnlock()
+
+	client, err := NewClientFromConfig(settings)
+	if err != nil {
+		return nil, err
+	}
+	client.SetRateLimit(requestsPerSecond)
+	client.SetGitOps(repo)
+	return client, nil
+}
+
+// newSDKClients builds the SDK client and its dry-run counterpart
+func newSDKClients(settings config.Nobl9Config) (*sdk.Client, *sdk.Client, error) {
+	sdkClient, err := newSDKClient(settings)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create Nobl9 SDK client: %w", err)
+	}
+
+	// WithDryRun switches a client permanently, so plans use a dedicated one
+	dryRunClient, err := newSDKClient(settings)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create Nobl9 SDK dry-run client: %w", err)
+	}
+	return sdkClient, dryRunClient.WithDryRun(), nil
+}
+
+// newSDKClient creates an SDK client, letting settings override the SDK's own
+// configuration from environment variables and the selected sloctl context
+func newSDKClient(settings config.Nobl9Config) (*sdk.Client, error) {
+	contextsPath, err := config.ContextsPath(settings)
+	if err != nil {
+		return nil, err
+	}
+	options := []sdk.ConfigOption{sdk.ConfigOptionFilePath(contextsPath)}
+	if settings.Context != "" {
+		options = append(options, sdk.ConfigOptionUseContext(settings.Context))
+	}
+	if settings.ClientID != "" || settings.ClientSecret != "" {
+		options = append(options, sdk.ConfigOptionWithCredentials(settings.ClientID, settings.ClientSecret))
+	}
+	sdkConfig, err := sdk.ReadConfig(options...)
+	if err != nil {
+		return nil, err
+	}
+	// Credentials read from config.toml or the SDK's variables must not be logged either
+	logging.RegisterSecret(sdkConfig.ClientSecret)
+	if settings.Organization != "" {
+		sdkConfig.Organization = settings.Organization
+	}
+	if settings.URL != "" {
+		baseURL, err := url.Parse(settings.URL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Nobl9 URL %q: %w", settings.URL, err)
+		}
+		sdkConfig.URL = baseURL
+	}
+	client, err := sdk.NewClient(sdkConfig)
+	if err != nil {
+		return nil, err
+	}
+	// One span per SDK call, covering the SDK's own retries
+	client.HTTP.Transport = tracing.Transport("nobl9", client.HTTP.Transport)
+	return client, nil
+}
+
+// CurrentContext returns the sloctl context the client is connected with
+func (c *Client) CurrentContext() string {
+	return c.api().Config.GetCurrentContext()
+}
+
+// Organization returns the Nobl9 organization the client is connected to
+func (c *Client) Organization() string {
+	return c.api().Config.Organization
+}
+
+// api returns the SDK client for the current connection
+func (c *Client) api() *sdk.Client {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.sdkClient
+}
+
+// dryRunAPI returns the dry-run SDK client for the current connection
+func (c *Client) dryRunAPI() *sdk.Client {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.dryRunClient
+}
+
 // GetProject retrieves a project by name
 func (c *Client) GetProject(ctx context.Context, name string) (*Project, error) {
-	projects, err := c.sdkClient.Objects().V1().GetV1alphaProjects(ctx, objectsV1.GetProjectsRequest{
+	projects, err := c.api().Objects().V1().GetV1alphaProjects(ctx, objectsV1.GetProjectsRequest{
 		Names: []string{name},
 	})
 	if err != nil {
@@ -75,17 +235,12 @@ func (c *Client) GetProject(ctx context.Context, name string) (*Project, error)
 		return nil, nil // Project not found
 	}
 
-	proj := projects[0]
-	return &Project{
-		Name:        proj.Metadata.Name,
-		Description: proj.Spec.Description,
-		CreatedAt:   time.Now(), // SDK doesn't expose creation time directly
-	}, nil
+	return projectFromSDK(projects[0]), nil
 }
 
 // ListProjects retrieves all projects in the organization
 func (c *Client) ListProjects(ctx context.Context) ([]*Project, error) {
-	projects, err := c.sdkClient.Objects().V1().GetV1alphaProjects(ctx, objectsV1.GetProjectsRequest{
+	projects, err := c.api().Objects().V1().GetV1alphaProjects(ctx, objectsV1.GetProjectsRequest{
 		// Empty Names slice means get all projects
 	})
 	if err != nil {
@@ -94,54 +249,328 @@ func (c *Client) ListProjects(ctx context.Context) ([]*Project, error) {
 
 	result := make([]*Project, len(projects))
 	for i, proj := range projects {
-		result[i] = &Project{
-			Name:        proj.Metadata.Name,
-			Description: proj.Spec.Description,
-			CreatedAt:   time.Now(), // SDK doesn't expose creation time directly
-		}
+		result[i] = projectFromSDK(proj)
 	}
 
 	return result, nil
 }
 
-// CreateProject creates a new project
-func (c *Client) CreateProject(ctx context.Context, name, description string) (*Project, error) {
+// CreateProject creates a new project with the given labels and makes owner its project owner.
+// The owner and creation time are recorded as annotations on the project.
+func (c *Client) CreateProject(ctx context.Context, name, description string, labels map[string]string, owner string) (*Project, error) {
+	objects := ProjectObjects(name, description, labels, owner)
+
+	// In GitOps mode the project and its owner binding are reviewed together
+	if repo := c.repo(); repo != nil {
+		branch, err := c.commit(ctx, repo, fmt.Sprintf("Create project %s", name), objects)
+		if err != nil {
+			return nil, fmt.Errorf("failed to commit project: %w", err)
+		}
+		created := projectFromSDK(objects[0].(project.Project))
+		created.Branch = branch
+		return created, nil
+	}
+
+	// The project and its owner binding are applied together, so a project is
+	// never left without its owner
+	if err := c.api().Objects().V1().Apply(ctx, objects); err != nil {
+		return nil, fmt.Errorf("failed to create project: %w", err)
+	}
+
+	return projectFromSDK(objects[0].(project.Project)), nil
+}
+
+// ProjectObjects builds the objects CreateProject applies: the project followed by
+// the owner's project-owner RoleBinding when owner is set
+func ProjectObjects(name, description string, labels map[string]string, owner string) []manifest.Object {
 	// Create project using the official SDK
 	proj := project.New(
 		project.Metadata{
 			Name:        name,
 			DisplayName: name,
+			Labels:      toV1alphaLabels(labels),
 		},
 		project.Spec{
 			Description: description,
 		},
 	)
+	return WithOwnership([]manifest.Object{proj}, owner)
+}
 
-	// Apply the project - note the correct type conversion
-	objects := []manifest.Object{proj}
-	if err := c.sdkClient.Objects().V1().Apply(ctx, objects); err != nil {
-		return nil, fmt.Errorf("failed to create project: %w", err)
+// WithOwnership annotates the Project in a bundle with its creator and appends a
+// project-owner RoleBinding for owner unless the bundle already grants one.
+func WithOwnership(objects []manifest.Object, owner string) []manifest.Object {
+	now := time.Now().UTC()
+
+	result := make([]manifest.Object, 0, len(objects)+1)
+	projectName := ""
+	hasOwnerBinding := false
+	for _, obj := range objects {
+		switch o := obj.(type) {
+		case project.Project:
+			// Copy so the caller's annotations are left untouched
+			annotations := make(v1alpha.MetadataAnnotations, len(o.Metadata.Annotations)+2)
+			for key, value := range o.Metadata.Annotations {
+				annotations[key] = value
+			}
+			for key, value := range creationAnnotations(owner, now) {
+				annotations[key] = value
+			}
+			o.Metadata.Annotations = annotations
+			projectName = o.Metadata.Name
+			obj = o
+		case rolebinding.RoleBinding:
+			if o.Spec.RoleRef == RoleProjectOwner && o.Spec.User != nil && *o.Spec.User == owner {
+				hasOwnerBinding = true
+			}
+		}
+		result = append(result, obj)
 	}
 
-	return &Project{
-		Name:        name,
-		Description: description,
-		CreatedAt:   time.Now(),
-	}, nil
+	if owner != "" && projectName != "" && !hasOwnerBinding {
+		result = append(result, NewRoleBinding(projectName, owner, RoleProjectOwner))
+	}
+	return result
+}
+
+// creationAnnotations returns the annotations recording who created a project and when
+func creationAnnotations(owner string, createdAt time.Time) v1alpha.MetadataAnnotations {
+	annotations := v1alpha.MetadataAnnotations{
+		AnnotationCreatedAt: createdAt.Format(time.RFC3339),
+	}
+	if owner != "" {
+		annotations[AnnotationCreatedBy] = owner
+	}
+	return annotations
 }
 
+// projectFromSDK converts an SDK project, preferring the bot's creation annotations
+// and falling back to the creation details reported by the API
+func projectFromSDK(proj project.Project) *Project {
+	result := &Project{
+		Name:        proj.Metadata.Name,
+		Description: proj.Spec.Description,
+		Owner:       proj.Metadata.Annotations[AnnotationCreatedBy],
+		Labels:      fromV1alphaLabels(proj.Metadata.Labels),
+	}
+	if result.Owner == "" {
+		result.Owner = proj.Spec.CreatedBy
+	}
+
+	createdAt := proj.Metadata.Annotations[AnnotationCreatedAt]
+	if createdAt == "" {
+		createdAt = proj.Spec.CreatedAt
+	}
+	if t, err := time.Parse(time.RFC3339, createdAt); err == nil {
+		result.CreatedAt = t
+	}
+
+	return result
+}
+
+// ApplyObjects applies a bundle of manifest objects in a single request. In GitOps mode
+// the objects are committed instead and the branch name is returned; summary describes
+// the change for the branch and commit message.
+func (c *Client) ApplyObjects(ctx context.Context, summary string, objects []manifest.Object) (string, error) {
+	if len(objects) == 0 {
+		return "", nil
+	}
+	if repo := c.repo(); repo != nil {
+		return c.commit(ctx, repo, summary, objects)
+	}
+	if err := c.api().Objects().V1().Apply(ctx, objects); err != nil {
+		return "", fmt.Errorf("failed to apply objects: %w", err)
+	}
+	return "", nil
+}
+
+// SetGitOps switches the client to committing changes into a git repository for
+// review instead of applying them. A nil repository restores direct apply.
+func (c *Client) SetGitOps(repo *gitops.Repository) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.gitops = repo
+}
+
+// GitOpsEnabled reports whether changes are committed for review instead of applied
+func (c *Client) GitOpsEnabled() bool {
+	return c.repo() != nil
+}
+
+// repo returns the GitOps repository changes are committed to, nil when they are applied
+func (c *Client) repo() *gitops.Repository {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.gitops
+}
+
+// commit writes objects to a new branch of repo. The commit message lists every object
+// so reviewers can see the change at a glance.
+func (c *Client) commit(ctx context.Context, repo *gitops.Repository, summary string, objects []manifest.Object) (string, error) {
+	message := fmt.Sprintf("%s\n\n%s", summary, DescribeObjects(objects))
+	return repo.Commit(ctx, summary, message, objects)
+}
+
+// toV1alphaLabels converts single-valued labels to the SDK representation
+func toV1alphaLabels(labels map[string]string) v1alpha.Labels {
+	if len(labels) == 0 {
+		return nil
+	}
+	result := make(v1alpha.Labels, len(labels))
+	for key, value := range labels {
+		result[key] = []string{value}
+	}
+	return result
+}
+
+// fromV1alphaLabels flattens SDK labels, keeping the first value of each key
+func fromV1alphaLabels(labels v1alpha.Labels) map[string]string {
+	if len(labels) == 0 {
+		return nil
+	}
+	result := make(map[string]string, len(labels))
+	for key, values := range labels {
+		if len(values) > 0 {
+			result[key] = values[0]
+		} else {
+			result[key] = ""
+		}
+	}
+	return result
+}
+
+// NameAvailability describes whether a project name can be used
+type NameAvailability struct {
+	Available   bool
+	Owners      []string // Owners of the existing project when the name is taken
+	Suggestions []string // Available alternative names when the name is taken
+}
+
+// maxNameSuggestions is the number of alternative names offered for a taken name
+const maxNameSuggestions = 3
+
 // ValidateProjectName checks if a project name is valid and available
 func (c *Client) ValidateProjectName(ctx context.Context, name string) (bool, string, error) {
-	project, err := c.GetProject(ctx, name)
+	availability, err := c.CheckProjectName(ctx, name, nil)
 	if err != nil {
 		return false, "", err
 	}
+	return availability.Available, strings.Join(availability.Owners, ", "), nil
+}
+
+// CheckProjectName checks whether a project name is available. When it is taken the
+// result lists the current owners and up to three available alternative names.
+// Alternatives are only offered if allowed accepts them, when allowed is not nil.
+func (c *Client) CheckProjectName(ctx context.Context, name string, allowed func(string) bool) (*NameAvailability, error) {
+	project, err := c.GetProject(ctx, name)
+	if err != nil {
+		return nil, err
+	}
 
 	if project == nil {
-		return true, "", nil // Project doesn't exist, name is available
+		return &NameAvailability{Available: true}, nil // Project doesn't exist, name is available
 	}
 
-	return false, project.Owner, nil // Project exists
+	owners, err := c.GetProjectOwners(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if len(owners) == 0 && project.Owner != "" {
+		owners = []string{project.Owner}
+	}
+
+	candidates := SuggestProjectNames(name)
+	if allowed != nil {
+		filtered := candidates[:0]
+		for _, candidate := range candidates {
+			if allowed(candidate) {
+				filtered = append(filtered, candidate)
+			}
+		}
+		candidates = filtered
+	}
+	suggestions, err := c.availableNames(ctx, candidates, maxNameSuggestions)
+	if err != nil {
+		return nil, err
+	}
+
+	return &NameAvailability{
+		Available:   false,
+		Owners:      owners,
+		Suggestions: suggestions,
+	}, nil
+}
+
+// GetProjectOwners returns the users and groups bound to the project-owner role in a project
+func (c *Client) GetProjectOwners(ctx context.Context, projectName string) ([]string, error) {
+	bindings, err := c.api().Objects().V1().GetV1alphaRoleBindings(ctx, objectsV1.GetRoleBindingsRequest{
+		Project: projectName,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get role bindings: %w", err)
+	}
+
+	owners := make([]string, 0)
+	for _, binding := range bindings {
+		if binding.Spec.RoleRef != RoleProjectOwner {
+			continue
+		}
+		switch {
+		case binding.Spec.User != nil:
+			owners = append(owners, *binding.Spec.User)
+		case binding.Spec.GroupRef != nil:
+			owners = append(owners, fmt.Sprintf("group %s", *binding.Spec.GroupRef))
+		}
+	}
+	return owners, nil
+}
+
+// availableNames returns up to limit candidates that are not used by an existing
+// project, checking all candidates in a single request
+func (c *Client) availableNames(ctx context.Context, candidates []string, limit int) ([]string, error) {
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+
+	existing, err := c.api().Objects().V1().GetV1alphaProjects(ctx, objectsV1.GetProjectsRequest{
+		Names: candidates,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to check alternative project names: %w", err)
+	}
+
+	taken := make(map[string]bool, len(existing))
+	for _, proj := range existing {
+		taken[proj.Metadata.Name] = true
+	}
+
+	available := make([]string, 0, limit)
+	for _, candidate := range candidates {
+		if taken[candidate] {
+			continue
+		}
+		available = append(available, candidate)
+		if len(available) == limit {
+			break
+		}
+	}
+	return available, nil
+}
+
+// SuggestProjectNames generates alternative project names derived from name.
+// Candidates respect the 63 character limit for project names.
+func SuggestProjectNames(name string) []string {
+	suffixes := []string{"-2", "-3", "-v2", "-new", "-4", "-5"}
+
+	candidates := make([]string, 0, len(suffixes))
+	for _, suffix := range suffixes {
+		base := name
+		if len(base)+len(suffix) > 63 {
+			base = strings.TrimRight(base[:63-len(suffix)], "-")
+		}
+		candidates = append(candidates, base+suffix)
+	}
+	return candidates
 }
 
 // ValidateUser checks if a user exists in Nobl9
@@ -196,82 +625,304 @@ func (c *Client) ValidateRoles(ctx context.Context, projectName, userEmail strin
 	return true, nil, nil
 }
 
-// AssignRoles assigns roles to users in a project using RoleBinding objects through the Nobl9 SDK
-func (c *Client) AssignRoles(ctx context.Context, projectName string, assignments map[string][]string) error {
+// AssignRoles assigns roles to users in a project using RoleBinding objects through the Nobl9 SDK.
+// In GitOps mode the bindings are committed instead and the branch name is returned.
+func (c *Client) AssignRoles(ctx context.Context, projectName string, assignments map[string][]string) (string, error) {
+	objects, err := c.RoleBindingObjects(ctx, projectName, assignments)
+	if err != nil {
+		return "", err
+	}
+
+	if len(objects) == 0 {
+		return "", nil
+	}
+
+	// In GitOps mode the bindings are committed for review instead
+	if repo := c.repo(); repo != nil {
+		branch, err := c.commit(ctx, repo, fmt.Sprintf("Assign roles in project %s", projectName), objects)
+		if err != nil {
+			return "", fmt.Errorf("failed to commit role bindings: %w", err)
+		}
+		return branch, nil
+	}
+
+	// Apply all RoleBinding objects
+	if err := c.api().Objects().V1().Apply(ctx, objects); err != nil {
+		return "", fmt.Errorf("failed to apply role bindings: %w", err)
+	}
+
+	return "", nil
+}
+
+// RoleBindingObjects validates the users and builds the RoleBinding objects for a set of
+// role assignments without applying them
+func (c *Client) RoleBindingObjects(ctx context.Context, projectName string, assignments map[string][]string) ([]manifest.Object, error) {
 	objects := make([]manifest.Object, 0)
 
 	for userEmail, roles := range assignments {
 		// Validate user exists (basic check)
 		exists, err := c.ValidateUser(ctx, userEmail)
 		if err != nil {
-			return fmt.Errorf("failed to validate user %s: %w", userEmail, err)
+			return nil, fmt.Errorf("failed to validate user %s: %w", userEmail, err)
 		}
 		if !exists {
-			return fmt.Errorf("user %s does not exist", userEmail)
+			return nil, fmt.Errorf("user %s does not exist", userEmail)
 		}
 
 		// Create RoleBinding for each role assignment
 		for _, role := range roles {
-			var nobl9Role string
-			switch role {
-			case "admin", "1":
-				nobl9Role = "project-owner" 
-			case "member", "2":
-				nobl9Role = "project-editor"
-			case "viewer", "3":
-				nobl9Role = "project-viewer"
-			default:
-				return fmt.Errorf("invalid role: %s. Valid roles are: admin, member, viewer", role)
-			}
-
-			// Generate a unique name for the role binding (RFC-1123 compliant)
-			// Replace @ with -at-, dots with -, underscores with -, and ensure lowercase
-			emailPart := strings.ReplaceAll(userEmail, "@", "-at-")
-			emailPart = strings.ReplaceAll(emailPart, ".", "-")
-			emailPart = strings.ReplaceAll(emailPart, "_", "-")
-			
-			// Also ensure the role part is RFC-1123 compliant
-			rolePart := strings.ReplaceAll(nobl9Role, "_", "-")
-			
-			roleBindingName := fmt.Sprintf("%s-%s-%s", projectName, emailPart, rolePart)
-			roleBindingName = strings.ToLower(roleBindingName)
-			
-			// Final cleanup to ensure RFC-1123 compliance
-			roleBindingName = strings.ReplaceAll(roleBindingName, "_", "-")
-			
-			if len(roleBindingName) > 63 {
-				// Truncate but keep it meaningful
-				suffix := fmt.Sprintf("-%s", rolePart)
-				maxPrefix := 63 - len(suffix)
-				roleBindingName = roleBindingName[:maxPrefix] + suffix
+			nobl9Role, err := MapRole(role)
+			if err != nil {
+				return nil, err
 			}
 
-			fmt.Printf("✅ Creating RoleBinding for user %s in project %s with role %s\n", userEmail, projectName, nobl9Role)
-			
 			// Create RoleBinding object using the Nobl9 SDK
-			roleBinding := rolebinding.New(
-				rolebinding.Metadata{
-					Name: roleBindingName,
-				},
-				rolebinding.Spec{
-					User:       &userEmail,  // User email
-					RoleRef:    nobl9Role,   // Role reference
-					ProjectRef: projectName, // Project reference
-				},
-			)
-
+			roleBinding := NewRoleBinding(projectName, userEmail, nobl9Role)
 			objects = append(objects, roleBinding)
 		}
 	}
 
-	// Apply all RoleBinding objects
-	if len(objects) > 0 {
-		fmt.Printf("🔄 Applying %d role binding(s) to Nobl9...\n", len(objects))
-		if err := c.sdkClient.Objects().V1().Apply(ctx, objects); err != nil {
-			return fmt.Errorf("failed to apply role bindings: %w", err)
+	return objects, nil
+}
+
+// ExportProject fetches a project and all of its RoleBindings, and optionally its
+// Services and SLOs, as a manifest bundle that can be re-applied with sloctl
+func (c *Client) ExportProject(ctx context.Context, name string, includeSLOs bool) ([]manifest.Object, error) {
+	objectsAPI := c.api().Objects().V1()
+
+	projects, err := objectsAPI.GetV1alphaProjects(ctx, objectsV1.GetProjectsRequest{
+		Names: []string{name},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get project: %w", err)
+	}
+	if len(projects) == 0 {
+		return nil, errors.NewNotFoundError(fmt.Sprintf("project %s does not exist", name), nil)
+	}
+
+	objects := []manifest.Object{projects[0]}
+
+	bindings, err := objectsAPI.GetV1alphaRoleBindings(ctx, objectsV1.GetRoleBindingsRequest{
+		Project: name,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get role bindings: %w", err)
+	}
+	for _, binding := range bindings {
+		objects = append(objects, binding)
+	}
+
+	if includeSLOs {
+		services, err := objectsAPI.GetV1alphaServices(ctx, objectsV1.GetServicesRequest{
+			Project: name,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get services: %w", err)
+		}
+		for _, svc := range services {
+			objects = append(objects, svc)
+		}
+
+		slos, err := objectsAPI.GetV1alphaSLOs(ctx, objectsV1.GetSLOsRequest{
+			Project: name,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get SLOs: %w", err)
+		}
+		for _, s := range slos {
+			objects = append(objects, s)
 		}
-		fmt.Printf("✅ Successfully applied all role bindings!\n")
 	}
-	
+
+	return SanitizeForExport(objects), nil
+}
+
+// SanitizeForExport strips the organization, manifest source, status and other
+// server-populated fields so exported objects can be applied to any organization
+func SanitizeForExport(objects []manifest.Object) []manifest.Object {
+	result := make([]manifest.Object, len(objects))
+	for i, obj := range objects {
+		switch o := obj.(type) {
+		case project.Project:
+			o.Organization = ""
+			o.ManifestSource = ""
+			o.Spec.CreatedAt = ""
+			o.Spec.CreatedBy = ""
+			obj = o
+		case rolebinding.RoleBinding:
+			o.Organization = ""
+			o.ManifestSource = ""
+			obj = o
+		case service.Service:
+			o.Organization = ""
+			o.ManifestSource = ""
+			o.Status = nil
+			obj = o
+		case slo.SLO:
+			o.Organization = ""
+			o.ManifestSource = ""
+			o.Status = nil
+			obj = o
+		}
+		result[i] = obj
+	}
+	return result
+}
+
+// DescribeObjects renders a one-line summary per object for previews
+func DescribeObjects(objects []manifest.Object) string {
+	var sb strings.Builder
+	for i, obj := range objects {
+		sb.WriteString(fmt.Sprintf("%d. %s", i+1, DescribeObject(obj)))
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// DescribeObject summarizes an object and the details that matter for review
+func DescribeObject(obj manifest.Object) string {
+	switch o := obj.(type) {
+	case project.Project:
+		description := fmt.Sprintf("Project %s", o.Metadata.Name)
+		if labels := fromV1alphaLabels(o.Metadata.Labels); len(labels) > 0 {
+			pairs := make([]string, 0, len(labels))
+			for key, value := range labels {
+				pairs = append(pairs, fmt.Sprintf("%s=%s", key, value))
+			}
+			sort.Strings(pairs)
+			description += fmt.Sprintf(" (labels: %s)", strings.Join(pairs, ", "))
+		}
+		return description
+	case rolebinding.RoleBinding:
+		subject := "nobody"
+		switch {
+		case o.Spec.User != nil:
+			subject = fmt.Sprintf("user %s", *o.Spec.User)
+		case o.Spec.GroupRef != nil:
+			subject = fmt.Sprintf("group %s", *o.Spec.GroupRef)
+		}
+		return fmt.Sprintf("RoleBinding %s: grants %s to %s in project %s", o.Metadata.Name, o.Spec.RoleRef, subject, o.Spec.ProjectRef)
+	}
+	if scoped, ok := obj.(manifest.ProjectScopedObject); ok {
+		return fmt.Sprintf("%s %s (project %s)", obj.GetKind(), obj.GetName(), scoped.GetProject())
+	}
+	return fmt.Sprintf("%s %s", obj.GetKind(), obj.GetName())
+}
+
+// DryRunApply sends objects to the API in server-side dry-run mode. The API validates
+// them exactly as it would for a real apply but persists nothing.
+func (c *Client) DryRunApply(ctx context.Context, objects []manifest.Object) error {
+	if len(objects) == 0 {
+		return nil
+	}
+	if err := c.dryRunAPI().Objects().V1().Apply(ctx, objects); err != nil {
+		return fmt.Errorf("dry-run apply failed: %w", err)
+	}
 	return nil
-} 
\ No newline at end of file
+}
+
+// EncodeObjects renders objects as a YAML or JSON manifest
+func EncodeObjects(objects []manifest.Object, format manifest.ObjectFormat) (string, error) {
+	var buf bytes.Buffer
+	if err := sdk.EncodeObjects(objects, &buf, format); err != nil {
+		return "", fmt.Errorf("failed to encode objects: %w", err)
+	}
+	return buf.String(), nil
+}
+
+var (
+	roleMappingsMu sync.RWMutex
+	roleMappings   = config.Default().RoleMappings
+)
+
+// SetRoleMappings sets the role names users pick from and the Nobl9 project role each grants
+func SetRoleMappings(mappings []config.RoleMapping) {
+	roleMappingsMu.Lock()
+	defer roleMappingsMu.Unlock()
+	roleMappings = append([]config.RoleMapping(nil), mappings...)
+}
+
+// RoleNames returns the role names users pick from, in menu order
+func RoleNames() []string {
+	roleMappingsMu.RLock()
+	defer roleMappingsMu.RUnlock()
+	names := make([]string, len(roleMappings))
+	for i, mapping := range roleMappings {
+		names[i] = mapping.Name
+	}
+	return names
+}
+
+// DefaultRoleName returns the role offered by default: member when it is configured,
+// otherwise the least privileged role
+func DefaultRoleName() string {
+	names := RoleNames()
+	for _, name := range names {
+		if name == "member" {
+			return name
+		}
+	}
+	if len(names) == 0 {
+		return ""
+	}
+	return names[len(names)-1]
+}
+
+// MapRole converts a bot role name or menu number to a Nobl9 project role
+func MapRole(role string) (string, error) {
+	roleMappingsMu.RLock()
+	defer roleMappingsMu.RUnlock()
+	for i, mapping := range roleMappings {
+		if strings.EqualFold(role, mapping.Name) || role == strconv.Itoa(i+1) {
+			return mapping.Role, nil
+		}
+	}
+	names := make([]string, len(roleMappings))
+	for i, mapping := range roleMappings {
+		names[i] = mapping.Name
+	}
+	return "", fmt.Errorf("invalid role: %s. Valid roles are: %s", role, strings.Join(names, ", "))
+}
+
+// NewRoleBinding creates a project RoleBinding for a user
+func NewRoleBinding(projectName, userEmail, nobl9Role string) rolebinding.RoleBinding {
+	user := userEmail
+	return rolebinding.New(
+		rolebinding.Metadata{
+			Name: RoleBindingName(projectName, userEmail, nobl9Role),
+		},
+		rolebinding.Spec{
+			User:       &user,       // User email
+			RoleRef:    nobl9Role,   // Role reference
+			ProjectRef: projectName, // Project reference
+		},
+	)
+}
+
+// RoleBindingName generates a unique, RFC-1123 compliant name for a role binding
+func RoleBindingName(projectName, userEmail, nobl9Role string) string {
+	// Replace @ with -at-, dots with -, underscores with -, and ensure lowercase
+	emailPart := strings.ReplaceAll(userEmail, "@", "-at-")
+	emailPart = strings.ReplaceAll(emailPart, ".", "-")
+	emailPart = strings.ReplaceAll(emailPart, "_", "-")
+
+	// Also ensure the role part is RFC-1123 compliant
+	rolePart := strings.ReplaceAll(nobl9Role, "_", "-")
+
+	roleBindingName := fmt.Sprintf("%s-%s-%s", projectName, emailPart, rolePart)
+	roleBindingName = strings.ToLower(roleBindingName)
+
+	// Final cleanup to ensure RFC-1123 compliance
+	roleBindingName = strings.ReplaceAll(roleBindingName, "_", "-")
+
+	if len(roleBindingName) > 63 {
+		// Truncate but keep it meaningful. The truncated part may hold the whole
+		// email, so a hash of it keeps the names of different users apart.
+		sum := sha256.Sum256([]byte(strings.ToLower(userEmail) + "/" + nobl9Role))
+		suffix := fmt.Sprintf("-%s-%s", hex.EncodeToString(sum[:4]), rolePart)
+		maxPrefix := 63 - len(suffix)
+		roleBindingName = strings.TrimRight(roleBindingName[:maxPrefix], "-") + suffix
+	}
+
+	return roleBindingName
+}
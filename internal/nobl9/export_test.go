@@ -0,0 +1,64 @@
+package nobl9_test
+
+import (
+	"testing"
+
+	"github.com/nobl9/nobl9-go/manifest"
+	"github.com/nobl9/nobl9-go/manifest/v1alpha/project"
+	"github.com/nobl9/nobl9-go/manifest/v1alpha/rolebinding"
+	"github.com/nobl9/nobl9-go/manifest/v1alpha/service"
+	"github.com/nobl9/nobl9-go/sdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dfaile/backstage-nobl9/internal/nobl9"
+)
+
+func TestSanitizeForExport(t *testing.T) {
+	proj := project.New(project.Metadata{Name: "payments-api"}, project.Spec{
+		Description: "Payments",
+		CreatedAt:   "2024-03-19T10:00:00Z",
+		CreatedBy:   "someone",
+	})
+	proj.Organization = "acme"
+	proj.ManifestSource = "/tmp/project.yaml"
+
+	binding := nobl9.NewRoleBinding("payments-api", "owner@example.com", nobl9.RoleProjectOwner)
+	binding.Organization = "acme"
+
+	svc := service.New(service.Metadata{Name: "api", Project: "payments-api"}, service.Spec{})
+	svc.Organization = "acme"
+	svc.Status = &service.Status{SloCount: 2}
+
+	result := nobl9.SanitizeForExport([]manifest.Object{proj, binding, svc})
+	require.Len(t, result, 3)
+
+	exportedProject := result[0].(project.Project)
+	assert.Empty(t, exportedProject.Organization)
+	assert.Empty(t, exportedProject.ManifestSource)
+	assert.Empty(t, exportedProject.Spec.CreatedAt)
+	assert.Empty(t, exportedProject.Spec.CreatedBy)
+	assert.Equal(t, "Payments", exportedProject.Spec.Description)
+
+	assert.Empty(t, result[1].(rolebinding.RoleBinding).Organization)
+
+	exportedService := result[2].(service.Service)
+	assert.Empty(t, exportedService.Organization)
+	assert.Nil(t, exportedService.Status)
+
+	// The exported bundle decodes back into the same objects
+	rendered, err := nobl9.EncodeObjects(result, manifest.ObjectFormatYAML)
+	require.NoError(t, err)
+	decoded, err := sdk.DecodeObjects([]byte(rendered))
+	require.NoError(t, err)
+	assert.Len(t, decoded, 3)
+	assert.NotContains(t, rendered, "organization")
+}
+
+func TestDescribeObjects(t *testing.T) {
+	objects := nobl9.ProjectObjects("payments-api", "", map[string]string{"team": "payments"}, "owner@example.com")
+
+	description := nobl9.DescribeObjects(objects)
+	assert.Contains(t, description, "1. Project payments-api (labels: team=payments)")
+	assert.Contains(t, description, "2. RoleBinding payments-api-owner-at-example-com-project-owner: grants project-owner to user owner@example.com in project payments-api")
+}
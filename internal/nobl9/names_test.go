@@ -0,0 +1,30 @@
+package nobl9_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dfaile/backstage-nobl9/internal/nobl9"
+)
+
+func TestSuggestProjectNames(t *testing.T) {
+	suggestions := nobl9.SuggestProjectNames("payments-api")
+	assert.Equal(t, []string{
+		"payments-api-2",
+		"payments-api-3",
+		"payments-api-v2",
+		"payments-api-new",
+		"payments-api-4",
+		"payments-api-5",
+	}, suggestions)
+}
+
+func TestSuggestProjectNamesRespectsLengthLimit(t *testing.T) {
+	name := strings.Repeat("a", 60) + "-bc"
+	for _, suggestion := range nobl9.SuggestProjectNames(name) {
+		assert.LessOrEqual(t, len(suggestion), 63, suggestion)
+		assert.NotContains(t, suggestion, "--", suggestion)
+	}
+}
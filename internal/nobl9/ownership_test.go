@@ -0,0 +1,129 @@
+package nobl9_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nobl9/nobl9-go/manifest"
+	"github.com/nobl9/nobl9-go/manifest/v1alpha/project"
+	"github.com/nobl9/nobl9-go/manifest/v1alpha/rolebinding"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dfaile/backstage-nobl9/internal/nobl9"
+)
+
+func TestWithOwnership(t *testing.T) {
+	objects := []manifest.Object{
+		project.New(project.Metadata{Name: "payments-api"}, project.Spec{}),
+	}
+
+	result := nobl9.WithOwnership(objects, "owner@example.com")
+	require.Len(t, result, 2)
+
+	proj, ok := result[0].(project.Project)
+	require.True(t, ok)
+	assert.Equal(t, "owner@example.com", proj.Metadata.Annotations[nobl9.AnnotationCreatedBy])
+	_, err := time.Parse(time.RFC3339, proj.Metadata.Annotations[nobl9.AnnotationCreatedAt])
+	assert.NoError(t, err)
+
+	binding, ok := result[1].(rolebinding.RoleBinding)
+	require.True(t, ok)
+	assert.Equal(t, nobl9.RoleProjectOwner, binding.Spec.RoleRef)
+	assert.Equal(t, "payments-api", binding.Spec.ProjectRef)
+	assert.Equal(t, "owner@example.com", *binding.Spec.User)
+
+	// The input bundle is left untouched
+	original := objects[0].(project.Project)
+	assert.Nil(t, original.Metadata.Annotations)
+}
+
+func TestWithOwnershipKeepsExistingOwnerBinding(t *testing.T) {
+	objects := []manifest.Object{
+		project.New(project.Metadata{Name: "payments-api"}, project.Spec{}),
+		nobl9.NewRoleBinding("payments-api", "owner@example.com", nobl9.RoleProjectOwner),
+	}
+
+	result := nobl9.WithOwnership(objects, "owner@example.com")
+	assert.Len(t, result, 2)
+}
+
+func TestWithOwnershipWithoutOwner(t *testing.T) {
+	objects := []manifest.Object{
+		project.New(project.Metadata{Name: "payments-api"}, project.Spec{}),
+	}
+
+	result := nobl9.WithOwnership(objects, "")
+	require.Len(t, result, 1)
+
+	proj := result[0].(project.Project)
+	assert.NotContains(t, proj.Metadata.Annotations, nobl9.AnnotationCreatedBy)
+	assert.Contains(t, proj.Metadata.Annotations, nobl9.AnnotationCreatedAt)
+}
+
+func TestProjectObjects(t *testing.T) {
+	objects := nobl9.ProjectObjects("payments-api", "Payments", map[string]string{"team": "payments"}, "owner@example.com")
+	require.Len(t, objects, 2)
+
+	proj := objects[0].(project.Project)
+	assert.Equal(t, "Payments", proj.Spec.Description)
+	assert.Equal(t, []string{"payments"}, proj.Metadata.Labels["team"])
+	assert.Equal(t, "owner@example.com", proj.Metadata.Annotations[nobl9.AnnotationCreatedBy])
+	assert.Equal(t, manifest.KindRoleBinding, objects[1].GetKind())
+}
+
+func TestEncodeObjects(t *testing.T) {
+	objects := nobl9.ProjectObjects("payments-api", "Payments", nil, "owner@example.com")
+
+	out, err := nobl9.EncodeObjects(objects, manifest.ObjectFormatYAML)
+	require.NoError(t, err)
+	assert.Contains(t, out, "kind: Project")
+	assert.Contains(t, out, "kind: RoleBinding")
+	assert.Contains(t, out, "roleRef: project-owner")
+
+	out, err = nobl9.EncodeObjects(objects, manifest.ObjectFormatJSON)
+	require.NoError(t, err)
+	assert.Contains(t, out, `"kind": "Project"`)
+}
+
+func TestMapRole(t *testing.T) {
+	tests := map[string]string{
+		"admin":  nobl9.RoleProjectOwner,
+		"1":      nobl9.RoleProjectOwner,
+		"member": nobl9.RoleProjectEditor,
+		"2":      nobl9.RoleProjectEditor,
+		"viewer": nobl9.RoleProjectViewer,
+		"3":      nobl9.RoleProjectViewer,
+	}
+	for role, want := range tests {
+		got, err := nobl9.MapRole(role)
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+	}
+
+	_, err := nobl9.MapRole("superuser")
+	assert.Error(t, err)
+}
+
+func TestRoleBindingName(t *testing.T) {
+	name := nobl9.RoleBindingName("payments-api", "Jane.Doe_1@example.com", nobl9.RoleProjectOwner)
+	assert.Equal(t, "payments-api-jane-doe-1-at-example-com-project-owner", name)
+
+	long := nobl9.RoleBindingName(strings.Repeat("p", 50), "someone@example.com", nobl9.RoleProjectViewer)
+	assert.Len(t, long, 63)
+	assert.True(t, strings.HasSuffix(long, "-project-viewer"))
+
+	// Owners of a project whose name is at the length limit get bindings of their own
+	projectName := strings.Repeat("p", 63)
+	jane := nobl9.RoleBindingName(projectName, "jane@example.com", nobl9.RoleProjectOwner)
+	sam := nobl9.RoleBindingName(projectName, "sam@example.com", nobl9.RoleProjectOwner)
+	assert.NotEqual(t, jane, sam)
+	for _, binding := range []string{jane, sam} {
+		assert.LessOrEqual(t, len(binding), 63)
+		assert.Regexp(t, `^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`, binding)
+		assert.True(t, strings.HasSuffix(binding, "-project-owner"))
+	}
+	assert.Equal(t, jane, nobl9.RoleBindingName(projectName, "Jane@Example.com", nobl9.RoleProjectOwner))
+	assert.NotEqual(t, jane, nobl9.RoleBindingName(projectName, "jane@example.com", nobl9.RoleProjectEditor))
+}
@@ -2,9 +2,13 @@ package nobl9
 
 import (
 	"context"
+	"net/http"
+	"sync"
 	"time"
 
 	"golang.org/x/time/rate"
+
+	"github.com/dfaile/backstage-nobl9/internal/tracing"
 )
 
 // SimpleRateLimiter implements the RateLimiter interface using golang.org/x/time/rate
@@ -32,4 +36,69 @@ func (r *SimpleRateLimiter) Success() {
 // Failure records a failed API call
 func (r *SimpleRateLimiter) Failure() {
 	// No action needed for simple rate limiter
-} 
\ No newline at end of file
+}
+
+// limitedTransport waits for its rate limiter before every API request
+type limitedTransport struct {
+	base    http.RoundTripper
+	mu      sync.RWMutex
+	limiter RateLimiter // No limit when nil
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *limitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.mu.RLock()
+	limiter := t.limiter
+	t.mu.RUnlock()
+	if limiter == nil {
+		return t.base.RoundTrip(req)
+	}
+
+	_, span := tracing.Start(req.Context(), "nobl9.rate_limit_wait")
+	err := limiter.Wait(req.Context())
+	tracing.End(span, err)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || resp.StatusCode == http.StatusTooManyRequests {
+		limiter.Failure()
+	} else {
+		limiter.Success()
+	}
+	return resp, err
+}
+
+// SetRateLimit throttles API calls to requestsPerSecond. Zero removes the limit.
+func (c *Client) SetRateLimit(requestsPerSecond int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.requestsPerSecond = requestsPerSecond
+	c.limitTransports(requestsPerSecond)
+}
+
+// limitTransports installs the rate limit on the current SDK clients. c.mu must be held.
+func (c *Client) limitTransports(requestsPerSecond int) {
+	var limiter RateLimiter
+	if requestsPerSecond > 0 {
+		limiter = NewSimpleRateLimiter(requestsPerSecond, time.Second)
+	}
+	// Plans share the limit with real calls
+	for _, client := range []*http.Client{c.sdkClient.HTTP, c.dryRunClient.HTTP} {
+		if client == nil {
+			continue
+		}
+		transport, ok := client.Transport.(*limitedTransport)
+		if !ok {
+			base := client.Transport
+			if base == nil {
+				base = http.DefaultTransport
+			}
+			transport = &limitedTransport{base: base}
+			client.Transport = transport
+		}
+		transport.mu.Lock()
+		transport.limiter = limiter
+		transport.mu.Unlock()
+	}
+}
@@ -0,0 +1,386 @@
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/dfaile/backstage-nobl9/internal/authz"
+	"github.com/dfaile/backstage-nobl9/internal/command"
+	"github.com/dfaile/backstage-nobl9/internal/errors"
+	"github.com/dfaile/backstage-nobl9/internal/identity"
+	"github.com/dfaile/backstage-nobl9/internal/logging"
+	"github.com/dfaile/backstage-nobl9/internal/tracing"
+)
+
+// Prefix is the start of a plugin executable's name, the rest is its command
+const Prefix = "nobl9-bot-"
+
+// Protocol is the version of the request and response format plugins speak
+const Protocol = 1
+
+// ManifestTimeout bounds how long a plugin may take to print its manifest
+const ManifestTimeout = 5 * time.Second
+
+// DefaultTimeout bounds how long a plugin's command may run when no timeout is configured
+const DefaultTimeout = 30 * time.Second
+
+// Limits on what the bot reads back from a plugin
+const (
+	maxOutput = 1 << 20 // Bytes of stdout, the JSON response
+	maxStderr = 4 << 10 // Bytes of stderr kept to explain failures
+)
+
+// Capabilities a plugin declares to be given more than its arguments
+const (
+	CapabilityCaller  = "caller"        // The request includes who sent the command
+	CapabilityContext = "nobl9_context" // The request includes the Nobl9 context the bot is connected with
+	CapabilityMutates = "mutates"       // The plugin changes things, so each run is audited
+)
+
+var capabilities = []string{CapabilityCaller, CapabilityContext, CapabilityMutates}
+
+// validName matches the command names plugins may register
+var validName = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+// Manifest is what a plugin prints when run with --manifest. It describes the
+// command the plugin adds and what the plugin needs to run it.
+type Manifest struct {
+	Protocol     int      `json:"protocol"`
+	Description  string   `json:"description"`
+	Aliases      []string `json:"aliases,omitempty"`
+	Usage        string   `json:"usage,omitempty"` // Generated from the args and flags when empty
+	command.Spec          // Arguments and flags, checked before the plugin runs
+	Permissions  []string `json:"permissions,omitempty"`  // Required on top of plugins:run
+	Capabilities []string `json:"capabilities,omitempty"` // See the Capability constants
+	Timeout      int      `json:"timeout,omitempty"`      // Milliseconds, at most the configured plugin timeout
+}
+
+// Plugin is an external command loaded from an executable
+type Plugin struct {
+	Name     string // Command name, the executable's name without Prefix
+	Path     string
+	Manifest Manifest
+	Timeout  time.Duration // How long a run may take
+}
+
+// Request is sent to a plugin on stdin each time its command is run
+type Request struct {
+	Protocol int                 `json:"protocol"`
+	Command  string              `json:"command"`
+	Args     []string            `json:"args"`             // As typed, after quoting is removed
+	Values   map[string][]string `json:"values,omitempty"` // Arguments and flags parsed with the manifest's spec
+	Caller   *identity.Caller    `json:"caller,omitempty"` // Only with the caller capability
+	Context  Context             `json:"context"`
+}
+
+// Context describes the conversation a plugin's command was sent in
+type Context struct {
+	RequestID string                `json:"request_id,omitempty"`
+	Tenant    string                `json:"tenant,omitempty"`
+	PlanMode  bool                  `json:"plan_mode"`       // Preview changes without making them
+	Nobl9     *command.Nobl9Context `json:"nobl9,omitempty"` // Only with the nobl9_context capability
+}
+
+// Response is what a plugin prints on stdout once it has run
+type Response struct {
+	Message string         `json:"message"`
+	Error   *ResponseError `json:"error,omitempty"`
+}
+
+// ResponseError is a failure reported by a plugin. Type is one of the bot's
+// error types, e.g. validation_error or not_found_error.
+type ResponseError struct {
+	Type    errors.ErrorType `json:"type"`
+	Message string           `json:"message"`
+}
+
+// Discover finds the plugin executables in dirs. When two directories hold a
+// plugin with the same name, the one in the earlier directory is used.
+// Directories that do not exist are skipped.
+func Discover(dirs []string) ([]string, error) {
+	var paths []string
+	seen := make(map[string]bool)
+	for _, dir := range dirs {
+		if dir == "" {
+			continue
+		}
+		entries, err := os.ReadDir(dir)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read plugin directory %s: %w", dir, err)
+		}
+		for _, entry := range entries {
+			name, ok := strings.CutPrefix(entry.Name(), Prefix)
+			if !ok || seen[name] || !validName.MatchString(name) {
+				continue
+			}
+			path := filepath.Join(dir, entry.Name())
+			info, err := os.Stat(path)
+			if err != nil || !info.Mode().IsRegular() || info.Mode().Perm()&0111 == 0 {
+				continue
+			}
+			seen[name] = true
+			paths = append(paths, path)
+		}
+	}
+	return paths, nil
+}
+
+// Load runs the plugin at path with --manifest and checks what it prints.
+// maxTimeout caps how long the plugin's command may run, DefaultTimeout when
+// it is not positive, so a plugin never runs without a deadline.
+func Load(path string, maxTimeout time.Duration) (*Plugin, error) {
+	name := strings.TrimPrefix(filepath.Base(path), Prefix)
+
+	ctx, cancel := context.WithTimeout(context.Background(), ManifestTimeout)
+	defer cancel()
+	output, err := run(ctx, path, []string{"--manifest"}, nil)
+	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, errors.NewTimeoutError(fmt.Sprintf("plugin '%s' did not print its manifest within %s", name, ManifestTimeout), nil)
+		}
+		return nil, errors.NewInternalError(fmt.Sprintf("plugin '%s' failed to print its manifest", name), err)
+	}
+
+	var manifest Manifest
+	if err := json.Unmarshal(output, &manifest); err != nil {
+		return nil, errors.NewValidationError(fmt.Sprintf("plugin '%s' printed an invalid manifest", name), err)
+	}
+	if err := manifest.validate(); err != nil {
+		return nil, errors.NewValidationError(fmt.Sprintf("plugin '%s' has an invalid manifest: %s", name, err), nil)
+	}
+
+	timeout := maxTimeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	if requested := time.Duration(manifest.Timeout) * time.Millisecond; requested > 0 && requested < timeout {
+		timeout = requested
+	}
+	return &Plugin{Name: name, Path: path, Manifest: manifest, Timeout: timeout}, nil
+}
+
+// validate checks the manifest speaks the bot's protocol and declares nothing unknown
+func (m *Manifest) validate() error {
+	var problems []string
+	add := func(format string, a ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, a...))
+	}
+
+	if m.Protocol != Protocol {
+		add("protocol must be %d, got %d", Protocol, m.Protocol)
+	}
+	for _, alias := range m.Aliases {
+		if !validName.MatchString(alias) {
+			add("alias %q must be lowercase letters, digits and dashes", alias)
+		}
+	}
+	for i, arg := range m.Args {
+		if arg.Name == "" {
+			add("args[%d] has no name", i)
+		}
+		if arg.Type == command.TypeBool {
+			add("args[%d] may not be a bool, only flags may", i)
+		} else if !knownType(arg.Type) {
+			add("args[%d] has unknown type %q", i, arg.Type)
+		}
+		if arg.Repeated && i != len(m.Args)-1 {
+			add("args[%d] repeats but is not the last argument", i)
+		}
+	}
+	for i, flag := range m.Flags {
+		if flag.Name == "" {
+			add("flags[%d] has no name", i)
+		}
+		if flag.Type != command.TypeBool && !knownType(flag.Type) {
+			add("flags[%d] has unknown type %q", i, flag.Type)
+		}
+	}
+	for _, capability := range m.Capabilities {
+		if !contains(capabilities, capability) {
+			add("unknown capability %q, use %s", capability, strings.Join(capabilities, ", "))
+		}
+	}
+	if m.Timeout < 0 {
+		add("timeout must not be negative")
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// Has reports whether the plugin declared capability
+func (p *Plugin) Has(capability string) bool {
+	return contains(p.Manifest.Capabilities, capability)
+}
+
+// Command returns the command the plugin adds. It has no handler, the bot runs
+// the plugin with Run.
+func (p *Plugin) Command() *command.Command {
+	permissions := []authz.Permission{authz.PermissionPluginsRun}
+	for _, permission := range p.Manifest.Permissions {
+		permissions = append(permissions, authz.Permission(permission))
+	}
+	cmd := &command.Command{
+		Name:        p.Name,
+		Aliases:     p.Manifest.Aliases,
+		Description: p.Manifest.Description,
+		Usage:       p.Manifest.Usage,
+		Permissions: permissions,
+	}
+	if len(p.Manifest.Args) > 0 || len(p.Manifest.Flags) > 0 {
+		spec := p.Manifest.Spec
+		cmd.Spec = &spec
+	}
+	return cmd
+}
+
+// Run sends req to the plugin and returns its message. Errors the plugin reports
+// keep their type, anything else going wrong is an internal error.
+func (p *Plugin) Run(ctx context.Context, req Request) (string, error) {
+	ctx, span := tracing.Start(ctx, "plugin.run", attribute.String("plugin.name", p.Name))
+	message, err := p.run(ctx, req)
+	tracing.End(span, err)
+	return message, err
+}
+
+// run runs the plugin for Run
+func (p *Plugin) run(ctx context.Context, req Request) (string, error) {
+	req.Protocol = Protocol
+	req.Command = p.Name
+	input, err := json.Marshal(req)
+	if err != nil {
+		return "", errors.NewInternalError(fmt.Sprintf("failed to encode the request for plugin '%s'", p.Name), err)
+	}
+
+	if p.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.Timeout)
+		defer cancel()
+	}
+	output, err := run(ctx, p.Path, nil, input)
+	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return "", errors.NewTimeoutError(fmt.Sprintf("plugin '%s' did not finish within %s", p.Name, p.Timeout), nil)
+		}
+		return "", errors.NewInternalError(fmt.Sprintf("plugin '%s' failed", p.Name), err)
+	}
+
+	var resp Response
+	if err := json.Unmarshal(output, &resp); err != nil {
+		return "", errors.NewInternalError(fmt.Sprintf("plugin '%s' returned an invalid response", p.Name), err)
+	}
+	if resp.Error != nil {
+		return "", responseError(resp.Error)
+	}
+	return resp.Message, nil
+}
+
+// responseError turns an error reported by a plugin into a bot error of the same type
+func responseError(e *ResponseError) error {
+	switch e.Type {
+	case errors.ErrorTypeValidation:
+		return errors.NewValidationError(e.Message, nil)
+	case errors.ErrorTypeNotFound:
+		return errors.NewNotFoundError(e.Message, nil)
+	case errors.ErrorTypeConflict:
+		return errors.NewConflictError(e.Message, nil)
+	case errors.ErrorTypeRateLimit:
+		return errors.NewRateLimitError(e.Message, nil)
+	case errors.ErrorTypeTimeout:
+		return errors.NewTimeoutError(e.Message, nil)
+	case errors.ErrorTypePermission:
+		return errors.NewPermissionError(e.Message, nil)
+	default:
+		return errors.NewInternalError(e.Message, nil)
+	}
+}
+
+// run executes path with args and input on stdin, returning what it wrote to stdout
+func run(ctx context.Context, path string, args []string, input []byte) ([]byte, error) {
+	var stdout, stderr limitedBuffer
+	stdout.limit, stderr.limit = maxOutput, maxStderr
+
+	cmd := exec.CommandContext(ctx, path, args...)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	cmd.Env = environment()
+	cmd.WaitDelay = time.Second
+
+	if err := cmd.Run(); err != nil {
+		if message := strings.TrimSpace(logging.Redact(stderr.String())); message != "" {
+			return nil, fmt.Errorf("%w: %s", err, message)
+		}
+		return nil, err
+	}
+	if stdout.truncated {
+		return nil, fmt.Errorf("output is larger than %d bytes", maxOutput)
+	}
+	return stdout.Bytes(), nil
+}
+
+// environment returns the bot's environment without its credentials, so plugins
+// only get the Nobl9 access the request gives them
+func environment() []string {
+	var env []string
+	for _, kv := range os.Environ() {
+		if strings.HasPrefix(kv, "NOBL9_") || logging.Redact(kv) != kv {
+			continue
+		}
+		env = append(env, kv)
+	}
+	return env
+}
+
+// limitedBuffer keeps the first limit bytes written to it and drops the rest
+type limitedBuffer struct {
+	bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+// Write implements io.Writer, reporting everything as written so the plugin is not stopped
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - b.Len(); room < len(p) {
+		b.truncated = true
+		if room > 0 {
+			b.Buffer.Write(p[:room])
+		}
+		return len(p), nil
+	}
+	return b.Buffer.Write(p)
+}
+
+// knownType reports whether t is an argument type the bot can parse
+func knownType(t command.ArgType) bool {
+	switch t {
+	case "", command.TypeString, command.TypeInt, command.TypeKeyValue:
+		return true
+	}
+	return false
+}
+
+// contains reports whether values holds value
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,177 @@
+package plugin_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dfaile/backstage-nobl9/internal/authz"
+	"github.com/dfaile/backstage-nobl9/internal/command"
+	"github.com/dfaile/backstage-nobl9/internal/errors"
+	"github.com/dfaile/backstage-nobl9/internal/identity"
+	"github.com/dfaile/backstage-nobl9/internal/plugin"
+)
+
+const oncallManifest = `{
+  "protocol": 1,
+  "description": "Show who is on call for a team",
+  "aliases": ["who"],
+  "args": [{"name": "team", "required": true}],
+  "flags": [{"name": "format", "choices": ["text", "json"], "default": "text"}],
+  "permissions": ["projects:read"],
+  "capabilities": ["caller"],
+  "timeout": 2000
+}`
+
+// writePlugin writes a shell script plugin named nobl9-bot-<name> to dir. It
+// prints manifest when run with --manifest, otherwise it saves its request to
+// request.json in dir and runs body.
+func writePlugin(t *testing.T, dir, name, manifest, body string) string {
+	t.Helper()
+	path := filepath.Join(dir, plugin.Prefix+name)
+	script := "#!/bin/sh\n" +
+		"if [ \"$1\" = --manifest ]; then\ncat <<'EOF'\n" + manifest + "\nEOF\nexit 0\nfi\n" +
+		"cat > " + filepath.Join(dir, "request.json") + "\n" +
+		body + "\n"
+	require.NoError(t, os.WriteFile(path, []byte(script), 0755))
+	return path
+}
+
+func TestDiscover(t *testing.T) {
+	first, second := t.TempDir(), t.TempDir()
+	writePlugin(t, first, "oncall", oncallManifest, "")
+	writePlugin(t, second, "oncall", oncallManifest, "")
+	writePlugin(t, second, "report", oncallManifest, "")
+	writePlugin(t, second, "Bad_Name", oncallManifest, "")
+	require.NoError(t, os.WriteFile(filepath.Join(second, plugin.Prefix+"notes"), []byte("not executable"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(second, "other-tool"), []byte("#!/bin/sh\n"), 0755))
+
+	paths, err := plugin.Discover([]string{first, filepath.Join(first, "missing"), second})
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(first, plugin.Prefix+"oncall"),
+		filepath.Join(second, plugin.Prefix+"report"),
+	}, paths)
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	p, err := plugin.Load(writePlugin(t, dir, "oncall", oncallManifest, ""), 30*time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, "oncall", p.Name)
+	assert.Equal(t, 2*time.Second, p.Timeout)
+	assert.True(t, p.Has(plugin.CapabilityCaller))
+	assert.False(t, p.Has(plugin.CapabilityMutates))
+
+	cmd := p.Command()
+	registry := command.NewCommandRegistry()
+	registry.Register(cmd)
+	assert.Equal(t, "oncall [--format text|json] <team>", cmd.Usage)
+	assert.Equal(t, []authz.Permission{authz.PermissionPluginsRun, authz.PermissionProjectsRead}, cmd.Permissions)
+	got, ok := registry.Get("who")
+	require.True(t, ok)
+	assert.Same(t, cmd, got)
+
+	// The configured timeout caps the one the plugin asks for
+	p, err = plugin.Load(writePlugin(t, dir, "oncall", oncallManifest, ""), time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, time.Second, p.Timeout)
+
+	// Without a configured timeout plugins still get a deadline
+	p, err = plugin.Load(writePlugin(t, dir, "report", `{"protocol": 1}`, ""), 0)
+	require.NoError(t, err)
+	assert.Equal(t, plugin.DefaultTimeout, p.Timeout)
+}
+
+func TestLoadInvalidManifest(t *testing.T) {
+	dir := t.TempDir()
+
+	_, err := plugin.Load(writePlugin(t, dir, "bad", `{"protocol": 2, "args": [{"name": "a", "repeated": true}, {"name": "b", "type": "bool"}], "capabilities": ["credentials"]}`, ""), time.Second)
+	assert.True(t, errors.IsValidationError(err))
+	assert.Contains(t, err.Error(), "protocol must be 1, got 2")
+	assert.Contains(t, err.Error(), "args[0] repeats but is not the last argument")
+	assert.Contains(t, err.Error(), "args[1] may not be a bool")
+	assert.Contains(t, err.Error(), `unknown capability "credentials"`)
+
+	_, err = plugin.Load(writePlugin(t, dir, "garbled", "not json", ""), time.Second)
+	assert.True(t, errors.IsValidationError(err))
+	assert.Contains(t, err.Error(), "plugin 'garbled' printed an invalid manifest")
+}
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	p, err := plugin.Load(writePlugin(t, dir, "oncall", oncallManifest, `echo '{"message": "alice is on call"}'`), time.Second)
+	require.NoError(t, err)
+
+	caller := identity.Caller{ID: "U123", Source: identity.SourceSlack}
+	message, err := p.Run(context.Background(), plugin.Request{
+		Args:    []string{"payments"},
+		Values:  map[string][]string{"team": {"payments"}, "format": {"text"}},
+		Caller:  &caller,
+		Context: plugin.Context{RequestID: "req-1", PlanMode: true},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "alice is on call", message)
+
+	data, err := os.ReadFile(filepath.Join(dir, "request.json"))
+	require.NoError(t, err)
+	var req plugin.Request
+	require.NoError(t, json.Unmarshal(data, &req))
+	assert.Equal(t, plugin.Protocol, req.Protocol)
+	assert.Equal(t, "oncall", req.Command)
+	assert.Equal(t, []string{"payments"}, req.Args)
+	assert.Equal(t, []string{"payments"}, req.Values["team"])
+	assert.Equal(t, &caller, req.Caller)
+	assert.Equal(t, "req-1", req.Context.RequestID)
+	assert.True(t, req.Context.PlanMode)
+}
+
+func TestRunErrors(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name  string
+		body  string
+		check func(error) bool
+		want  string
+	}{
+		{"reported", `echo '{"error": {"type": "not_found_error", "message": "no team called payments"}}'`, errors.IsNotFoundError, "no team called payments"},
+		{"unknown-type", `echo '{"error": {"type": "oops", "message": "broke"}}'`, errors.IsInternalError, "broke"},
+		{"invalid", `echo 'done'`, errors.IsInternalError, "plugin 'invalid' returned an invalid response"},
+		{"exit", `echo 'pager api unreachable' >&2; exit 3`, errors.IsInternalError, "pager api unreachable"},
+		{"slow", `sleep 5`, errors.IsTimeoutError, "plugin 'slow' did not finish within 200ms"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := plugin.Load(writePlugin(t, dir, tt.name, `{"protocol": 1}`, tt.body), 200*time.Millisecond)
+			require.NoError(t, err)
+			_, err = p.Run(context.Background(), plugin.Request{})
+			require.Error(t, err)
+			assert.True(t, tt.check(err), err.Error())
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+}
+
+func TestRunHidesCredentials(t *testing.T) {
+	t.Setenv("NOBL9_SDK_CLIENT_SECRET", "s3cr3t-value")
+	t.Setenv("PAGER_API_TOKEN", "token=abcdef123456")
+	t.Setenv("PLUGIN_TEST_SETTING", "kept")
+
+	dir := t.TempDir()
+	p, err := plugin.Load(writePlugin(t, dir, "env", `{"protocol": 1}`, `env > `+filepath.Join(dir, "env.txt")+`; echo '{}'`), time.Second)
+	require.NoError(t, err)
+	_, err = p.Run(context.Background(), plugin.Request{})
+	require.NoError(t, err)
+
+	env, err := os.ReadFile(filepath.Join(dir, "env.txt"))
+	require.NoError(t, err)
+	assert.Contains(t, string(env), "PLUGIN_TEST_SETTING=kept")
+	assert.NotContains(t, string(env), "s3cr3t-value")
+	assert.NotContains(t, string(env), "abcdef123456")
+}
@@ -2,8 +2,10 @@ package recovery
 
 import (
 	"fmt"
+	"sync"
 	"time"
 
+	"github.com/dfaile/backstage-nobl9/internal/config"
 	"github.com/dfaile/backstage-nobl9/internal/errors"
 )
 
@@ -27,6 +29,63 @@ type Recovery struct {
 	Message     string
 }
 
+// Policy controls how an error type is retried
+type Policy struct {
+	MaxAttempts int
+	Delay       time.Duration // Before the first retry
+	MaxDelay    time.Duration // The delay doubles with each attempt up to this, when longer than Delay
+}
+
+var (
+	policiesMu sync.RWMutex
+	policies   = defaultPolicies()
+)
+
+// defaultPolicies returns the retry policies used until Configure is called
+func defaultPolicies() map[errors.ErrorType]Policy {
+	return map[errors.ErrorType]Policy{
+		errors.ErrorTypeRateLimit: {MaxAttempts: 3, Delay: 5 * time.Second},
+		errors.ErrorTypeTimeout:   {MaxAttempts: 2, Delay: 2 * time.Second},
+	}
+}
+
+// Configure replaces the retry policies with the configured rate limit backoff and
+// retry policies. Error kinds without a policy are not retried.
+func Configure(limits config.RateLimitConfig, retry map[string]config.RetryPolicy) {
+	configured := map[errors.ErrorType]Policy{
+		errors.ErrorTypeRateLimit: {
+			MaxAttempts: limits.MaxRetries,
+			Delay:       time.Duration(limits.InitialDelay) * time.Millisecond,
+			MaxDelay:    time.Duration(limits.MaxDelay) * time.Millisecond,
+		},
+	}
+	kinds := map[string]errors.ErrorType{
+		config.RetryTimeout:  errors.ErrorTypeTimeout,
+		config.RetryInternal: errors.ErrorTypeInternal,
+	}
+	for kind, policy := range retry {
+		if errorType, ok := kinds[kind]; ok {
+			configured[errorType] = Policy{
+				MaxAttempts: policy.MaxAttempts,
+				Delay:       time.Duration(policy.Delay) * time.Millisecond,
+				MaxDelay:    time.Duration(policy.MaxDelay) * time.Millisecond,
+			}
+		}
+	}
+
+	policiesMu.Lock()
+	defer policiesMu.Unlock()
+	policies = configured
+}
+
+// policyFor returns the retry policy for an error type
+func policyFor(errorType errors.ErrorType) (Policy, bool) {
+	policiesMu.RLock()
+	defer policiesMu.RUnlock()
+	policy, ok := policies[errorType]
+	return policy, ok && policy.MaxAttempts > 0
+}
+
 // NewRecovery creates a new recovery action
 func NewRecovery(strategy Strategy, maxAttempts int, delay time.Duration, message string) *Recovery {
 	return &Recovery{
@@ -55,17 +114,25 @@ func (r *Recovery) Format() string {
 func GetRecoveryForError(err error) *Recovery {
 	switch {
 	case errors.IsRateLimitError(err):
+		policy, ok := policyFor(errors.ErrorTypeRateLimit)
+		if !ok {
+			return NewRecovery(StrategyCancel, 1, 0, "Rate limit exceeded")
+		}
 		return NewRecovery(
 			StrategyRetry,
-			3,
-			5*time.Second,
+			policy.MaxAttempts,
+			policy.Delay,
 			"Rate limit exceeded, retrying...",
 		)
 	case errors.IsTimeoutError(err):
+		policy, ok := policyFor(errors.ErrorTypeTimeout)
+		if !ok {
+			return NewRecovery(StrategyCancel, 1, 0, "Operation timed out")
+		}
 		return NewRecovery(
 			StrategyRetry,
-			2,
-			2*time.Second,
+			policy.MaxAttempts,
+			policy.Delay,
 			"Operation timed out, retrying...",
 		)
 	case errors.IsNotFoundError(err):
@@ -82,6 +149,13 @@ func GetRecoveryForError(err error) *Recovery {
 			0,
 			"Resource already exists",
 		)
+	case errors.IsPermissionError(err):
+		return NewRecovery(
+			StrategyCancel,
+			1,
+			0,
+			"You are not allowed to do that",
+		)
 	case errors.IsValidationError(err):
 		return NewRecovery(
 			StrategyCancel,
@@ -90,6 +164,14 @@ func GetRecoveryForError(err error) *Recovery {
 			"Invalid input, please check your request",
 		)
 	default:
+		if policy, ok := policyFor(errors.ErrorTypeInternal); ok && errors.IsInternalError(err) {
+			return NewRecovery(
+				StrategyRetry,
+				policy.MaxAttempts,
+				policy.Delay,
+				"An unexpected error occurred, retrying...",
+			)
+		}
 		return NewRecovery(
 			StrategyCancel,
 			1,
@@ -109,4 +191,33 @@ func ShouldRetry(err error, attempts int) bool {
 func GetRetryDelay(err error) time.Duration {
 	recovery := GetRecoveryForError(err)
 	return recovery.Delay
-} 
\ No newline at end of file
+}
+
+// Backoff returns the delay before retry number attempts+1. The delay doubles with
+// each attempt, up to the policy's maximum.
+func Backoff(err error, attempts int) time.Duration {
+	delay := GetRetryDelay(err)
+
+	var errorType errors.ErrorType
+	switch {
+	case errors.IsRateLimitError(err):
+		errorType = errors.ErrorTypeRateLimit
+	case errors.IsTimeoutError(err):
+		errorType = errors.ErrorTypeTimeout
+	case errors.IsInternalError(err):
+		errorType = errors.ErrorTypeInternal
+	default:
+		return delay
+	}
+	policy, ok := policyFor(errorType)
+	if !ok || policy.MaxDelay <= delay {
+		return delay
+	}
+	for i := 0; i < attempts && delay < policy.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > policy.MaxDelay {
+		delay = policy.MaxDelay
+	}
+	return delay
+}
@@ -5,34 +5,35 @@ import (
 	"time"
 
 	"github.com/dfaile/backstage-nobl9/internal/errors"
+	"github.com/dfaile/backstage-nobl9/internal/recovery"
 )
 
 func TestNewRecovery(t *testing.T) {
-	recovery := NewRecovery(StrategyRetry, 3, 5*time.Second, "test message")
-	if recovery.Strategy != StrategyRetry {
-		t.Errorf("Expected strategy %v, got %v", StrategyRetry, recovery.Strategy)
+	r := recovery.NewRecovery(recovery.StrategyRetry, 3, 5*time.Second, "test message")
+	if r.Strategy != recovery.StrategyRetry {
+		t.Errorf("Expected strategy %v, got %v", recovery.StrategyRetry, r.Strategy)
 	}
-	if recovery.MaxAttempts != 3 {
-		t.Errorf("Expected max attempts 3, got %d", recovery.MaxAttempts)
+	if r.MaxAttempts != 3 {
+		t.Errorf("Expected max attempts 3, got %d", r.MaxAttempts)
 	}
-	if recovery.Delay != 5*time.Second {
-		t.Errorf("Expected delay 5s, got %v", recovery.Delay)
+	if r.Delay != 5*time.Second {
+		t.Errorf("Expected delay 5s, got %v", r.Delay)
 	}
-	if recovery.Message != "test message" {
-		t.Errorf("Expected message 'test message', got %s", recovery.Message)
+	if r.Message != "test message" {
+		t.Errorf("Expected message 'test message', got %s", r.Message)
 	}
 }
 
 func TestRecoveryFormat(t *testing.T) {
 	tests := []struct {
 		name     string
-		recovery *Recovery
+		recovery *recovery.Recovery
 		want     string
 	}{
 		{
 			name: "retry strategy",
-			recovery: NewRecovery(
-				StrategyRetry,
+			recovery: recovery.NewRecovery(
+				recovery.StrategyRetry,
 				3,
 				5*time.Second,
 				"test message",
@@ -41,8 +42,8 @@ func TestRecoveryFormat(t *testing.T) {
 		},
 		{
 			name: "fallback strategy",
-			recovery: NewRecovery(
-				StrategyFallback,
+			recovery: recovery.NewRecovery(
+				recovery.StrategyFallback,
 				1,
 				0,
 				"test message",
@@ -51,8 +52,8 @@ func TestRecoveryFormat(t *testing.T) {
 		},
 		{
 			name: "cancel strategy",
-			recovery: NewRecovery(
-				StrategyCancel,
+			recovery: recovery.NewRecovery(
+				recovery.StrategyCancel,
 				1,
 				0,
 				"test message",
@@ -73,67 +74,67 @@ func TestRecoveryFormat(t *testing.T) {
 
 func TestGetRecoveryForError(t *testing.T) {
 	tests := []struct {
-		name    string
-		err     error
-		want    Strategy
+		name     string
+		err      error
+		want     recovery.Strategy
 		attempts int
-		delay   time.Duration
+		delay    time.Duration
 	}{
 		{
-			name:    "rate limit error",
-			err:     errors.NewRateLimitError("rate limit exceeded"),
-			want:    StrategyRetry,
+			name:     "rate limit error",
+			err:      errors.NewRateLimitError("rate limit exceeded", nil),
+			want:     recovery.StrategyRetry,
 			attempts: 3,
-			delay:   5 * time.Second,
+			delay:    5 * time.Second,
 		},
 		{
-			name:    "timeout error",
-			err:     errors.NewTimeoutError("operation timed out"),
-			want:    StrategyRetry,
+			name:     "timeout error",
+			err:      errors.NewTimeoutError("operation timed out", nil),
+			want:     recovery.StrategyRetry,
 			attempts: 2,
-			delay:   2 * time.Second,
+			delay:    2 * time.Second,
 		},
 		{
-			name:    "not found error",
-			err:     errors.NewNotFoundError("resource not found"),
-			want:    StrategyFallback,
+			name:     "not found error",
+			err:      errors.NewNotFoundError("resource not found", nil),
+			want:     recovery.StrategyFallback,
 			attempts: 1,
-			delay:   0,
+			delay:    0,
 		},
 		{
-			name:    "conflict error",
-			err:     errors.NewConflictError("resource already exists"),
-			want:    StrategyCancel,
+			name:     "conflict error",
+			err:      errors.NewConflictError("resource already exists", nil),
+			want:     recovery.StrategyCancel,
 			attempts: 1,
-			delay:   0,
+			delay:    0,
 		},
 		{
-			name:    "validation error",
-			err:     errors.NewValidationError("invalid input"),
-			want:    StrategyCancel,
+			name:     "validation error",
+			err:      errors.NewValidationError("invalid input", nil),
+			want:     recovery.StrategyCancel,
 			attempts: 1,
-			delay:   0,
+			delay:    0,
 		},
 		{
-			name:    "unknown error",
-			err:     errors.NewInternalError("unknown error"),
-			want:    StrategyCancel,
+			name:     "unknown error",
+			err:      errors.NewInternalError("unknown error", nil),
+			want:     recovery.StrategyCancel,
 			attempts: 1,
-			delay:   0,
+			delay:    0,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			recovery := GetRecoveryForError(tt.err)
-			if recovery.Strategy != tt.want {
-				t.Errorf("GetRecoveryForError() strategy = %v, want %v", recovery.Strategy, tt.want)
+			r := recovery.GetRecoveryForError(tt.err)
+			if r.Strategy != tt.want {
+				t.Errorf("GetRecoveryForError() strategy = %v, want %v", r.Strategy, tt.want)
 			}
-			if recovery.MaxAttempts != tt.attempts {
-				t.Errorf("GetRecoveryForError() attempts = %v, want %v", recovery.MaxAttempts, tt.attempts)
+			if r.MaxAttempts != tt.attempts {
+				t.Errorf("GetRecoveryForError() attempts = %v, want %v", r.MaxAttempts, tt.attempts)
 			}
-			if recovery.Delay != tt.delay {
-				t.Errorf("GetRecoveryForError() delay = %v, want %v", recovery.Delay, tt.delay)
+			if r.Delay != tt.delay {
+				t.Errorf("GetRecoveryForError() delay = %v, want %v", r.Delay, tt.delay)
 			}
 		})
 	}
@@ -148,31 +149,31 @@ func TestShouldRetry(t *testing.T) {
 	}{
 		{
 			name:     "rate limit error with attempts remaining",
-			err:      errors.NewRateLimitError("rate limit exceeded"),
+			err:      errors.NewRateLimitError("rate limit exceeded", nil),
 			attempts: 2,
 			want:     true,
 		},
 		{
 			name:     "rate limit error with no attempts remaining",
-			err:      errors.NewRateLimitError("rate limit exceeded"),
+			err:      errors.NewRateLimitError("rate limit exceeded", nil),
 			attempts: 3,
 			want:     false,
 		},
 		{
 			name:     "timeout error with attempts remaining",
-			err:      errors.NewTimeoutError("operation timed out"),
+			err:      errors.NewTimeoutError("operation timed out", nil),
 			attempts: 1,
 			want:     true,
 		},
 		{
 			name:     "timeout error with no attempts remaining",
-			err:      errors.NewTimeoutError("operation timed out"),
+			err:      errors.NewTimeoutError("operation timed out", nil),
 			attempts: 2,
 			want:     false,
 		},
 		{
 			name:     "not found error",
-			err:      errors.NewNotFoundError("resource not found"),
+			err:      errors.NewNotFoundError("resource not found", nil),
 			attempts: 1,
 			want:     false,
 		},
@@ -180,7 +181,7 @@ func TestShouldRetry(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := ShouldRetry(tt.err, tt.attempts)
+			got := recovery.ShouldRetry(tt.err, tt.attempts)
 			if got != tt.want {
 				t.Errorf("ShouldRetry() = %v, want %v", got, tt.want)
 			}
@@ -196,27 +197,27 @@ func TestGetRetryDelay(t *testing.T) {
 	}{
 		{
 			name: "rate limit error",
-			err:  errors.NewRateLimitError("rate limit exceeded"),
+			err:  errors.NewRateLimitError("rate limit exceeded", nil),
 			want: 5 * time.Second,
 		},
 		{
 			name: "timeout error",
-			err:  errors.NewTimeoutError("operation timed out"),
+			err:  errors.NewTimeoutError("operation timed out", nil),
 			want: 2 * time.Second,
 		},
 		{
 			name: "not found error",
-			err:  errors.NewNotFoundError("resource not found"),
+			err:  errors.NewNotFoundError("resource not found", nil),
 			want: 0,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := GetRetryDelay(tt.err)
+			got := recovery.GetRetryDelay(tt.err)
 			if got != tt.want {
 				t.Errorf("GetRetryDelay() = %v, want %v", got, tt.want)
 			}
 		})
 	}
-} 
\ No newline at end of file
+}
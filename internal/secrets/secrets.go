@@ -0,0 +1,128 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/dfaile/backstage-nobl9/internal/errors"
+	"github.com/dfaile/backstage-nobl9/internal/logging"
+)
+
+// Provider fetches secrets from one secret store
+type Provider interface {
+	// Get returns the secret ref points to. The form of ref depends on the store,
+	// such as a file path or a Vault path and field.
+	Get(ctx context.Context, ref string) (string, error)
+}
+
+// ProviderFunc adapts a function to the Provider interface
+type ProviderFunc func(ctx context.Context, ref string) (string, error)
+
+// Get calls f
+func (f ProviderFunc) Get(ctx context.Context, ref string) (string, error) {
+	return f(ctx, ref)
+}
+
+// Resolver resolves secret references of the form scheme:ref, such as
+// file:/run/secrets/nobl9, env:NOBL9_CLIENT_SECRET or vault:secret/data/nobl9#client_secret,
+// with the provider registered for the scheme
+type Resolver struct {
+	mu        sync.RWMutex
+	providers map[string]Provider
+}
+
+// NewResolver creates a resolver for file: and env: references
+func NewResolver() *Resolver {
+	r := &Resolver{providers: make(map[string]Provider)}
+	r.Register("file", FileProvider{})
+	r.Register("env", EnvProvider{})
+	return r
+}
+
+// Register makes p resolve references with the given scheme, replacing any
+// provider registered for it before
+func (r *Resolver) Register(scheme string, p Provider) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.providers[scheme] = p
+}
+
+// IsReference reports whether value names a secret in a registered store
+func (r *Resolver) IsReference(value string) bool {
+	_, _, ok := r.provider(value)
+	return ok
+}
+
+// Resolve returns the secret value refers to. Values that are not references are
+// returned unchanged. Every secret is registered with the logging package so it is
+// redacted from logs, errors and chat responses.
+func (r *Resolver) Resolve(ctx context.Context, value string) (string, error) {
+	provider, ref, ok := r.provider(value)
+	if !ok {
+		logging.RegisterSecret(value)
+		return value, nil
+	}
+
+	secret, err := provider.Get(ctx, ref)
+	if err != nil {
+		return "", err
+	}
+	logging.RegisterSecret(secret)
+	return secret, nil
+}
+
+// provider returns the provider and reference for value, if it is a reference
+func (r *Resolver) provider(value string) (Provider, string, bool) {
+	scheme, ref, found := strings.Cut(value, ":")
+	if !found || ref == "" {
+		return nil, "", false
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	provider, ok := r.providers[scheme]
+	return provider, ref, ok
+}
+
+// FileProvider reads secrets from files, such as Docker or Kubernetes secrets
+// mounted under /run/secrets. The reference is the file path.
+type FileProvider struct{}
+
+// Get reads the secret in the file at path
+func (FileProvider) Get(_ context.Context, path string) (string, error) {
+	return ReadFile(path)
+}
+
+// ReadFile reads a secret from a file, without the trailing newline editors and
+// echo leave behind
+func ReadFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", errors.NewNotFoundError(fmt.Sprintf("secret file %s does not exist", path), nil)
+		}
+		return "", fmt.Errorf("failed to read secret file %s: %w", path, err)
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// EnvProvider reads secrets from environment variables. The reference is the
+// variable name.
+type EnvProvider struct {
+	LookupEnv func(string) (string, bool) // Defaults to os.LookupEnv
+}
+
+// Get returns the value of the named environment variable
+func (p EnvProvider) Get(_ context.Context, name string) (string, error) {
+	lookup := p.LookupEnv
+	if lookup == nil {
+		lookup = os.LookupEnv
+	}
+	value, ok := lookup(name)
+	if !ok {
+		return "", errors.NewNotFoundError(fmt.Sprintf("environment variable %s is not set", name), nil)
+	}
+	return value, nil
+}
@@ -0,0 +1,79 @@
+package secrets_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dfaile/backstage-nobl9/internal/errors"
+	"github.com/dfaile/backstage-nobl9/internal/logging"
+	"github.com/dfaile/backstage-nobl9/internal/secrets"
+	"github.com/dfaile/backstage-nobl9/internal/secrets/vaulttest"
+)
+
+func TestResolver(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "secret")
+	require.NoError(t, os.WriteFile(path, []byte("file-secret-value\r\n"), 0600))
+
+	r := secrets.NewResolver()
+	r.Register("env", secrets.EnvProvider{LookupEnv: func(name string) (string, bool) {
+		return "env-secret-value", name == "NOBL9_SECRET"
+	}})
+
+	value, err := r.Resolve(ctx, "file:"+path)
+	require.NoError(t, err)
+	assert.Equal(t, "file-secret-value", value)
+
+	value, err = r.Resolve(ctx, "env:NOBL9_SECRET")
+	require.NoError(t, err)
+	assert.Equal(t, "env-secret-value", value)
+
+	// Values without a registered scheme are the secret itself
+	for _, plain := range []string{"plain-secret-value", "https://example.com", "vault:secret/data/nobl9#key"} {
+		assert.False(t, r.IsReference(plain))
+		value, err = r.Resolve(ctx, plain)
+		require.NoError(t, err)
+		assert.Equal(t, plain, value)
+	}
+
+	_, err = r.Resolve(ctx, "file:"+filepath.Join(t.TempDir(), "missing"))
+	assert.True(t, errors.IsNotFoundError(err))
+	_, err = r.Resolve(ctx, "env:UNSET")
+	assert.True(t, errors.IsNotFoundError(err))
+
+	// Every secret is redacted from then on
+	assert.Equal(t, "a "+logging.Redacted+" b "+logging.Redacted, logging.Redact("a file-secret-value b plain-secret-value"))
+}
+
+func TestVaultProvider(t *testing.T) {
+	ctx := context.Background()
+	server := vaulttest.NewServer("vault-token")
+	defer server.Close()
+	server.Put("secret/nobl9", map[string]string{"client_secret": "vault-secret-value"})
+
+	vault := secrets.NewVaultProvider(server.URL, "vault-token")
+	value, err := vault.Get(ctx, "secret/data/nobl9#client_secret")
+	require.NoError(t, err)
+	assert.Equal(t, "vault-secret-value", value)
+
+	// Rotated secrets are read fresh
+	server.Put("secret/nobl9", map[string]string{"client_secret": "rotated-secret-value"})
+	value, err = vault.Get(ctx, "secret/data/nobl9#client_secret")
+	require.NoError(t, err)
+	assert.Equal(t, "rotated-secret-value", value)
+
+	_, err = vault.Get(ctx, "secret/data/nobl9#client_id")
+	assert.True(t, errors.IsNotFoundError(err))
+	_, err = vault.Get(ctx, "secret/data/other#client_secret")
+	assert.True(t, errors.IsNotFoundError(err))
+	_, err = vault.Get(ctx, "secret/data/nobl9")
+	assert.True(t, errors.IsValidationError(err))
+
+	_, err = secrets.NewVaultProvider(server.URL, "wrong-token").Get(ctx, "secret/data/nobl9#client_secret")
+	assert.True(t, errors.IsPermissionError(err))
+}
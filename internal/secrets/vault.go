@@ -0,0 +1,100 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/dfaile/backstage-nobl9/internal/errors"
+)
+
+// VaultProvider reads secrets from HashiCorp Vault's KV secrets engine over its
+// HTTP API. The reference is the API path and the field, e.g. secret/data/nobl9#client_secret
+// for version 2 of the engine or secret/nobl9#client_secret for version 1.
+type VaultProvider struct {
+	Address    string // e.g. https://vault.example.com:8200
+	Token      string
+	Namespace  string // Vault Enterprise namespace, optional
+	HTTPClient *http.Client
+}
+
+// NewVaultProvider creates a provider for the Vault server at address
+func NewVaultProvider(address, token string) *VaultProvider {
+	return &VaultProvider{
+		Address:    strings.TrimRight(address, "/"),
+		Token:      token,
+		HTTPClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// vaultResponse is the body Vault returns for reads and errors
+type vaultResponse struct {
+	Data   map[string]interface{} `json:"data"`
+	Errors []string               `json:"errors"`
+}
+
+// Get reads one field of a Vault secret
+func (p *VaultProvider) Get(ctx context.Context, ref string) (string, error) {
+	path, field, _ := strings.Cut(ref, "#")
+	path = strings.Trim(path, "/")
+	if path == "" || field == "" {
+		return "", errors.NewValidationError(fmt.Sprintf("vault reference %q must take the form path#field", ref), nil)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.Address+"/v1/"+path, nil)
+	if err != nil {
+		return "", errors.NewValidationError(fmt.Sprintf("invalid vault address %q", p.Address), err)
+	}
+	req.Header.Set("X-Vault-Token", p.Token)
+	if p.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", p.Namespace)
+	}
+
+	client := p.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", errors.NewInternalError(fmt.Sprintf("failed to reach vault for %s", path), err)
+	}
+	defer resp.Body.Close()
+
+	var body vaultResponse
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err == nil && len(data) > 0 {
+		err = json.Unmarshal(data, &body)
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return "", errors.NewNotFoundError(fmt.Sprintf("vault secret %s not found", path), nil)
+	case resp.StatusCode == http.StatusForbidden:
+		return "", errors.NewPermissionError(fmt.Sprintf("vault denied access to %s", path), nil)
+	case resp.StatusCode != http.StatusOK:
+		return "", errors.NewInternalError(fmt.Sprintf("vault returned %s for %s: %s", resp.Status, path, strings.Join(body.Errors, "; ")), nil)
+	case err != nil:
+		return "", errors.NewInternalError(fmt.Sprintf("invalid vault response for %s", path), err)
+	}
+
+	// Version 2 of the KV engine nests the secret under data.data, next to its metadata
+	values := body.Data
+	if nested, ok := values["data"].(map[string]interface{}); ok {
+		if _, ok := values["metadata"]; ok {
+			values = nested
+		}
+	}
+	value, ok := values[field]
+	if !ok {
+		return "", errors.NewNotFoundError(fmt.Sprintf("vault secret %s has no field '%s'", path, field), nil)
+	}
+	secret, ok := value.(string)
+	if !ok {
+		return "", errors.NewValidationError(fmt.Sprintf("vault secret %s field '%s' is not a string", path, field), nil)
+	}
+	return secret, nil
+}
@@ -0,0 +1,76 @@
+package vaulttest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+)
+
+// Server is a local stand-in for a Vault server in tests. It answers KV version 2
+// reads for the secrets put into it, and only for requests carrying its token.
+type Server struct {
+	*httptest.Server
+	Token string
+
+	mu      sync.Mutex
+	secrets map[string]secret // By API path, e.g. secret/data/nobl9
+}
+
+// secret is one stored secret and its version
+type secret struct {
+	data    map[string]string
+	version int
+}
+
+// NewServer starts a server accepting token. Close it when done.
+func NewServer(token string) *Server {
+	s := &Server{Token: token, secrets: make(map[string]secret)}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+// Put stores data at path, given as mount/name like the vault kv put command takes
+// it, e.g. secret/nobl9. It is read from the API path secret/data/nobl9.
+func (s *Server) Put(path string, data map[string]string) {
+	mount, name, _ := strings.Cut(strings.Trim(path, "/"), "/")
+	key := mount + "/data/" + name
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.secrets[key] = secret{data: data, version: s.secrets[key].version + 1}
+}
+
+// handle serves a read
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]interface{}{"errors": []string{"unsupported operation"}})
+		return
+	}
+	if r.Header.Get("X-Vault-Token") != s.Token {
+		writeJSON(w, http.StatusForbidden, map[string]interface{}{"errors": []string{"permission denied"}})
+		return
+	}
+
+	s.mu.Lock()
+	stored, ok := s.secrets[strings.TrimPrefix(r.URL.Path, "/v1/")]
+	s.mu.Unlock()
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]interface{}{"errors": []string{}})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"data": map[string]interface{}{
+			"data":     stored.data,
+			"metadata": map[string]interface{}{"version": stored.version},
+		},
+	})
+}
+
+// writeJSON writes body with the given status
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,303 @@
+package templates
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/nobl9/nobl9-go/manifest"
+	"github.com/nobl9/nobl9-go/manifest/v1alpha/rolebinding"
+	"github.com/nobl9/nobl9-go/sdk"
+	"gopkg.in/yaml.v3"
+
+	"github.com/dfaile/backstage-nobl9/internal/errors"
+)
+
+// Parameter represents an extra input a template asks the user for
+type Parameter struct {
+	Name        string   `yaml:"name"`
+	Description string   `yaml:"description"`
+	Default     string   `yaml:"default"`
+	Options     []string `yaml:"options"`
+	Required    bool     `yaml:"required"`
+}
+
+// Template represents a starter project definition loaded from a YAML file
+type Template struct {
+	Name        string            `yaml:"name"`
+	Description string            `yaml:"description"`
+	Labels      map[string]string `yaml:"labels"`
+	Parameters  []Parameter       `yaml:"parameters"`
+	Kinds       []string          `yaml:"kinds"` // Kinds the manifest may create, Project and RoleBinding when empty
+	Manifest    string            `yaml:"manifest"`
+
+	manifest *template.Template
+}
+
+// Data holds the user's inputs passed to the manifest template
+type Data struct {
+	Project     string
+	Description string
+	Labels      map[string]string
+	Params      map[string]string
+}
+
+// Registry holds the templates available to the bot
+type Registry struct {
+	templates map[string]*Template
+}
+
+// NewRegistry creates an empty template registry
+func NewRegistry() *Registry {
+	return &Registry{
+		templates: make(map[string]*Template),
+	}
+}
+
+// LoadDir loads every *.yaml and *.yml template from dir into a registry
+func LoadDir(dir string) (*Registry, error) {
+	registry := NewRegistry()
+	if dir == "" {
+		return registry, nil
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template directory: %w", err)
+	}
+
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+		if entry.IsDir() || (ext != ".yaml" && ext != ".yml") {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read template %s: %w", entry.Name(), err)
+		}
+
+		tmpl, err := Parse(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse template %s: %w", entry.Name(), err)
+		}
+		if tmpl.Name == "" {
+			tmpl.Name = strings.TrimSuffix(entry.Name(), ext)
+		}
+		registry.Register(tmpl)
+	}
+
+	return registry, nil
+}
+
+// Parse parses a template definition
+func Parse(data []byte) (*Template, error) {
+	var tmpl Template
+	if err := yaml.Unmarshal(data, &tmpl); err != nil {
+		return nil, fmt.Errorf("failed to decode template: %w", err)
+	}
+	if strings.TrimSpace(tmpl.Manifest) == "" {
+		return nil, fmt.Errorf("template has no manifest")
+	}
+
+	parsed, err := template.New(tmpl.Name).
+		Funcs(funcMap).
+		Option("missingkey=error").
+		Parse(tmpl.Manifest)
+	if err != nil {
+		return nil, fmt.Errorf("invalid manifest template: %w", err)
+	}
+	tmpl.manifest = parsed
+
+	return &tmpl, nil
+}
+
+// Register adds a template to the registry
+func (r *Registry) Register(tmpl *Template) {
+	r.templates[tmpl.Name] = tmpl
+}
+
+// Get retrieves a template by name
+func (r *Registry) Get(name string) (*Template, error) {
+	if tmpl, ok := r.templates[name]; ok {
+		return tmpl, nil
+	}
+	names := r.Names()
+	if len(names) == 0 {
+		return nil, errors.NewNotFoundError(fmt.Sprintf("template '%s' not found, no templates are configured", name), nil)
+	}
+	return nil, errors.NewNotFoundError(fmt.Sprintf("template '%s' not found, available templates: %s", name, strings.Join(names, ", ")), nil)
+}
+
+// Names returns the sorted names of all registered templates
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.templates))
+	for name := range r.templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Render executes the manifest template with data and decodes the resulting objects.
+// Template labels are applied first so that the user's labels take precedence.
+func (t *Template) Render(data Data) ([]manifest.Object, error) {
+	labels := make(map[string]string, len(t.Labels)+len(data.Labels))
+	for key, value := range t.Labels {
+		labels[key] = value
+	}
+	for key, value := range data.Labels {
+		labels[key] = value
+	}
+	data.Labels = labels
+
+	for key, value := range labels {
+		if err := singleLine("label '"+key+"'", key+value); err != nil {
+			return nil, err
+		}
+	}
+
+	params := make(map[string]string, len(t.Parameters))
+	for _, param := range t.Parameters {
+		value, ok := data.Params[param.Name]
+		if !ok || value == "" {
+			value = param.Default
+		}
+		if value == "" && param.Required {
+			return nil, errors.NewValidationError(fmt.Sprintf("template parameter '%s' is required", param.Name), nil)
+		}
+		if err := singleLine("template parameter '"+param.Name+"'", value); err != nil {
+			return nil, err
+		}
+		params[param.Name] = value
+	}
+	data.Params = params
+	if err := singleLine("description", data.Description); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := t.manifest.Execute(&buf, data); err != nil {
+		return nil, errors.NewValidationError("failed to render template", err)
+	}
+
+	objects, err := sdk.DecodeObjects(buf.Bytes())
+	if err != nil {
+		return nil, errors.NewValidationError("rendered template is not a valid manifest", err)
+	}
+
+	if err := t.checkKinds(objects); err != nil {
+		return nil, err
+	}
+	if err := Validate(objects, data.Project); err != nil {
+		return nil, err
+	}
+
+	return objects, nil
+}
+
+// checkKinds rejects objects of kinds the template does not declare
+func (t *Template) checkKinds(objects []manifest.Object) error {
+	kinds := t.Kinds
+	if len(kinds) == 0 {
+		kinds = []string{manifest.KindProject.String(), manifest.KindRoleBinding.String()}
+	}
+	for _, obj := range objects {
+		allowed := false
+		for _, kind := range kinds {
+			if strings.EqualFold(kind, obj.GetKind().String()) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return errors.NewValidationError(fmt.Sprintf("template renders a %s '%s' but only declares %s", obj.GetKind(), obj.GetName(), strings.Join(kinds, ", ")), nil)
+		}
+	}
+	return nil
+}
+
+// singleLine rejects values spanning lines, which could add YAML to a manifest
+// whose template forgets to quote them
+func singleLine(name, value string) error {
+	if strings.ContainsAny(value, "\n\r") {
+		return errors.NewValidationError(fmt.Sprintf("%s must be a single line", name), nil)
+	}
+	return nil
+}
+
+// Validate checks that objects form a single-project bundle and pass SDK validation.
+// Role bindings must grant roles in the project, never in another project or the
+// organization.
+func Validate(objects []manifest.Object, projectName string) error {
+	projects := 0
+	for _, obj := range objects {
+		if obj.GetKind() == manifest.KindProject {
+			projects++
+			if obj.GetName() != projectName {
+				return errors.NewValidationError(fmt.Sprintf("template defines project '%s', expected '%s'", obj.GetName(), projectName), nil)
+			}
+		}
+		if scoped, ok := obj.(manifest.ProjectScopedObject); ok && scoped.GetProject() != projectName {
+			return errors.NewValidationError(fmt.Sprintf("%s '%s' belongs to project '%s', expected '%s'", obj.GetKind(), obj.GetName(), scoped.GetProject(), projectName), nil)
+		}
+		if binding, ok := obj.(rolebinding.RoleBinding); ok && binding.Spec.ProjectRef != projectName {
+			return errors.NewValidationError(fmt.Sprintf("RoleBinding '%s' grants a role in project '%s', expected '%s'", obj.GetName(), binding.Spec.ProjectRef, projectName), nil)
+		}
+	}
+	if projects != 1 {
+		return errors.NewValidationError(fmt.Sprintf("template must define exactly one project, found %d", projects), nil)
+	}
+
+	if errs := manifest.Validate(objects); len(errs) > 0 {
+		messages := make([]string, len(errs))
+		for i, err := range errs {
+			messages[i] = err.Error()
+		}
+		return errors.NewValidationError(fmt.Sprintf("template objects failed validation: %s", strings.Join(messages, "; ")), nil)
+	}
+
+	return nil
+}
+
+// Preview summarizes the objects a template will apply
+func Preview(objects []manifest.Object) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("The template will apply %d object(s):\n", len(objects)))
+	for _, obj := range objects {
+		if scoped, ok := obj.(manifest.ProjectScopedObject); ok {
+			sb.WriteString(fmt.Sprintf("• %s %s (project %s)\n", obj.GetKind(), obj.GetName(), scoped.GetProject()))
+		} else {
+			sb.WriteString(fmt.Sprintf("• %s %s\n", obj.GetKind(), obj.GetName()))
+		}
+	}
+	return sb.String()
+}
+
+// funcMap holds the helper functions available inside manifest templates
+var funcMap = template.FuncMap{
+	"quote":  strconv.Quote,
+	"toYaml": toYAML,
+	"default": func(def, value string) string {
+		if value == "" {
+			return def
+		}
+		return value
+	},
+	"lower": strings.ToLower,
+}
+
+// toYAML renders value as a YAML scalar, quoted whenever YAML would read it as
+// anything other than the plain string
+func toYAML(value string) (string, error) {
+	out, err := yaml.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(string(out), "\n"), nil
+}
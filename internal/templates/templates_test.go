@@ -0,0 +1,230 @@
+package templates_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nobl9/nobl9-go/manifest"
+	"github.com/nobl9/nobl9-go/manifest/v1alpha/project"
+	"github.com/nobl9/nobl9-go/manifest/v1alpha/rolebinding"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dfaile/backstage-nobl9/internal/errors"
+	"github.com/dfaile/backstage-nobl9/internal/templates"
+)
+
+func loadStarter(t *testing.T) *templates.Template {
+	t.Helper()
+	registry, err := templates.LoadDir("testdata")
+	require.NoError(t, err)
+	tmpl, err := registry.Get("starter")
+	require.NoError(t, err)
+	return tmpl
+}
+
+func TestLoadDir(t *testing.T) {
+	registry, err := templates.LoadDir("testdata")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"starter"}, registry.Names())
+
+	_, err = registry.Get("missing")
+	require.Error(t, err)
+	assert.True(t, errors.IsNotFoundError(err))
+	assert.Contains(t, err.Error(), "available templates: starter")
+}
+
+func TestLoadDirEmptyPath(t *testing.T) {
+	registry, err := templates.LoadDir("")
+	require.NoError(t, err)
+	assert.Empty(t, registry.Names())
+}
+
+func TestParseInvalid(t *testing.T) {
+	_, err := templates.Parse([]byte("name: empty\n"))
+	assert.Error(t, err)
+
+	_, err = templates.Parse([]byte("name: broken\nmanifest: \"{{ .Project \"\n"))
+	assert.Error(t, err)
+}
+
+func TestRender(t *testing.T) {
+	tmpl := loadStarter(t)
+
+	objects, err := tmpl.Render(templates.Data{
+		Project:     "payments-api",
+		Description: "Payments API",
+		Labels:      map[string]string{"team": "payments"},
+		Params:      map[string]string{"owner": "owner@example.com"},
+	})
+	require.NoError(t, err)
+	require.Len(t, objects, 3)
+
+	proj, ok := objects[0].(project.Project)
+	require.True(t, ok)
+	assert.Equal(t, "payments-api", proj.Metadata.Name)
+	assert.Equal(t, "Payments API", proj.Spec.Description)
+	assert.Equal(t, []string{"standard"}, proj.Metadata.Labels["tier"])
+	assert.Equal(t, []string{"payments"}, proj.Metadata.Labels["team"])
+
+	binding, ok := objects[1].(rolebinding.RoleBinding)
+	require.True(t, ok)
+	assert.Equal(t, "project-owner", binding.Spec.RoleRef)
+	assert.Equal(t, "owner@example.com", *binding.Spec.User)
+
+	assert.Equal(t, manifest.KindService, objects[2].GetKind())
+	assert.Equal(t, "api", objects[2].GetName())
+}
+
+func TestRenderOptionalAlertPolicy(t *testing.T) {
+	tmpl := loadStarter(t)
+
+	objects, err := tmpl.Render(templates.Data{
+		Project: "payments-api",
+		Params:  map[string]string{"owner": "owner@example.com", "alerts": "yes", "service": "checkout"},
+	})
+	require.NoError(t, err)
+	require.Len(t, objects, 4)
+	assert.Equal(t, "checkout", objects[2].GetName())
+	assert.Equal(t, manifest.KindAlertPolicy, objects[3].GetKind())
+}
+
+func TestRenderMissingRequiredParameter(t *testing.T) {
+	tmpl := loadStarter(t)
+
+	_, err := tmpl.Render(templates.Data{Project: "payments-api"})
+	require.Error(t, err)
+	assert.True(t, errors.IsValidationError(err))
+	assert.Contains(t, err.Error(), "owner")
+}
+
+func TestRenderRejectsForeignProject(t *testing.T) {
+	dir := t.TempDir()
+	data := `name: foreign
+kinds: [Project, Service]
+manifest: |
+  - apiVersion: n9/v1alpha
+    kind: Project
+    metadata:
+      name: {{ .Project }}
+    spec:
+      description: ""
+  - apiVersion: n9/v1alpha
+    kind: Service
+    metadata:
+      name: api
+      project: someone-else
+    spec:
+      description: ""
+`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "foreign.yaml"), []byte(data), 0600))
+
+	registry, err := templates.LoadDir(dir)
+	require.NoError(t, err)
+	tmpl, err := registry.Get("foreign")
+	require.NoError(t, err)
+
+	_, err = tmpl.Render(templates.Data{Project: "payments-api"})
+	require.Error(t, err)
+	assert.True(t, errors.IsValidationError(err))
+	assert.Contains(t, err.Error(), "someone-else")
+}
+
+func TestRenderRejectsInjectedYAML(t *testing.T) {
+	tmpl := loadStarter(t)
+
+	// A parameter trying to add a binding on another project stays a plain value
+	objects, err := tmpl.Render(templates.Data{
+		Project: "payments-api",
+		Params:  map[string]string{"owner": "owner@example.com", "service": "api, project: payments-prod}"},
+	})
+	require.Error(t, err)
+	assert.Nil(t, objects)
+
+	_, err = tmpl.Render(templates.Data{
+		Project: "payments-api",
+		Params: map[string]string{"owner": "owner@example.com", "service": "api\n" +
+			"- apiVersion: n9/v1alpha\n  kind: RoleBinding\n  metadata:\n    name: grab\n" +
+			"  spec:\n    user: attacker@example.com\n    roleRef: project-owner\n    projectRef: payments-prod"},
+	})
+	assert.True(t, errors.IsValidationError(err))
+	assert.Contains(t, err.Error(), "template parameter 'service' must be a single line")
+
+	_, err = tmpl.Render(templates.Data{
+		Project: "payments-api",
+		Labels:  map[string]string{"team: x\n        evil": "1"},
+		Params:  map[string]string{"owner": "owner@example.com"},
+	})
+	assert.True(t, errors.IsValidationError(err))
+}
+
+func TestRenderRejectsForeignRoleBinding(t *testing.T) {
+	dir := t.TempDir()
+	data := `name: grant
+manifest: |
+  - apiVersion: n9/v1alpha
+    kind: Project
+    metadata:
+      name: {{ toYaml .Project }}
+    spec:
+      description: ""
+  - apiVersion: n9/v1alpha
+    kind: RoleBinding
+    metadata:
+      name: grab
+    spec:
+      user: attacker@example.com
+      roleRef: project-owner
+      projectRef: payments-prod
+`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "grant.yaml"), []byte(data), 0600))
+	registry, err := templates.LoadDir(dir)
+	require.NoError(t, err)
+	tmpl, err := registry.Get("grant")
+	require.NoError(t, err)
+
+	_, err = tmpl.Render(templates.Data{Project: "payments-api"})
+	assert.True(t, errors.IsValidationError(err))
+	assert.Contains(t, err.Error(), "RoleBinding 'grab' grants a role in project 'payments-prod', expected 'payments-api'")
+}
+
+func TestRenderRejectsUndeclaredKind(t *testing.T) {
+	tmpl, err := templates.Parse([]byte(`name: service
+kinds: [Project]
+manifest: |
+  - apiVersion: n9/v1alpha
+    kind: Project
+    metadata:
+      name: {{ toYaml .Project }}
+    spec:
+      description: ""
+  - apiVersion: n9/v1alpha
+    kind: Service
+    metadata:
+      name: api
+      project: {{ toYaml .Project }}
+    spec:
+      description: ""
+`))
+	require.NoError(t, err)
+
+	_, err = tmpl.Render(templates.Data{Project: "payments-api"})
+	assert.True(t, errors.IsValidationError(err))
+	assert.Contains(t, err.Error(), "template renders a Service 'api' but only declares Project")
+}
+
+func TestPreview(t *testing.T) {
+	tmpl := loadStarter(t)
+	objects, err := tmpl.Render(templates.Data{
+		Project: "payments-api",
+		Params:  map[string]string{"owner": "owner@example.com"},
+	})
+	require.NoError(t, err)
+
+	preview := templates.Preview(objects)
+	assert.Contains(t, preview, "3 object(s)")
+	assert.Contains(t, preview, "• Project payments-api")
+	assert.Contains(t, preview, "• RoleBinding payments-api-owner")
+	assert.Contains(t, preview, "• Service api (project payments-api)")
+}
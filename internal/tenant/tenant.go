@@ -0,0 +1,180 @@
+package tenant
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/dfaile/backstage-nobl9/internal/config"
+	"github.com/dfaile/backstage-nobl9/internal/errors"
+	"github.com/dfaile/backstage-nobl9/internal/identity"
+	"github.com/dfaile/backstage-nobl9/internal/nobl9"
+)
+
+// Registry matches callers to tenants and keeps a pool of Nobl9 clients, one per tenant
+type Registry struct {
+	mu       sync.RWMutex
+	config   config.TenantsConfig
+	callers  map[string]string // Caller identity, or source:*, to tenant
+	channels map[string]string // Chat channel to tenant
+	domains  map[string]string // Email domain to tenant
+	clients  map[string]*nobl9.Client
+}
+
+// NewRegistry creates a registry for the configured tenants
+func NewRegistry(cfg config.TenantsConfig) *Registry {
+	r := &Registry{clients: make(map[string]*nobl9.Client)}
+	r.Update(cfg)
+	return r
+}
+
+// Update replaces the tenants, for example when the config is reloaded. Clients of
+// removed tenants are dropped from the pool.
+func (r *Registry) Update(cfg config.TenantsConfig) {
+	callers := make(map[string]string)
+	channels := make(map[string]string)
+	domains := make(map[string]string)
+	for name, tenant := range cfg.Orgs {
+		for _, caller := range tenant.Callers {
+			callers[caller] = name
+		}
+		for _, channel := range tenant.Channels {
+			channels[channel] = name
+		}
+		for _, domain := range tenant.Domains {
+			domains[strings.ToLower(domain)] = name
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.config = cfg
+	r.callers = callers
+	r.channels = channels
+	r.domains = domains
+	for name := range r.clients {
+		if _, ok := cfg.Orgs[name]; !ok {
+			delete(r.clients, name)
+		}
+	}
+}
+
+// Names returns the tenant names, sorted
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.config.Orgs))
+	for name := range r.config.Orgs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Resolve returns the caller's tenant. The tenant named by the integration wins
+// if the caller belongs to it, then the caller's identity, their chat channel,
+// the domain of their email and finally the default tenant. A named tenant comes
+// from the client, for example an HTTP header, so it is never trusted alone.
+func (r *Registry) Resolve(caller identity.Caller) (string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if caller.Tenant != "" {
+		if _, ok := r.config.Orgs[caller.Tenant]; !ok {
+			return "", errors.NewValidationError(fmt.Sprintf("unknown tenant '%s'", caller.Tenant), nil)
+		}
+		if !r.belongs(caller, caller.Tenant) {
+			return "", errors.NewPermissionError(fmt.Sprintf("%s does not belong to tenant '%s'", caller, caller.Tenant), nil)
+		}
+		return caller.Tenant, nil
+	}
+	return r.match(caller)
+}
+
+// belongs reports whether the tenants config puts caller in the named tenant.
+// Callers no rule matches belong to the default tenant. r.mu must be held.
+func (r *Registry) belongs(caller identity.Caller, name string) bool {
+	tenant := r.config.Orgs[name]
+	for _, member := range tenant.Callers {
+		if (caller.ID != "" && member == caller.Identity()) || member == caller.Source+":*" {
+			return true
+		}
+	}
+	for _, channel := range tenant.Channels {
+		if caller.Channel != "" && channel == caller.Channel {
+			return true
+		}
+	}
+	if domain := emailDomain(caller.Email); domain != "" {
+		for _, member := range tenant.Domains {
+			if strings.EqualFold(member, domain) {
+				return true
+			}
+		}
+	}
+	matched, err := r.match(caller)
+	return err == nil && matched == name
+}
+
+// match returns the tenant the config's rules put caller in. r.mu must be held.
+func (r *Registry) match(caller identity.Caller) (string, error) {
+	if name, ok := r.callers[caller.Identity()]; ok && caller.ID != "" {
+		return name, nil
+	}
+	if name, ok := r.callers[caller.Source+":*"]; ok {
+		return name, nil
+	}
+	if name, ok := r.channels[caller.Channel]; ok && caller.Channel != "" {
+		return name, nil
+	}
+	if name, ok := r.domains[emailDomain(caller.Email)]; ok {
+		return name, nil
+	}
+	if r.config.Default != "" {
+		return r.config.Default, nil
+	}
+	return "", errors.NewPermissionError(fmt.Sprintf("%s does not belong to any tenant", caller), nil)
+}
+
+// emailDomain returns the lowercase domain of email, or an empty string
+func emailDomain(email string) string {
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return ""
+	}
+	return strings.ToLower(email[at+1:])
+}
+
+// FromHeader returns the tenant named in the configured HTTP header, for HTTP
+// integrations to set as the caller's tenant. Resolve only accepts it for
+// callers who belong to that tenant.
+func (r *Registry) FromHeader(header http.Header) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.config.Header == "" {
+		return ""
+	}
+	return strings.TrimSpace(header.Get(r.config.Header))
+}
+
+// Client returns the pooled Nobl9 client for a tenant, connecting it on first use
+func (r *Registry) Client(name string) (*nobl9.Client, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if client, ok := r.clients[name]; ok {
+		return client, nil
+	}
+	tenant, ok := r.config.Orgs[name]
+	if !ok {
+		return nil, errors.NewNotFoundError(fmt.Sprintf("unknown tenant '%s'", name), nil)
+	}
+	client, err := nobl9.NewClientFromConfig(tenant.Nobl9)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect tenant '%s': %w", name, err)
+	}
+	r.clients[name] = client
+	return client, nil
+}
@@ -0,0 +1,109 @@
+package tenant_test
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dfaile/backstage-nobl9/internal/config"
+	"github.com/dfaile/backstage-nobl9/internal/errors"
+	"github.com/dfaile/backstage-nobl9/internal/identity"
+	"github.com/dfaile/backstage-nobl9/internal/tenant"
+)
+
+func tenants() config.TenantsConfig {
+	return config.TenantsConfig{
+		Header: "X-Nobl9-Tenant",
+		Orgs: map[string]config.TenantConfig{
+			"payments": {Callers: []string{"slack:U1", "http:*"}},
+			"search":   {Callers: []string{"cli:alice"}, Channels: []string{"C-search"}, Domains: []string{"Search.example.com"}},
+		},
+	}
+}
+
+func TestResolve(t *testing.T) {
+	registry := tenant.NewRegistry(tenants())
+	assert.Equal(t, []string{"payments", "search"}, registry.Names())
+
+	tests := []struct {
+		name   string
+		caller identity.Caller
+		want   string
+	}{
+		{"named by integration", identity.Caller{ID: "U1", Source: identity.SourceSlack, Channel: "C-search", Tenant: "search"}, "search"},
+		{"named by integration for a member by domain", identity.Caller{ID: "U9", Source: identity.SourceSlack, Email: "ann@search.example.com", Tenant: "search"}, "search"},
+		{"identity", identity.Caller{ID: "U1", Source: identity.SourceSlack, Channel: "C-search"}, "payments"},
+		{"whole source", identity.Caller{ID: "bob@example.com", Source: identity.SourceHTTP}, "payments"},
+		{"channel", identity.Caller{ID: "U2", Source: identity.SourceSlack, Channel: "C-search"}, "search"},
+		{"email domain", identity.Caller{ID: "U2", Source: identity.SourceSlack, Email: "ann@search.example.com"}, "search"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := registry.Resolve(tt.caller)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	_, err := registry.Resolve(identity.Caller{ID: "U2", Source: identity.SourceSlack})
+	assert.True(t, errors.IsPermissionError(err))
+
+	_, err = registry.Resolve(identity.Caller{ID: "U1", Source: identity.SourceSlack, Tenant: "billing"})
+	assert.True(t, errors.IsValidationError(err))
+
+	// A tenant named by the client is refused to callers outside it
+	_, err = registry.Resolve(identity.Caller{ID: "bob@example.com", Source: identity.SourceHTTP, Tenant: "search"})
+	assert.True(t, errors.IsPermissionError(err))
+	assert.Contains(t, err.Error(), "does not belong to tenant 'search'")
+
+	// Unmatched callers fall back to the default tenant
+	cfg := tenants()
+	cfg.Default = "search"
+	registry.Update(cfg)
+	got, err := registry.Resolve(identity.Caller{ID: "U2", Source: identity.SourceSlack})
+	require.NoError(t, err)
+	assert.Equal(t, "search", got)
+}
+
+func TestFromHeader(t *testing.T) {
+	registry := tenant.NewRegistry(tenants())
+	header := http.Header{}
+	header.Set("X-Nobl9-Tenant", " search ")
+	assert.Equal(t, "search", registry.FromHeader(header))
+
+	registry.Update(config.TenantsConfig{Orgs: tenants().Orgs})
+	assert.Empty(t, registry.FromHeader(header))
+}
+
+func TestClientPool(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	require.NoError(t, os.WriteFile(path, []byte(`defaultContext = "payments"
+
+[contexts.payments]
+clientId = "id"
+clientSecret = "secret"
+organization = "payments-org"
+`), 0600))
+
+	cfg := tenants()
+	cfg.Orgs["payments"] = config.TenantConfig{Nobl9: config.Nobl9Config{ConfigFile: path}}
+	registry := tenant.NewRegistry(cfg)
+
+	client, err := registry.Client("payments")
+	require.NoError(t, err)
+	assert.Equal(t, "payments-org", client.Organization())
+
+	again, err := registry.Client("payments")
+	require.NoError(t, err)
+	assert.Same(t, client, again)
+
+	// Removing a tenant drops its client from the pool
+	delete(cfg.Orgs, "payments")
+	registry.Update(cfg)
+	_, err = registry.Client("payments")
+	assert.True(t, errors.IsNotFoundError(err))
+}
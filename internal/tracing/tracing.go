@@ -0,0 +1,159 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/dfaile/backstage-nobl9/internal/logging"
+)
+
+// TracerName names the tracer every span of the bot is started with
+const TracerName = "github.com/dfaile/backstage-nobl9"
+
+// DefaultServiceName is the service name spans are exported under
+const DefaultServiceName = "nobl9-bot"
+
+// Options configures how spans are exported
+type Options struct {
+	Endpoint    string            // OTLP/HTTP endpoint, e.g. http://localhost:4318
+	Headers     map[string]string // Sent with every export, e.g. a collector API key
+	ServiceName string            // Defaults to DefaultServiceName
+	SampleRatio float64           // Share of new traces recorded, from 0 to 1
+	// Exporter replaces the OTLP exporter, for example with an in-memory one in tests.
+	// Its spans are exported as soon as they end.
+	Exporter sdktrace.SpanExporter
+}
+
+// Setup installs the tracer provider spans are exported with and the W3C trace
+// context propagator. The returned function flushes the spans still buffered and
+// stops the exporter.
+func Setup(ctx context.Context, opts Options) (func(context.Context) error, error) {
+	serviceName := opts.ServiceName
+	if serviceName == "" {
+		serviceName = DefaultServiceName
+	}
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(attribute.String("service.name", serviceName)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe the tracing resource: %w", err)
+	}
+
+	providerOptions := []sdktrace.TracerProviderOption{
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(opts.SampleRatio))),
+	}
+	if opts.Exporter != nil {
+		providerOptions = append(providerOptions, sdktrace.WithSyncer(opts.Exporter))
+	} else {
+		exporterOptions := []otlptracehttp.Option{otlptracehttp.WithEndpointURL(opts.Endpoint)}
+		if len(opts.Headers) > 0 {
+			exporterOptions = append(exporterOptions, otlptracehttp.WithHeaders(opts.Headers))
+		}
+		exporter, err := otlptracehttp.New(ctx, exporterOptions...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create OTLP exporter: %w", err)
+		}
+		providerOptions = append(providerOptions, sdktrace.WithBatcher(exporter))
+	}
+
+	provider := sdktrace.NewTracerProvider(providerOptions...)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	return provider.Shutdown, nil
+}
+
+// Start starts a span as a child of the span in ctx. Until Setup is called spans
+// are not recorded.
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(TracerName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End ends a span, marking it failed when err is not nil. Secrets are redacted
+// from the recorded error.
+func End(span trace.Span, err error) {
+	if err != nil {
+		err = logging.RedactError(err)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Extract returns ctx carrying the W3C trace context of an incoming HTTP request,
+// so spans started from it join the caller's trace
+func Extract(ctx context.Context, header http.Header) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, propagation.HeaderCarrier(header))
+}
+
+// Inject adds the trace context in ctx to the headers of an outgoing request
+func Inject(ctx context.Context, header http.Header) {
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(header))
+}
+
+// Handler wraps an HTTP integration's handler, continuing the trace of each request
+// in a server span. The request's context carries the span to the bot.
+func Handler(name string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := Extract(r.Context(), r.Header)
+		ctx, span := otel.Tracer(TracerName).Start(ctx, name,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", r.Method),
+				attribute.String("url.path", r.URL.Path),
+			),
+		)
+		defer span.End()
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// transport traces each request sent through it
+type transport struct {
+	service string
+	base    http.RoundTripper
+}
+
+// Transport wraps base so every request to service is traced in a client span and
+// carries the trace context
+func Transport(service string, base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{service: service, base: base}
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, span := otel.Tracer(TracerName).Start(req.Context(), fmt.Sprintf("%s %s %s", t.service, req.Method, req.URL.Path),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("peer.service", t.service),
+			attribute.String("http.request.method", req.Method),
+			attribute.String("server.address", req.URL.Host),
+			attribute.String("url.path", req.URL.Path),
+		),
+	)
+
+	req = req.Clone(ctx)
+	Inject(ctx, req.Header)
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		End(span, err)
+		return nil, err
+	}
+	span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+	if resp.StatusCode >= http.StatusBadRequest {
+		span.SetStatus(codes.Error, resp.Status)
+	}
+	span.End()
+	return resp, nil
+}
@@ -0,0 +1,133 @@
+package tracing_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/dfaile/backstage-nobl9/internal/logging"
+	"github.com/dfaile/backstage-nobl9/internal/tracing"
+	"github.com/dfaile/backstage-nobl9/internal/tracing/tracingtest"
+)
+
+func TestStartAndEnd(t *testing.T) {
+	recorder := tracingtest.NewRecorder()
+	logging.RegisterSecret("span-secret-value")
+
+	ctx, parent := tracing.Start(context.Background(), "bot.HandleMessage", attribute.String("bot.conversation_id", "cli"))
+	_, child := tracing.Start(ctx, "bot.command")
+	tracing.End(child, errors.New("apply failed with span-secret-value"))
+	tracing.End(parent, nil)
+
+	assert.Equal(t, []string{"bot.command", "bot.HandleMessage"}, recorder.Names())
+	command, ok := recorder.Span("bot.command")
+	require.True(t, ok)
+	handle, _ := recorder.Span("bot.HandleMessage")
+	assert.Equal(t, handle.SpanContext.SpanID(), command.Parent.SpanID())
+	assert.Equal(t, handle.SpanContext.TraceID(), command.SpanContext.TraceID())
+	assert.Contains(t, handle.Attributes, attribute.String("bot.conversation_id", "cli"))
+
+	assert.Equal(t, codes.Error, command.Status.Code)
+	assert.Equal(t, "apply failed with "+logging.Redacted, command.Status.Description)
+	assert.Equal(t, codes.Unset, handle.Status.Code)
+}
+
+func TestHandler(t *testing.T) {
+	recorder := tracingtest.NewRecorder()
+
+	var traceID trace.TraceID
+	handler := tracing.Handler("POST /messages", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, span := tracing.Start(r.Context(), "bot.HandleMessage")
+		traceID = span.SpanContext().TraceID()
+		span.End()
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/messages", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", traceID.String())
+	server, ok := recorder.Span("POST /messages")
+	require.True(t, ok)
+	assert.Equal(t, trace.SpanKindServer, server.SpanKind)
+	assert.Equal(t, "00f067aa0ba902b7", server.Parent.SpanID().String())
+}
+
+func TestTransport(t *testing.T) {
+	recorder := tracingtest.NewRecorder()
+
+	var traceparents []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparents = append(traceparents, r.Header.Get("traceparent"))
+		if r.URL.Path == "/api/broken" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: tracing.Transport("nobl9", nil)}
+	ctx, parent := tracing.Start(context.Background(), "bot.command")
+	for _, path := range []string{"/api/projects", "/api/broken"} {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+path, nil)
+		require.NoError(t, err)
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+	parent.End()
+
+	call, ok := recorder.Span("nobl9 GET /api/projects")
+	require.True(t, ok)
+	assert.Equal(t, trace.SpanKindClient, call.SpanKind)
+	assert.Equal(t, parent.SpanContext().SpanID(), call.Parent.SpanID())
+	assert.Contains(t, call.Attributes, attribute.Int("http.response.status_code", http.StatusOK))
+	assert.Equal(t, codes.Unset, call.Status.Code)
+
+	// The API receives the trace context of each call
+	require.Len(t, traceparents, 2)
+	assert.Contains(t, traceparents[0], call.SpanContext.TraceID().String())
+	assert.Contains(t, traceparents[0], call.SpanContext.SpanID().String())
+
+	broken, _ := recorder.Span("nobl9 GET /api/broken")
+	assert.Equal(t, codes.Error, broken.Status.Code)
+}
+
+func TestSetupExportsOTLP(t *testing.T) {
+	var mu sync.Mutex
+	var paths []string
+	var apiKeys []string
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		paths = append(paths, r.URL.Path)
+		apiKeys = append(apiKeys, r.Header.Get("X-Api-Key"))
+	}))
+	defer collector.Close()
+
+	ctx := context.Background()
+	shutdown, err := tracing.Setup(ctx, tracing.Options{
+		Endpoint:    collector.URL,
+		Headers:     map[string]string{"X-Api-Key": "collector-key"},
+		SampleRatio: 1,
+	})
+	require.NoError(t, err)
+
+	_, span := tracing.Start(ctx, "bot.HandleMessage")
+	span.End()
+	// Buffered spans are sent on shutdown
+	require.NoError(t, shutdown(ctx))
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{"/v1/traces"}, paths)
+	assert.Equal(t, []string{"collector-key"}, apiKeys)
+}
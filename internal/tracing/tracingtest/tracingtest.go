@@ -0,0 +1,43 @@
+package tracingtest
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/dfaile/backstage-nobl9/internal/tracing"
+)
+
+// Recorder keeps every span the bot ends in memory, for tests to inspect instead
+// of exporting them
+type Recorder struct {
+	*tracetest.InMemoryExporter
+}
+
+// NewRecorder installs a recorder as the exporter of every span, sampling all traces
+func NewRecorder() *Recorder {
+	exporter := tracetest.NewInMemoryExporter()
+	// Setup can only fail creating the OTLP exporter, which this replaces
+	_, _ = tracing.Setup(context.Background(), tracing.Options{SampleRatio: 1, Exporter: exporter})
+	return &Recorder{InMemoryExporter: exporter}
+}
+
+// Names returns the names of the ended spans, in the order they ended
+func (r *Recorder) Names() []string {
+	spans := r.GetSpans()
+	names := make([]string, len(spans))
+	for i, span := range spans {
+		names[i] = span.Name
+	}
+	return names
+}
+
+// Span returns the first ended span with the given name
+func (r *Recorder) Span(name string) (tracetest.SpanStub, bool) {
+	for _, span := range r.GetSpans() {
+		if span.Name == name {
+			return span, true
+		}
+	}
+	return tracetest.SpanStub{}, false
+}
@@ -0,0 +1,104 @@
+package validation
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/nobl9/nobl9-go/manifest"
+	"github.com/nobl9/nobl9-go/manifest/v1alpha"
+	"github.com/nobl9/nobl9-go/manifest/v1alpha/project"
+	"github.com/nobl9/nobl9-go/manifest/v1alpha/rolebinding"
+
+	"github.com/dfaile/backstage-nobl9/internal/config"
+	"github.com/dfaile/backstage-nobl9/internal/errors"
+)
+
+// DefaultAllowedKinds are the object kinds user-supplied manifests may contain
+var DefaultAllowedKinds = []string{"Project", "RoleBinding"}
+
+// projectRoles are the roles a manifest RoleBinding may grant
+var projectRoles = map[string]bool{
+	"project-owner":  true,
+	"project-editor": true,
+	"project-viewer": true,
+}
+
+// ManifestPolicy applies the guided flows' checks to user-supplied manifests
+type ManifestPolicy struct {
+	AllowedKinds  []string
+	ProjectNames  *ProjectNameValidator
+	LabelPolicies []config.LabelPolicy
+}
+
+// Check validates every object and returns a validation error listing each problem
+// prefixed with the object it belongs to
+func (p ManifestPolicy) Check(objects []manifest.Object) error {
+	if len(objects) == 0 {
+		return errors.NewValidationError("manifest contains no objects", nil)
+	}
+
+	allowedKinds := p.AllowedKinds
+	if len(allowedKinds) == 0 {
+		allowedKinds = DefaultAllowedKinds
+	}
+
+	var problems []string
+	for _, obj := range objects {
+		for _, problem := range p.objectProblems(obj, allowedKinds) {
+			problems = append(problems, fmt.Sprintf("%s '%s': %s", obj.GetKind(), obj.GetName(), problem))
+		}
+	}
+	if len(problems) > 0 {
+		return errors.NewValidationError(strings.Join(problems, "; "), nil)
+	}
+	return nil
+}
+
+// objectProblems returns the policy problems for a single object
+func (p ManifestPolicy) objectProblems(obj manifest.Object, allowedKinds []string) []string {
+	if !kindAllowed(obj.GetKind(), allowedKinds) {
+		return []string{fmt.Sprintf("kind %s is not allowed, allowed kinds: %s", obj.GetKind(), strings.Join(allowedKinds, ", "))}
+	}
+
+	var problems []string
+	switch o := obj.(type) {
+	case project.Project:
+		if p.ProjectNames != nil {
+			problems = append(problems, p.ProjectNames.Problems(o.Metadata.Name)...)
+		}
+		problems = append(problems, config.LabelProblems(p.LabelPolicies, flattenLabels(o.Metadata.Labels))...)
+	case rolebinding.RoleBinding:
+		if o.Spec.ProjectRef == "" {
+			problems = append(problems, "organization-level role bindings are not allowed, set projectRef")
+		}
+		if !projectRoles[o.Spec.RoleRef] {
+			problems = append(problems, fmt.Sprintf("role '%s' is not a project role, use project-owner, project-editor or project-viewer", o.Spec.RoleRef))
+		}
+	}
+
+	if err := obj.Validate(); err != nil {
+		problems = append(problems, strings.TrimSpace(err.Error()))
+	}
+	return problems
+}
+
+// kindAllowed reports whether kind is in the allowlist, ignoring case
+func kindAllowed(kind manifest.Kind, allowed []string) bool {
+	for _, name := range allowed {
+		if kind.Equals(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// flattenLabels keeps the first value of each label for policy checks
+func flattenLabels(labels v1alpha.Labels) map[string]string {
+	result := make(map[string]string, len(labels))
+	for key, values := range labels {
+		if len(values) > 0 {
+			result[key] = values[0]
+		}
+	}
+	return result
+}
@@ -0,0 +1,132 @@
+package validation_test
+
+import (
+	"testing"
+
+	"github.com/nobl9/nobl9-go/sdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dfaile/backstage-nobl9/internal/config"
+	"github.com/dfaile/backstage-nobl9/internal/errors"
+	"github.com/dfaile/backstage-nobl9/internal/validation"
+)
+
+const validManifest = `
+- apiVersion: n9/v1alpha
+  kind: Project
+  metadata:
+    name: payments-api
+    labels:
+      team: [payments]
+  spec:
+    description: Payments API
+- apiVersion: n9/v1alpha
+  kind: RoleBinding
+  metadata:
+    name: payments-api-owner
+  spec:
+    user: "00u2y4e4atkzaYkXP4x8"
+    roleRef: project-owner
+    projectRef: payments-api
+`
+
+func newManifestPolicy(t *testing.T) validation.ManifestPolicy {
+	t.Helper()
+	names, err := validation.NewProjectNameValidator(config.NamingPolicy{ReservedWords: []string{"admin"}})
+	require.NoError(t, err)
+	return validation.ManifestPolicy{
+		ProjectNames:  names,
+		LabelPolicies: []config.LabelPolicy{{Key: "team", Required: true}},
+	}
+}
+
+func TestManifestPolicyCheck(t *testing.T) {
+	objects, err := sdk.DecodeObjects([]byte(validManifest))
+	require.NoError(t, err)
+
+	assert.NoError(t, newManifestPolicy(t).Check(objects))
+}
+
+func TestManifestPolicyCheckProblems(t *testing.T) {
+	tests := []struct {
+		name     string
+		manifest string
+		wantErr  []string
+	}{
+		{
+			name: "disallowed kind",
+			manifest: `
+- apiVersion: n9/v1alpha
+  kind: Service
+  metadata:
+    name: api
+    project: payments-api
+  spec:
+    description: ""
+`,
+			wantErr: []string{"Service 'api': kind Service is not allowed, allowed kinds: Project, RoleBinding"},
+		},
+		{
+			name: "project violates naming and label policy",
+			manifest: `
+- apiVersion: n9/v1alpha
+  kind: Project
+  metadata:
+    name: admin
+  spec:
+    description: ""
+`,
+			wantErr: []string{"Project 'admin': ", "reserved", "label 'team' is required"},
+		},
+		{
+			name: "organization role",
+			manifest: `
+- apiVersion: n9/v1alpha
+  kind: RoleBinding
+  metadata:
+    name: org-admin
+  spec:
+    user: "00u2y4e4atkzaYkXP4x8"
+    roleRef: organization-admin
+`,
+			wantErr: []string{"RoleBinding 'org-admin': organization-level role bindings are not allowed", "not a project role"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			objects, err := sdk.DecodeObjects([]byte(tt.manifest))
+			require.NoError(t, err)
+
+			err = newManifestPolicy(t).Check(objects)
+			require.Error(t, err)
+			assert.True(t, errors.IsValidationError(err))
+			for _, want := range tt.wantErr {
+				assert.Contains(t, err.Error(), want)
+			}
+		})
+	}
+}
+
+func TestManifestPolicyAllowedKinds(t *testing.T) {
+	objects, err := sdk.DecodeObjects([]byte(`
+- apiVersion: n9/v1alpha
+  kind: Service
+  metadata:
+    name: api
+    project: payments-api
+  spec:
+    description: ""
+`))
+	require.NoError(t, err)
+
+	policy := validation.ManifestPolicy{AllowedKinds: []string{"project", "service"}}
+	assert.NoError(t, policy.Check(objects))
+}
+
+func TestManifestPolicyEmpty(t *testing.T) {
+	err := validation.ManifestPolicy{}.Check(nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "manifest contains no objects")
+}
@@ -0,0 +1,153 @@
+package validation
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/dfaile/backstage-nobl9/internal/config"
+	"github.com/dfaile/backstage-nobl9/internal/errors"
+)
+
+// MaxNameLength is the longest name allowed by RFC-1123 for a DNS label
+const MaxNameLength = 63
+
+// ProjectNameValidator checks project names against RFC-1123 and the org naming policy
+type ProjectNameValidator struct {
+	policy   config.NamingPolicy
+	pattern  *regexp.Regexp
+	reserved map[string]bool
+}
+
+// NewProjectNameValidator creates a validator for the given naming policy
+func NewProjectNameValidator(policy config.NamingPolicy) (*ProjectNameValidator, error) {
+	v := &ProjectNameValidator{
+		policy:   policy,
+		reserved: make(map[string]bool, len(policy.ReservedWords)),
+	}
+
+	if policy.Pattern != "" {
+		pattern, err := regexp.Compile(policy.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid project naming pattern %q: %w", policy.Pattern, err)
+		}
+		v.pattern = pattern
+	}
+
+	if policy.MaxLength < 0 || policy.MaxLength > MaxNameLength {
+		return nil, fmt.Errorf("project name max length must be between 1 and %d, got %d", MaxNameLength, policy.MaxLength)
+	}
+	if policy.MinLength < 0 || (policy.MaxLength > 0 && policy.MinLength > policy.MaxLength) {
+		return nil, fmt.Errorf("project name min length %d is not valid", policy.MinLength)
+	}
+
+	for _, word := range policy.ReservedWords {
+		v.reserved[strings.ToLower(word)] = true
+	}
+
+	return v, nil
+}
+
+// Validate checks a project name and returns a validation error listing every problem
+func (v *ProjectNameValidator) Validate(name string) error {
+	problems := v.Problems(name)
+	if len(problems) == 0 {
+		return nil
+	}
+	return errors.NewValidationError(strings.Join(problems, "; "), nil)
+}
+
+// Problems returns an actionable message for each rule the name breaks
+func (v *ProjectNameValidator) Problems(name string) []string {
+	if name == "" {
+		return []string{"project name is required"}
+	}
+
+	var problems []string
+
+	maxLength := MaxNameLength
+	if v.policy.MaxLength > 0 {
+		maxLength = v.policy.MaxLength
+	}
+	if len(name) > maxLength {
+		problems = append(problems, fmt.Sprintf("project name is %d characters long, the maximum is %d", len(name), maxLength))
+	}
+	if v.policy.MinLength > 0 && len(name) < v.policy.MinLength {
+		problems = append(problems, fmt.Sprintf("project name is %d characters long, the minimum is %d", len(name), v.policy.MinLength))
+	}
+
+	if name != strings.ToLower(name) {
+		problems = append(problems, "project name must be lowercase")
+	}
+	if invalid := invalidCharacters(strings.ToLower(name)); len(invalid) > 0 {
+		problems = append(problems, fmt.Sprintf("project name contains invalid characters %s, use only lowercase letters, numbers and '-'", strings.Join(invalid, " ")))
+	}
+	if !isAlphanumeric(name[0]) || !isAlphanumeric(name[len(name)-1]) {
+		problems = append(problems, "project name must start and end with a letter or number")
+	}
+
+	if v.reserved[strings.ToLower(name)] {
+		problems = append(problems, fmt.Sprintf("'%s' is a reserved name", name))
+	}
+
+	if v.pattern != nil && !v.pattern.MatchString(name) {
+		hint := v.policy.PatternHint
+		if hint == "" {
+			hint = v.policy.Pattern
+		}
+		problems = append(problems, fmt.Sprintf("project name must follow the naming convention %s", hint))
+	}
+
+	if len(problems) > 0 {
+		if suggestion := Normalize(name); suggestion != "" && suggestion != name && len(v.Problems(suggestion)) == 0 {
+			problems = append(problems, fmt.Sprintf("try '%s'", suggestion))
+		}
+	}
+
+	return problems
+}
+
+// Normalize converts a name to a best-effort RFC-1123 label: lowercase, with
+// runs of invalid characters replaced by a single '-'
+func Normalize(name string) string {
+	var sb strings.Builder
+	lastDash := false
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			sb.WriteRune(r)
+			lastDash = false
+			continue
+		}
+		if !lastDash {
+			sb.WriteRune('-')
+			lastDash = true
+		}
+	}
+
+	normalized := strings.Trim(sb.String(), "-")
+	if len(normalized) > MaxNameLength {
+		normalized = strings.TrimRight(normalized[:MaxNameLength], "-")
+	}
+	return normalized
+}
+
+// invalidCharacters returns the distinct characters not allowed in an RFC-1123 label
+func invalidCharacters(name string) []string {
+	seen := make(map[rune]bool)
+	var invalid []string
+	for _, r := range name {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '-' {
+			continue
+		}
+		if !seen[r] {
+			seen[r] = true
+			invalid = append(invalid, fmt.Sprintf("'%c'", r))
+		}
+	}
+	return invalid
+}
+
+// isAlphanumeric reports whether c is an ASCII letter or digit
+func isAlphanumeric(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
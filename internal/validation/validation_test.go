@@ -0,0 +1,101 @@
+package validation_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dfaile/backstage-nobl9/internal/config"
+	"github.com/dfaile/backstage-nobl9/internal/errors"
+	"github.com/dfaile/backstage-nobl9/internal/validation"
+)
+
+func TestValidateRFC1123(t *testing.T) {
+	v, err := validation.NewProjectNameValidator(config.NamingPolicy{})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		input   string
+		wantErr []string
+	}{
+		{name: "valid", input: "payments-api"},
+		{name: "digits", input: "team1-svc2"},
+		{name: "empty", input: "", wantErr: []string{"project name is required"}},
+		{name: "uppercase", input: "Payments-API", wantErr: []string{"must be lowercase", "try 'payments-api'"}},
+		{name: "invalid characters", input: "payments_api.v2", wantErr: []string{"invalid characters '_' '.'", "try 'payments-api-v2'"}},
+		{name: "leading dash", input: "-payments", wantErr: []string{"must start and end with a letter or number", "try 'payments'"}},
+		{name: "trailing dash", input: "payments-", wantErr: []string{"must start and end with a letter or number"}},
+		{name: "too long", input: strings.Repeat("a", 64), wantErr: []string{"64 characters long, the maximum is 63"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := v.Validate(tt.input)
+			if len(tt.wantErr) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.True(t, errors.IsValidationError(err))
+			for _, want := range tt.wantErr {
+				assert.Contains(t, err.Error(), want)
+			}
+		})
+	}
+}
+
+func TestValidatePolicy(t *testing.T) {
+	v, err := validation.NewProjectNameValidator(config.NamingPolicy{
+		Pattern:       `^[a-z0-9]+-[a-z0-9]+-(dev|staging|prod)$`,
+		PatternHint:   "team-service-env",
+		ReservedWords: []string{"default", "Admin"},
+		MinLength:     5,
+		MaxLength:     30,
+	})
+	require.NoError(t, err)
+
+	assert.NoError(t, v.Validate("payments-api-prod"))
+
+	err = v.Validate("payments-api")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "must follow the naming convention team-service-env")
+
+	problems := v.Problems("admin")
+	assert.Contains(t, problems, "'admin' is a reserved name")
+
+	problems = v.Problems("a-b")
+	assert.Contains(t, problems, "project name is 3 characters long, the minimum is 5")
+
+	problems = v.Problems("payments-api-" + strings.Repeat("x", 20))
+	assert.Contains(t, problems, "project name is 33 characters long, the maximum is 30")
+}
+
+func TestValidatePatternHintFallback(t *testing.T) {
+	v, err := validation.NewProjectNameValidator(config.NamingPolicy{Pattern: `^team-`})
+	require.NoError(t, err)
+
+	err = v.Validate("payments")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "naming convention ^team-")
+}
+
+func TestNewProjectNameValidatorInvalidPolicy(t *testing.T) {
+	_, err := validation.NewProjectNameValidator(config.NamingPolicy{Pattern: "("})
+	assert.Error(t, err)
+
+	_, err = validation.NewProjectNameValidator(config.NamingPolicy{MaxLength: 100})
+	assert.Error(t, err)
+
+	_, err = validation.NewProjectNameValidator(config.NamingPolicy{MinLength: 10, MaxLength: 5})
+	assert.Error(t, err)
+}
+
+func TestNormalize(t *testing.T) {
+	assert.Equal(t, "payments-api", validation.Normalize("Payments API"))
+	assert.Equal(t, "a-b", validation.Normalize("--a__b--"))
+	assert.Equal(t, "", validation.Normalize("___"))
+	assert.Len(t, validation.Normalize(strings.Repeat("a", 80)), 63)
+}
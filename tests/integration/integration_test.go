@@ -1,7 +1,6 @@
 package integration_test
 
 import (
-	"context"
 	"fmt"
 	"os"
 	"testing"
@@ -9,187 +8,75 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
 	"github.com/dfaile/backstage-nobl9/internal/bot"
+	"github.com/dfaile/backstage-nobl9/internal/identity"
 	"github.com/dfaile/backstage-nobl9/internal/nobl9"
-	"github.com/dfaile/backstage-nobl9/internal/logging"
 )
 
+// The tests talk to a live Nobl9 organization with the SDK's credentials. They
+// only plan changes, so nothing is left behind in the organization.
 var (
-	apiKey = os.Getenv("NOBL9_API_KEY")
-	org    = os.Getenv("NOBL9_ORG")
-	baseURL = os.Getenv("NOBL9_BASE_URL")
+	clientID     = os.Getenv("NOBL9_SDK_CLIENT_ID")
+	clientSecret = os.Getenv("NOBL9_SDK_CLIENT_SECRET")
+	org          = os.Getenv("NOBL9_ORG")
+	baseURL      = os.Getenv("NOBL9_BASE_URL")
+	email        = os.Getenv("NOBL9_TEST_EMAIL") // Nobl9 user the planned projects are owned by
 )
 
 func TestMain(m *testing.M) {
-	if apiKey == "" || org == "" || baseURL == "" {
-		os.Exit(0) // Skip tests if environment variables are not set
+	if clientID == "" || clientSecret == "" {
+		os.Exit(0) // Skip tests if the credentials are not set
 	}
 	os.Exit(m.Run())
 }
 
-func setupTest(t *testing.T) (*bot.Bot, func()) {
-	logger, err := logging.NewLogger(logging.LevelDebug)
-	require.NoError(t, err)
-
-	client, err := nobl9.NewClient(apiKey, org, baseURL)
-	require.NoError(t, err)
-
-	bot := bot.NewBot(client, logger)
-
-	// Cleanup function to delete test projects
-	cleanup := func() {
-		ctx := context.Background()
-		projects := []string{"test-project", "new-project"}
-		for _, name := range projects {
-			_ = client.DeleteProject(ctx, name)
-		}
-	}
-
-	return bot, cleanup
-}
-
-func TestProjectCreation(t *testing.T) {
-	bot, cleanup := setupTest(t)
-	defer cleanup()
-
-	// Test project creation flow
-	conv, err := bot.StartConversation("test-user")
-	require.NoError(t, err)
-
-	// Test command-based creation
-	response, err := bot.HandleMessage(conv.ID, "/create test-project")
-	require.NoError(t, err)
-	assert.Contains(t, response, "Please provide a display name for the project")
-
-	response, err = bot.HandleMessage(conv.ID, "Test Project")
-	require.NoError(t, err)
-	assert.Contains(t, response, "Project created successfully")
-
-	// Test interactive creation
-	response, err = bot.HandleMessage(conv.ID, "I want to create a project")
-	require.NoError(t, err)
-	assert.Contains(t, response, "What would you like to name your project?")
-
-	response, err = bot.HandleMessage(conv.ID, "new-project")
-	require.NoError(t, err)
-	assert.Contains(t, response, "Please provide a display name for the project")
-
-	response, err = bot.HandleMessage(conv.ID, "New Project")
+func setupTest(t *testing.T) *bot.Bot {
+	t.Helper()
+	client, err := nobl9.NewClient(clientID, clientSecret, org, baseURL)
 	require.NoError(t, err)
-	assert.Contains(t, response, "Project created successfully")
+	return bot.NewBot(client, nil)
 }
 
-func TestRoleManagement(t *testing.T) {
-	bot, cleanup := setupTest(t)
-	defer cleanup()
-
-	// Create a test project first
-	conv, err := bot.StartConversation("test-user")
-	require.NoError(t, err)
-
-	response, err := bot.HandleMessage(conv.ID, "/create test-project")
-	require.NoError(t, err)
-	response, err = bot.HandleMessage(conv.ID, "Test Project")
-	require.NoError(t, err)
-
-	// Test role assignment
-	response, err = bot.HandleMessage(conv.ID, "/assign test-project test@example.com")
-	require.NoError(t, err)
-	assert.Contains(t, response, "Please specify the roles to assign")
-
-	response, err = bot.HandleMessage(conv.ID, "admin,member")
-	require.NoError(t, err)
-	assert.Contains(t, response, "Roles assigned successfully")
-
-	// Test interactive role assignment
-	response, err = bot.HandleMessage(conv.ID, "I want to assign roles")
-	require.NoError(t, err)
-	assert.Contains(t, response, "Which project would you like to assign roles for?")
-
-	response, err = bot.HandleMessage(conv.ID, "test-project")
-	require.NoError(t, err)
-	assert.Contains(t, response, "Which user would you like to assign roles to?")
-
-	response, err = bot.HandleMessage(conv.ID, "another@example.com")
-	require.NoError(t, err)
-	assert.Contains(t, response, "Please specify the roles to assign")
+func TestListProjects(t *testing.T) {
+	b := setupTest(t)
+	caller := identity.Caller{ID: "integration", Source: identity.SourceCLI}
 
-	response, err = bot.HandleMessage(conv.ID, "member")
+	response, err := b.HandleMessage(caller, "list", "list-projects")
 	require.NoError(t, err)
-	assert.Contains(t, response, "Roles assigned successfully")
+	assert.NotEmpty(t, response)
 }
 
-func TestErrorHandling(t *testing.T) {
-	bot, cleanup := setupTest(t)
-	defer cleanup()
-
-	conv, err := bot.StartConversation("test-user")
-	require.NoError(t, err)
-
-	// Test invalid command
-	response, err := bot.HandleMessage(conv.ID, "/invalid")
-	require.NoError(t, err)
-	assert.Contains(t, response, "Unknown command")
-
-	// Test invalid project name
-	response, err = bot.HandleMessage(conv.ID, "/create invalid project")
-	require.NoError(t, err)
-	assert.Contains(t, response, "Invalid project name")
+func TestPlanProject(t *testing.T) {
+	if email == "" {
+		t.Skip("NOBL9_TEST_EMAIL is not set")
+	}
+	b := setupTest(t)
+	caller := identity.Caller{ID: "integration", Email: email, Source: identity.SourceCLI}
+	name := fmt.Sprintf("bot-integration-%d", time.Now().Unix())
 
-	// Test duplicate project
-	response, err = bot.HandleMessage(conv.ID, "/create test-project")
-	require.NoError(t, err)
-	response, err = bot.HandleMessage(conv.ID, "Test Project")
+	response, err := b.HandleMessage(caller, "plan", "plan on")
 	require.NoError(t, err)
+	assert.Contains(t, response, "Plan mode is on")
 
-	response, err = bot.HandleMessage(conv.ID, "/create test-project")
+	response, err = b.HandleMessage(caller, "plan", fmt.Sprintf("create-project %s --description %q", name, "Integration test"))
 	require.NoError(t, err)
-	response, err = bot.HandleMessage(conv.ID, "Test Project")
-	require.NoError(t, err)
-	assert.Contains(t, response, "Project already exists")
+	assert.Contains(t, response, "plan only, nothing will be applied")
 
-	// Test invalid user email
-	response, err = bot.HandleMessage(conv.ID, "/assign test-project invalid-email")
+	response, err = b.HandleMessage(caller, "plan", "yes")
 	require.NoError(t, err)
-	assert.Contains(t, response, "Invalid email address")
+	assert.Contains(t, response, "📝 Plan: 2 object(s) would be applied. Nothing was changed.")
+	assert.Contains(t, response, "✅ Server-side validation passed.")
 }
 
-func TestRateLimiting(t *testing.T) {
-	bot, cleanup := setupTest(t)
-	defer cleanup()
+func TestCommandErrors(t *testing.T) {
+	b := setupTest(t)
+	caller := identity.Caller{ID: "integration", Source: identity.SourceCLI}
 
-	conv, err := bot.StartConversation("test-user")
-	require.NoError(t, err)
+	// Creating a project needs a verified email for its owner
+	_, err := b.HandleMessage(caller, "errors", "create-project bot-integration")
+	assert.Error(t, err)
 
-	// Send multiple requests in quick succession
-	for i := 0; i < 10; i++ {
-		response, err := bot.HandleMessage(conv.ID, "/list")
-		require.NoError(t, err)
-		assert.NotEmpty(t, response)
-		time.Sleep(100 * time.Millisecond)
-	}
+	_, err = b.HandleMessage(caller, "errors", "assign-role --role unknown bot-integration someone@example.com")
+	assert.Error(t, err)
 }
-
-func TestInteractiveFeatures(t *testing.T) {
-	bot, cleanup := setupTest(t)
-	defer cleanup()
-
-	conv, err := bot.StartConversation("test-user")
-	require.NoError(t, err)
-
-	// Test help command
-	response, err := bot.HandleMessage(conv.ID, "/help")
-	require.NoError(t, err)
-	assert.Contains(t, response, "Available commands")
-
-	// Test list command
-	response, err = bot.HandleMessage(conv.ID, "/list")
-	require.NoError(t, err)
-	assert.Contains(t, response, "No projects found")
-
-	// Test conversation timeout
-	time.Sleep(30 * time.Minute)
-	response, err = bot.HandleMessage(conv.ID, "/list")
-	require.NoError(t, err)
-	assert.Contains(t, response, "Conversation expired")
-} 
\ No newline at end of file